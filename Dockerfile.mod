@@ -1,4 +1,4 @@
-FROM golang:1.12-alpine
+FROM golang:1.21-alpine
 
 RUN apk add --no-cache git
 
@@ -19,6 +19,7 @@ RUN go build -o ./out/go-ip2proxy-api .
 
 # This container exposes port 8080 to the outside world
 EXPOSE 8443
+EXPOSE 9443
 
 # Run the binary program produced by `go install`
 CMD ["./out/go-ip2proxy-api"]
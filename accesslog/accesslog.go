@@ -0,0 +1,258 @@
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nullc0rp/go-ip2proxy-api/clientip"
+	"github.com/nullc0rp/go-ip2proxy-api/logging"
+	"github.com/nullc0rp/go-ip2proxy-api/privacy"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	COMMON   = "common"
+	COMBINED = "combined"
+	JSON     = "json"
+	STDOUT   = "stdout"
+	STDERR   = "stderr"
+	//CLFTIME is the timestamp of the Common Log Format
+	CLFTIME = "02/Jan/2006:15:04:05 -0700"
+	EMPTY   = "-"
+	//Result classes of the lookups
+	RESULTPROXY    = "proxy"
+	RESULTNOTPROXY = "not_proxy"
+	RESULTSTALE    = "stale"
+	RESULTERROR    = "error"
+	//Rotation defaults of the log file
+	DEFAULTMAXSIZEMB  = 100
+	DEFAULTMAXBACKUPS = 10
+	BADFORMAT         = "Unknown access log format %q, it must be common, combined or json"
+)
+
+// Options configures the access log
+type Options struct {
+	//Format is common, combined or json, combined by default
+	Format string
+	//Output is stdout, stderr or the path of a file rotated by size, stdout by default
+	Output string
+	//MaxSizeMB is the size a file is rotated at, MaxBackups and MaxAgeDays limit the rotated files kept
+	MaxSizeMB  int
+	MaxBackups int
+	MaxAgeDays int
+	//Resolver finds the client address behind the trusted proxies
+	Resolver *clientip.Resolver
+	//Anonymizer rewrites the client address and the path, nil keeps them
+	Anonymizer *privacy.Anonymizer
+}
+
+// Logger writes one record per request
+type Logger struct {
+	format     string
+	mutex      sync.Mutex
+	writer     io.Writer
+	resolver   *clientip.Resolver
+	anonymizer *privacy.Anonymizer
+}
+
+// Record is a served request, the JSON format writes it as is
+type Record struct {
+	Time      string  `json:"time"`
+	RequestID string  `json:"request_id,omitempty"`
+	ClientIP  string  `json:"client_ip"`
+	Method    string  `json:"method"`
+	Path      string  `json:"path"`
+	Route     string  `json:"route,omitempty"`
+	Protocol  string  `json:"protocol"`
+	Status    int     `json:"status"`
+	Bytes     int     `json:"bytes"`
+	Duration  float64 `json:"duration_ms"`
+	Referer   string  `json:"referer,omitempty"`
+	UserAgent string  `json:"user_agent,omitempty"`
+	Result    string  `json:"result,omitempty"`
+	start     time.Time
+}
+
+// New opens the output of the access log
+func New(options Options) (*Logger, error) {
+	switch options.Format {
+	case "":
+		options.Format = COMBINED
+	case COMMON, COMBINED, JSON:
+	default:
+		return nil, fmt.Errorf(BADFORMAT, options.Format)
+	}
+
+	var writer io.Writer
+	switch options.Output {
+	case "", STDOUT:
+		writer = os.Stdout
+	case STDERR:
+		writer = os.Stderr
+	default:
+		if options.MaxSizeMB == 0 {
+			options.MaxSizeMB = DEFAULTMAXSIZEMB
+		}
+		if options.MaxBackups == 0 {
+			options.MaxBackups = DEFAULTMAXBACKUPS
+		}
+		//Records of users are not kept forever in privacy mode
+		if options.MaxAgeDays == 0 && options.Anonymizer.Enabled() {
+			options.MaxAgeDays = privacy.DEFAULTRETENTIONDAYS
+		}
+		writer = &lumberjack.Logger{
+			Filename:   options.Output,
+			MaxSize:    options.MaxSizeMB,
+			MaxBackups: options.MaxBackups,
+			MaxAge:     options.MaxAgeDays,
+		}
+	}
+	return NewWriter(options.Format, writer, options.Resolver).Anonymize(options.Anonymizer), nil
+}
+
+// NewWriter writes the records to the writer, for tests
+func NewWriter(format string, writer io.Writer, resolver *clientip.Resolver) *Logger {
+	if resolver == nil {
+		resolver = &clientip.Resolver{}
+	}
+	return &Logger{format: format, writer: writer, resolver: resolver}
+}
+
+// Anonymize rewrites the client address and the path of the records
+func (l *Logger) Anonymize(anonymizer *privacy.Anonymizer) *Logger {
+	l.anonymizer = anonymizer
+	return l
+}
+
+type contextKey struct{}
+
+// SetResult tells the access log the class of the lookup the request made. See Classify
+func SetResult(ctx context.Context, result string) {
+	if record, ok := ctx.Value(contextKey{}).(*Record); ok {
+		record.Result = result
+	}
+}
+
+// Classify names the outcome of a lookup: a proxy, not a proxy, a proxy not seen lately or a failure
+func Classify(data *service.IPData, err error) string {
+	var stale *service.StaleError
+	switch {
+	case errors.As(err, &stale):
+		return RESULTSTALE
+	case service.IsNoResult(err):
+		return RESULTNOTPROXY
+	case err != nil:
+		return RESULTERROR
+	case data == nil:
+		return RESULTNOTPROXY
+	}
+	return RESULTPROXY
+}
+
+// Middleware writes the record of every request once it is served, it can be used directly as a mux middleware
+func (l *Logger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		record := &Record{start: time.Now()}
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), contextKey{}, record)))
+
+		record.Duration = float64(time.Since(record.start).Microseconds()) / 1000
+		record.Status = recorder.status
+		record.Bytes = recorder.bytes
+		l.write(r, record)
+	})
+}
+
+// write fills in the request side of the record and writes it in a single line
+func (l *Logger) write(r *http.Request, record *Record) {
+	record.Time = record.start.Format(time.RFC3339Nano)
+	if ip, _ := l.resolver.Resolve(r); ip != nil {
+		record.ClientIP = l.anonymizer.IP(ip)
+	}
+	if request, ok := logging.FromContext(r.Context()); ok {
+		record.RequestID = request.ID
+	}
+	if current := mux.CurrentRoute(r); current != nil {
+		record.Route, _ = current.GetPathTemplate()
+	}
+	record.Method = r.Method
+	record.Path = l.anonymizer.String(r.URL.RequestURI())
+	record.Protocol = r.Proto
+	record.Referer = l.anonymizer.String(r.Referer())
+	record.UserAgent = l.anonymizer.String(r.UserAgent())
+
+	var line bytes.Buffer
+	switch l.format {
+	case JSON:
+		jData, err := json.Marshal(record)
+		if err != nil {
+			return
+		}
+		line.Write(jData)
+	default:
+		l.writeCLF(&line, record)
+	}
+	line.WriteString("\n")
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.writer.Write(line.Bytes())
+}
+
+// writeCLF writes the Common or Combined Log Format, followed by the latency in milliseconds and the result class
+func (l *Logger) writeCLF(line *bytes.Buffer, record *Record) {
+	bytesSent := EMPTY
+	if record.Bytes > 0 {
+		bytesSent = fmt.Sprint(record.Bytes)
+	}
+	fmt.Fprintf(line, "%s - - [%s] %q %d %s",
+		orEmpty(record.ClientIP), record.start.Format(CLFTIME),
+		record.Method+" "+record.Path+" "+record.Protocol, record.Status, bytesSent)
+	if l.format == COMBINED {
+		fmt.Fprintf(line, " %q %q", orEmpty(record.Referer), orEmpty(record.UserAgent))
+	}
+	fmt.Fprintf(line, " %.3f %s", record.Duration, orEmpty(record.Result))
+}
+
+func orEmpty(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return EMPTY
+	}
+	return value
+}
+
+// responseRecorder counts the status and bytes written by the handler, streamed lists still get flushed
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (s *responseRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *responseRecorder) Write(data []byte) (int, error) {
+	written, err := s.ResponseWriter.Write(data)
+	s.bytes += written
+	return written, err
+}
+
+func (s *responseRecorder) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,101 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/nullc0rp/go-ip2proxy-api/clientip"
+	"github.com/nullc0rp/go-ip2proxy-api/logging"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+	"github.com/stretchr/testify/assert"
+)
+
+// router serves a lookup telling its result class, behind a trusted proxy
+func router(l *Logger) *mux.Router {
+	r := mux.NewRouter()
+	r.HandleFunc("/v2/ip/{address}", func(w http.ResponseWriter, r *http.Request) {
+		SetResult(r.Context(), Classify(&service.IPData{ProxyType: "VPN"}, nil))
+		w.Write([]byte("{\"found\":true}"))
+	})
+	r.HandleFunc("/v2/country/{country}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	r.Use(logging.Middleware)
+	r.Use(l.Middleware)
+	return r
+}
+
+func request(path string) *http.Request {
+	r := httptest.NewRequest("GET", path, nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "203.0.113.7")
+	r.Header.Set("User-Agent", "curl/8.0")
+	r.Header.Set(logging.REQUESTIDHEADER, "abc-123")
+	return r
+}
+
+func TestCombined(t *testing.T) {
+
+	var buffer bytes.Buffer
+	l := NewWriter(COMBINED, &buffer, clientip.NewResolver([]string{"10.0.0.0/8"}))
+	r := router(l)
+
+	r.ServeHTTP(httptest.NewRecorder(), request("/v2/ip/1.2.3.4?fields=proxy_type"))
+	r.ServeHTTP(httptest.NewRecorder(), request("/v2/country/ar"))
+
+	lines := bytes.Split(bytes.TrimSpace(buffer.Bytes()), []byte("\n"))
+	assert.Equal(t, 2, len(lines))
+
+	//The client behind the proxy, the bytes sent, the latency and the result class
+	assert.Regexp(t, regexp.MustCompile(`^203\.0\.113\.7 - - \[[^\]]+\] "GET /v2/ip/1\.2\.3\.4\?fields=proxy_type HTTP/1\.1" 200 14 "-" "curl/8\.0" [0-9]+\.[0-9]{3} proxy$`), string(lines[0]))
+	assert.Regexp(t, regexp.MustCompile(`^203\.0\.113\.7 - - \[[^\]]+\] "GET /v2/country/ar HTTP/1\.1" 400 - "-" "curl/8\.0" [0-9]+\.[0-9]{3} -$`), string(lines[1]))
+}
+
+func TestCommonAndJSON(t *testing.T) {
+
+	var buffer bytes.Buffer
+	router(NewWriter(COMMON, &buffer, nil)).ServeHTTP(httptest.NewRecorder(), request("/v2/ip/1.2.3.4"))
+
+	//Without trusted proxies the direct hop is the client
+	assert.Regexp(t, regexp.MustCompile(`^10\.0\.0\.1 - - \[[^\]]+\] "GET /v2/ip/1\.2\.3\.4 HTTP/1\.1" 200 14 [0-9]+\.[0-9]{3} proxy\n$`), buffer.String())
+
+	buffer.Reset()
+	router(NewWriter(JSON, &buffer, nil)).ServeHTTP(httptest.NewRecorder(), request("/v2/ip/1.2.3.4"))
+
+	record := Record{}
+	assert.Nil(t, json.Unmarshal(buffer.Bytes(), &record))
+	assert.Equal(t, "abc-123", record.RequestID)
+	assert.Equal(t, "10.0.0.1", record.ClientIP)
+	assert.Equal(t, "/v2/ip/{address}", record.Route)
+	assert.Equal(t, 200, record.Status)
+	assert.Equal(t, 14, record.Bytes)
+	assert.Equal(t, "curl/8.0", record.UserAgent)
+	assert.Equal(t, RESULTPROXY, record.Result)
+}
+
+func TestClassify(t *testing.T) {
+
+	assert.Equal(t, RESULTPROXY, Classify(&service.IPData{}, nil))
+	assert.Equal(t, RESULTNOTPROXY, Classify(nil, service.NoResultError("query")))
+	assert.Equal(t, RESULTSTALE, Classify(nil, &service.StaleError{LastSeen: "45"}))
+	assert.Equal(t, RESULTERROR, Classify(nil, errors.New("connection refused")))
+}
+
+func TestNewRotatingFile(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "access.log")
+	l, err := New(Options{Format: JSON, Output: path})
+	assert.Nil(t, err)
+	router(l).ServeHTTP(httptest.NewRecorder(), request("/v2/ip/1.2.3.4"))
+	assert.FileExists(t, path)
+
+	_, err = New(Options{Format: "apache"})
+	assert.Equal(t, "Unknown access log format \"apache\", it must be common, combined or json", err.Error())
+}
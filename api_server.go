@@ -8,6 +8,8 @@ package main
 import (
 	"context"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -15,11 +17,31 @@ import (
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
-	"github.com/gorilla/mux"
+	"github.com/nullc0rp/go-ip2proxy-api/accesslog"
+	"github.com/nullc0rp/go-ip2proxy-api/cache"
+	"github.com/nullc0rp/go-ip2proxy-api/clientip"
 	"github.com/nullc0rp/go-ip2proxy-api/config"
 	"github.com/nullc0rp/go-ip2proxy-api/controller"
 	"github.com/nullc0rp/go-ip2proxy-api/database"
+	"github.com/nullc0rp/go-ip2proxy-api/graphqlapi"
+	"github.com/nullc0rp/go-ip2proxy-api/grpcserver"
+	"github.com/nullc0rp/go-ip2proxy-api/index"
+	"github.com/nullc0rp/go-ip2proxy-api/logging"
+	"github.com/nullc0rp/go-ip2proxy-api/metrics"
+	"github.com/nullc0rp/go-ip2proxy-api/openapi"
+	"github.com/nullc0rp/go-ip2proxy-api/privacy"
+	"github.com/nullc0rp/go-ip2proxy-api/probes"
 	"github.com/nullc0rp/go-ip2proxy-api/service"
+	"github.com/nullc0rp/go-ip2proxy-api/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+)
+
+const (
+	CERTFILE        = "./config/certs/server.crt"
+	KEYFILE         = "./config/certs/server.key"
+	DEFAULTGRPCPORT = "9443"
 )
 
 func main() {
@@ -27,41 +49,192 @@ func main() {
 	//Get configuration
 	configuration := config.GetConfig()
 
+	//Privacy mode rewrites the addresses of users before they are written anywhere
+	anonymizer, err := privacy.New(configuration.PRIVACYMODE, configuration.PRIVACYSALT)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	//JSON logs, every package at its configured level. The standard log lines go through the main logger
+	loggers, err := logging.New(logging.Config{
+		Level:      configuration.LOGLEVEL,
+		Output:     configuration.LOGOUTPUT,
+		Packages:   configuration.LOGPACKAGES,
+		Anonymizer: anonymizer,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.SetDefault(loggers.For("main"))
+
+	//Send traces to the collector when there is one, the trace context is always propagated
+	shutdownTracing := func(context.Context) error { return nil }
+	tracing.Propagate()
+	if configuration.OTLPENDPOINT != "" {
+		shutdown, err := tracing.Setup(context.Background(), configuration.OTLPENDPOINT, configuration.OTLPINSECURE)
+		if err != nil {
+			log.Fatal(err)
+		}
+		shutdownTracing = shutdown
+	}
+
 	//Define services
 	var serviceInstance service.Service
 	var controllerInstance controller.Controller
+	var controllerV2Instance controller.ControllerV2
 	var databaseInstance database.Database
 
 	//Create database connection TODO: use env variables
-	databaseInstance = &database.DatabaseImpl{
-		Server:   configuration.DBHOST,
-		User:     configuration.DBUSERNAME,
-		Password: configuration.DBPASSWORD,
-		Database: configuration.DBNAME,
+	databaseImpl := &database.DatabaseImpl{
+		Server:     configuration.DBHOST,
+		User:       configuration.DBUSERNAME,
+		Password:   configuration.DBPASSWORD,
+		Database:   configuration.DBNAME,
+		Logger:     loggers.For("database"),
+		Anonymizer: anonymizer,
 	}
+	databaseInstance = databaseImpl
 
 	//Start connection. If it fails, the server should not be operational
 	databaseInstance.Connect()
 
-	//Instance Service
-	serviceInstance = &service.ServiceImp{
-		DB: databaseInstance,
+	//Metrics of every layer, served on /metrics
+	metricsInstance := metrics.New()
+	metricsInstance.WatchDB(configuration.DBNAME, databaseImpl.Connection)
+
+	//Probes of the orchestrator, not ready until the dataset is reported
+	checker := &probes.Checker{DB: databaseImpl.Connection, Logger: loggers.For("probes")}
+
+	//Detect the package tier of the table, the PX7 columns are assumed when it can't be read
+	columns, err := service.DetectColumns(databaseInstance)
+	if err != nil {
+		log.Println("Could not detect the dataset columns, assuming PX7:", err)
+	}
+
+	//Instance Service, timed by the metrics
+	serviceImpl := &service.ServiceImp{
+		DB:      databaseInstance,
+		Columns: columns,
+		Logger:  loggers.For("service"),
+		Version: configuration.DATASETVERSION,
+	}
+	serviceInstance = metricsInstance.Service(serviceImpl)
+
+	//Report the loaded dataset, the gauges stay empty when it can't be read
+	version, err := serviceInstance.DatasetVersion()
+	if err != nil {
+		log.Println("Could not read the dataset version:", err)
+	}
+	rows, err := service.CountRows(databaseInstance)
+	if err != nil {
+		log.Println("Could not count the dataset rows:", err)
+	}
+	if version != "" {
+		metricsInstance.SetDataset(version, time.Now(), rows)
+	}
+	checker.SetDataset(version, time.Now(), rows)
+
+	//Answer the lookups from the index file, only when it is intact and of the loaded dataset
+	if configuration.INDEXFILE != "" {
+		lookupIndex, err := index.Open(configuration.INDEXFILE)
+		if err == nil {
+			//Tables of an undetected tier are read as PX7
+			fields := columns
+			if fields == nil {
+				fields = service.PX7FIELDS
+			}
+			err = lookupIndex.Check(version, fields)
+		}
+		if err != nil {
+			log.Println("Not using the lookup index:", err)
+		} else {
+			log.Println("Using the lookup index of", lookupIndex.Len(), "ranges")
+			serviceInstance = lookupIndex.Service(serviceInstance)
+		}
+	}
+
+	//Answer the addresses that are not in the dataset from memory, the filter is read from the table without the metrics
+	if configuration.NEGATIVEFILTER {
+		negatives := cache.NewNegatives(serviceImpl)
+		negatives.Rebuild()
+		metricsInstance.WatchCache("negatives", negatives)
+		serviceInstance = negatives.Service(serviceInstance)
+	}
+
+	//Answer the repeated lookups from memory, only the queries that reach the database are timed
+	if configuration.LOOKUPCACHESIZE >= 0 {
+		lookups := cache.NewLookups(cache.Options{
+			Size:         configuration.LOOKUPCACHESIZE,
+			TTL:          time.Duration(configuration.LOOKUPCACHETTLSECONDS) * time.Second,
+			NegativeTTL:  time.Duration(configuration.LOOKUPCACHENEGATIVETTLSECONDS) * time.Second,
+			VersionCheck: time.Duration(configuration.DATASETCHECKSECONDS) * time.Second,
+		})
+		metricsInstance.WatchCache("lookups", lookups)
+		serviceInstance = lookups.Service(serviceInstance)
 	}
 
 	//Instance Controller
 	controllerInstance = &controller.ControllerImpl{
-		Service: serviceInstance,
+		Service:  serviceInstance,
+		Resolver: clientip.NewResolver(configuration.TRUSTEDPROXIES),
+		Logger:   loggers.For("controller"),
+	}
+
+	//Instance v2 Controller on the same service layer
+	controllerV2Instance = &controller.ControllerV2Impl{
+		Service:  serviceInstance,
+		Resolver: clientip.NewResolver(configuration.TRUSTEDPROXIES),
+		Logger:   loggers.For("controller"),
+		Version: &controller.DatasetVersion{
+			Service:  serviceInstance,
+			Interval: time.Duration(configuration.DATASETCHECKSECONDS) * time.Second,
+		},
+	}
+
+	//Instance GraphQL handler
+	graphqlHandler, err := graphqlapi.NewHandler(serviceInstance)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	//Create Server and Route Handlers
-	r := mux.NewRouter()
+	//Create Server and Route Handlers, the REST paths of both versions are defined by the controller
+	r := controller.NewRouter(controllerInstance, controllerV2Instance)
 
 	//Define paths
-	r.HandleFunc("/ip/{address:.*}", controllerInstance.GetIpInfo).Methods("GET")
-	r.HandleFunc("/country/{country:[A-Z]+}", controllerInstance.GetIpList).Methods("GET")
-	r.HandleFunc("/country/{country:[A-Z]+}/isp", controllerInstance.GetISPCountry).Methods("GET")
-	r.HandleFunc("/country/{country:[A-Z]+}/total", controllerInstance.GetIPTotalCountry).Methods("GET")
-	r.HandleFunc("/proxytypes", controllerInstance.GetMostProxyTypes).Methods("GET")
+	r.Handle("/graphql", graphqlHandler).Methods("GET", "POST")
+	r.HandleFunc("/openapi.json", openapi.ServeSpec).Methods("GET")
+	r.HandleFunc("/docs", openapi.ServeDocs).Methods("GET")
+	r.Handle("/metrics", metricsInstance.Handler()).Methods("GET")
+	r.HandleFunc("/healthz", checker.Healthz).Methods("GET")
+	r.HandleFunc("/readyz", checker.Readyz).Methods("GET")
+	r.HandleFunc("/status", checker.Status).Methods("GET")
+	r.Use(metricsInstance.Middleware)
+	r.Use(tracing.Middleware(anonymizer))
+	r.Use(logging.Middleware)
+
+	//One record per request, with the client behind the trusted proxies
+	accessLog, err := accesslog.New(accesslog.Options{
+		Format:     configuration.ACCESSLOGFORMAT,
+		Output:     configuration.ACCESSLOGOUTPUT,
+		MaxSizeMB:  configuration.ACCESSLOGMAXSIZEMB,
+		MaxBackups: configuration.ACCESSLOGMAXBACKUPS,
+		MaxAgeDays: configuration.ACCESSLOGMAXAGEDAYS,
+		Resolver:   clientip.NewResolver(configuration.TRUSTEDPROXIES),
+		Anonymizer: anonymizer,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	r.Use(accessLog.Middleware)
+
+	//Validate responses against the OpenAPI document, for test environments
+	if configuration.OPENAPIVALIDATION {
+		validator, err := openapi.NewValidator()
+		if err != nil {
+			log.Fatal(err)
+		}
+		r.Use(validator.Middleware)
+	}
 
 	//Create http service TODO: use config variables
 	srv := &http.Server{
@@ -71,19 +244,42 @@ func main() {
 		WriteTimeout: 10 * time.Second,
 	}
 
+	//Create gRPC service on the same service layer and certificates
+	creds, err := credentials.NewServerTLSFromFile(CERTFILE, KEYFILE)
+	if err != nil {
+		log.Fatal(err)
+	}
+	grpcServer, healthServer := grpcserver.NewGRPCServer(serviceInstance, creds)
+	grpcPort := configuration.GRPCPORT
+	if grpcPort == "" {
+		grpcPort = DEFAULTGRPCPORT
+	}
+
 	// Start Server
 	go func() {
 		log.Println("Starting Server")
-		if err := srv.ListenAndServeTLS("./config/certs/server.crt", "./config/certs/server.key"); err != nil {
+		if err := srv.ListenAndServeTLS(CERTFILE, KEYFILE); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	// Start gRPC Server
+	go func() {
+		listener, err := net.Listen("tcp", ":"+grpcPort)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Println("Starting gRPC Server on port", grpcPort)
+		if err := grpcServer.Serve(listener); err != nil {
 			log.Fatal(err)
 		}
 	}()
 
 	// Graceful Shutdown
-	waitForShutdown(srv)
+	waitForShutdown(srv, grpcServer, healthServer, shutdownTracing)
 }
 
-func waitForShutdown(srv *http.Server) {
+func waitForShutdown(srv *http.Server, grpcServer *grpc.Server, healthServer *health.Server, shutdownTracing func(context.Context) error) {
 	interruptChan := make(chan os.Signal, 1)
 	signal.Notify(interruptChan, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 
@@ -93,7 +289,14 @@ func waitForShutdown(srv *http.Server) {
 	// Create a deadline to wait for.
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
+
+	// Health checks fail first so balancers stop sending traffic
+	healthServer.Shutdown()
 	srv.Shutdown(ctx)
+	grpcServer.GracefulStop()
+
+	// Send the spans still pending
+	shutdownTracing(ctx)
 
 	log.Println("Shutting down")
 	os.Exit(0)
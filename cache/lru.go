@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+)
+
+// LRU is a size bounded cache where entries also expire after a while.
+// A nil value is a valid entry, it caches addresses that are not in the dataset.
+type LRU struct {
+	mutex   sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[string]*list.Element
+	hits    uint64
+	misses  uint64
+}
+
+// Result is a cached lookup, the data found or the error answered instead, like a no result
+type Result struct {
+	Data *service.IPData
+	Err  error
+}
+
+// Stats are the lookups answered by a cache so far
+type Stats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int
+}
+
+type lruEntry struct {
+	key     string
+	result  Result
+	expires time.Time
+}
+
+// NewLRU creates a cache holding up to size entries for ttl
+func NewLRU(size int, ttl time.Duration) *LRU {
+	return &LRU{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get returns the cached data, a nil value with ok is a cached "not found"
+func (c *LRU) Get(key string) (*service.IPData, bool) {
+	result, ok := c.GetResult(key)
+	return result.Data, ok
+}
+
+// GetResult returns the cached lookup
+func (c *LRU) GetResult(key string) (Result, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return Result{}, false
+	}
+	entry := element.Value.(*lruEntry)
+	if time.Now().After(entry.expires) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		c.misses++
+		return Result{}, false
+	}
+	c.order.MoveToFront(element)
+	c.hits++
+	return entry.result, true
+}
+
+// Add stores the data, evicting the least recently used entry when full
+func (c *LRU) Add(key string, data *service.IPData) {
+	c.AddResult(key, Result{Data: data}, c.ttl)
+}
+
+// AddResult stores the lookup for ttl instead of the cache one
+func (c *LRU) AddResult(key string, result Result, ttl time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*lruEntry)
+		entry.result = result
+		entry.expires = time.Now().Add(ttl)
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{
+		key:     key,
+		result:  result,
+		expires: time.Now().Add(ttl),
+	})
+
+	//Evict the least recently used
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// Purge drops every entry, the hits and misses are kept
+func (c *LRU) Purge() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+}
+
+// Stats returns the hits and misses so far and the current amount of entries
+func (c *LRU) Stats() Stats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return Stats{Hits: c.hits, Misses: c.misses, Entries: c.order.Len()}
+}
@@ -0,0 +1,214 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+)
+
+const (
+	//BLOCKS are the /24 networks of the IPv4 space, a bit each
+	BLOCKS           = 1 << 24
+	NEGATIVESBUILT   = "Negative lookup filter built"
+	NEGATIVESFAILED  = "Could not build the negative lookup filter, lookups go to the database"
+	NEGATIVESCHANGED = "dataset version changed while building"
+	NEGATIVESRANGE   = "range %d-%d ends before it starts"
+	//NEGATIVESRETRY is the wait after a failed build, the version checks don't read the whole table again meanwhile
+	NEGATIVESRETRY = time.Minute
+)
+
+// Source is the dataset the filter is built from, only the bounds of its ranges in order. The database service and a loaded CSV provide it
+type Source interface {
+	EachBounds(visit func(from uint32, to uint32) error) error
+	DatasetVersion() (string, error)
+}
+
+// Negatives answers the lookups of addresses out of every range of the dataset without a query.
+// It has a bit for every /24 network holding an address of some range, 2 MB in all, addresses of a network without it are not in the dataset.
+// Addresses of the other networks may or may not be, those lookups go through.
+type Negatives struct {
+	//Retry is the wait after a failed build, NEGATIVESRETRY when zero
+	Retry    time.Duration
+	source   Source
+	bitmap   atomic.Pointer[bitmap]
+	building atomic.Bool
+	//retry is when a build may run again after a failure, in Unix nanoseconds
+	retry atomic.Int64
+	//latest is the last version read through the service, a filter of another one is not used
+	latest atomic.Pointer[string]
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// bitmap is the filter of a dataset version
+type bitmap struct {
+	bits    []uint64
+	version string
+	blocks  int
+}
+
+func (b *bitmap) has(ip uint32) bool {
+	block := ip >> 8
+	return b.bits[block/64]&(1<<(block%64)) != 0
+}
+
+// NewNegatives creates an empty filter, lookups go through until it is built
+func NewNegatives(source Source) *Negatives {
+	return &Negatives{source: source}
+}
+
+// Build reads the whole dataset and replaces the filter, it fails when the dataset version changed meanwhile
+func (n *Negatives) Build() error {
+	version, err := n.source.DatasetVersion()
+	if err != nil {
+		return err
+	}
+
+	built := &bitmap{bits: make([]uint64, BLOCKS/64), version: version}
+	err = n.source.EachBounds(func(from uint32, to uint32) error {
+		if from > to {
+			return fmt.Errorf(NEGATIVESRANGE, from, to)
+		}
+		for block := from >> 8; ; block++ {
+			if built.bits[block/64]&(1<<(block%64)) == 0 {
+				built.bits[block/64] |= 1 << (block % 64)
+				built.blocks++
+			}
+			if block == to>>8 {
+				return nil
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	//A dataset imported while reading leaves a filter of neither version
+	if version, err = n.source.DatasetVersion(); err != nil {
+		return err
+	}
+	if version != built.version {
+		return errors.New(NEGATIVESCHANGED)
+	}
+	n.bitmap.Store(built)
+	slog.Info(NEGATIVESBUILT, "version", version, "blocks", built.blocks)
+	return nil
+}
+
+// Rebuild builds the filter again in the background, unless a build is already running or one failed lately.
+// The filter in use is kept meanwhile, it is left aside by the lookups once it isn't of the latest version.
+func (n *Negatives) Rebuild() {
+	if time.Now().UnixNano() < n.retry.Load() || !n.building.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer n.building.Store(false)
+		if err := n.Build(); err != nil {
+			slog.Error(NEGATIVESFAILED, service.ERRORKEY, err)
+			n.retry.Store(time.Now().Add(n.retryInterval()).UnixNano())
+		}
+	}()
+}
+
+func (n *Negatives) retryInterval() time.Duration {
+	if n.Retry <= 0 {
+		return NEGATIVESRETRY
+	}
+	return n.Retry
+}
+
+// Stats returns the lookups answered by the filter, the ones that went through and the /24 networks with ranges
+func (n *Negatives) Stats() Stats {
+	stats := Stats{Hits: n.hits.Load(), Misses: n.misses.Load()}
+	if built := n.bitmap.Load(); built != nil {
+		stats.Entries = built.blocks
+	}
+	return stats
+}
+
+// Service answers the lookups of addresses that are not in the dataset, the other calls go through.
+// The filter is built again when the version of the wrapped service changes.
+func (n *Negatives) Service(s service.Service) service.Service {
+	return &filtered{Service: s, negatives: n}
+}
+
+// outside tells if the address is surely not in the dataset, counting the lookup
+func (n *Negatives) outside(ip net.IP) bool {
+	built := n.bitmap.Load()
+	if built != nil && n.current(built) && !built.has(service.IP2int(ip)) {
+		n.hits.Add(1)
+		return true
+	}
+	n.misses.Add(1)
+	return false
+}
+
+// current tells if the filter is of the latest version read, it is until one is read
+func (n *Negatives) current(built *bitmap) bool {
+	latest := n.latest.Load()
+	return latest == nil || *latest == built.version
+}
+
+// filtered is the service seen through the filter, addresses that are not in the dataset are not proxies for any max age
+type filtered struct {
+	service.Service
+	negatives *Negatives
+}
+
+func (f *filtered) GetIPInfo(ip net.IP) (*service.IPData, error) {
+	if f.negatives.outside(ip) {
+		return nil, service.NoResultError(service.CHECKDATA)
+	}
+	return f.Service.GetIPInfo(ip)
+}
+
+func (f *filtered) GetIPInfoFields(ip net.IP, fields []string) (*service.IPData, error) {
+	if known(fields) && f.negatives.outside(ip) {
+		return nil, service.NoResultError(service.CHECKDATA)
+	}
+	return f.Service.GetIPInfoFields(ip, fields)
+}
+
+func (f *filtered) GetIPInfoRange(ip net.IP, fields []string) (*service.IPDataRange, error) {
+	if known(fields) && f.negatives.outside(ip) {
+		return nil, service.NoResultError(service.CHECKDATA)
+	}
+	return f.Service.GetIPInfoRange(ip, fields)
+}
+
+// DatasetVersion is the version of the wrapped service, a filter of another one is built again
+func (f *filtered) DatasetVersion() (string, error) {
+	version, err := f.Service.DatasetVersion()
+	if err != nil {
+		return version, err
+	}
+	f.negatives.latest.Store(&version)
+	if built := f.negatives.bitmap.Load(); built == nil || built.version != version {
+		f.negatives.Rebuild()
+	}
+	return version, err
+}
+
+func (f *filtered) MaxAge(days int) service.Service {
+	return &filtered{Service: f.Service.MaxAge(days), negatives: f.negatives}
+}
+
+func (f *filtered) WithContext(ctx context.Context) service.Service {
+	return &filtered{Service: f.Service.WithContext(ctx), negatives: f.negatives}
+}
+
+// known tells if every field exists, unknown ones are refused by the wrapped service
+func known(fields []string) bool {
+	for _, field := range fields {
+		if (&service.IPData{}).FieldPointer(field) == nil {
+			return false
+		}
+	}
+	return true
+}
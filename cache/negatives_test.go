@@ -0,0 +1,171 @@
+package cache
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nullc0rp/go-ip2proxy-api/mocks"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+	"github.com/stretchr/testify/assert"
+)
+
+// source is a dataset of 10.10.10.0/24 and 10.20.0.0 - 10.21.0.10
+type source struct {
+	version string
+	read    int
+	//ranges replaces the dataset when set
+	ranges []*service.IPDataRange
+	err    error
+}
+
+func (s *source) EachBounds(visit func(from uint32, to uint32) error) error {
+	s.read++
+	if s.err != nil {
+		return s.err
+	}
+	if s.ranges != nil {
+		for _, found := range s.ranges {
+			if err := visit(found.IPFrom, found.IPTo); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	visit(168430080, 168430335)
+	return visit(169082880, 169148426)
+}
+
+func (s *source) DatasetVersion() (string, error) {
+	return s.version, nil
+}
+
+func TestNegativesBuilt(t *testing.T) {
+
+	negatives := NewNegatives(&source{version: "2024-01-01 00:00:00"})
+	assert.Nil(t, negatives.Build())
+
+	//Every /24 of the ranges, the last one partly
+	assert.Equal(t, 1+257, negatives.Stats().Entries)
+	built := negatives.bitmap.Load()
+	for ip, inside := range map[string]bool{
+		"10.10.10.1":      true,
+		"10.10.11.1":      false,
+		"10.20.0.0":       true,
+		"10.20.200.5":     true,
+		"10.21.0.200":     true,
+		"10.21.1.0":       false,
+		"0.0.0.0":         false,
+		"255.255.255.255": false,
+	} {
+		assert.Equal(t, inside, built.has(service.IP2int(net.ParseIP(ip))), ip)
+	}
+}
+
+func TestNegativesService(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	mockService.EXPECT().GetIPInfo(net.ParseIP("10.10.10.1")).Return(&service.IPData{ProxyType: "PUB"}, nil).Times(2)
+	mockService.EXPECT().GetIPInfoFields(net.ParseIP("10.10.11.1"), []string{"nope"}).Return(nil, &service.BadField{Field: "nope"}).Times(1)
+
+	negatives := NewNegatives(&source{version: "2024-01-01 00:00:00"})
+	svc := negatives.Service(mockService)
+
+	//Not built yet, lookups go through
+	data, _ := svc.GetIPInfo(net.ParseIP("10.10.10.1"))
+	assert.Equal(t, "PUB", data.ProxyType)
+
+	negatives.Build()
+	data, _ = svc.GetIPInfo(net.ParseIP("10.10.10.1"))
+	assert.Equal(t, "PUB", data.ProxyType)
+
+	//No query for the addresses out of every range
+	_, err := svc.GetIPInfo(net.ParseIP("10.10.11.1"))
+	assert.True(t, service.IsNoResult(err))
+	_, err = svc.GetIPInfoFields(net.ParseIP("10.10.11.1"), []string{"proxy_type"})
+	assert.True(t, service.IsNoResult(err))
+	_, err = svc.GetIPInfoRange(net.ParseIP("10.10.11.1"), nil)
+	assert.True(t, service.IsNoResult(err))
+
+	//Unknown fields are still refused
+	_, err = svc.GetIPInfoFields(net.ParseIP("10.10.11.1"), []string{"nope"})
+	assert.Equal(t, "nope", err.(*service.BadField).Field)
+
+	assert.Equal(t, uint64(3), negatives.Stats().Hits)
+	assert.Equal(t, uint64(2), negatives.Stats().Misses)
+}
+
+func TestNegativesRebuiltOnNewVersion(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	dataset := &source{version: "2024-01-01 00:00:00"}
+	version := dataset.version
+	mockService.EXPECT().DatasetVersion().DoAndReturn(func() (string, error) { return version, nil }).AnyTimes()
+
+	negatives := NewNegatives(dataset)
+	svc := negatives.Service(mockService)
+	negatives.Build()
+
+	//Same version, nothing to build
+	svc.DatasetVersion()
+	assert.Equal(t, 1, dataset.read)
+
+	//The filter of the old version is left aside right away and built again for the new one
+	old := negatives.bitmap.Load()
+	version, dataset.version = "2024-02-01 00:00:00", "2024-02-01 00:00:00"
+	svc.DatasetVersion()
+	assert.False(t, negatives.current(old))
+	assert.Eventually(t, func() bool {
+		built := negatives.bitmap.Load()
+		return built != nil && built.version == version
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, 2, dataset.read)
+}
+
+func TestNegativesBadRange(t *testing.T) {
+
+	//A range ending before it starts would never reach its last block
+	negatives := NewNegatives(&source{version: "2024-01-01 00:00:00", ranges: []*service.IPDataRange{{IPFrom: 168430335, IPTo: 168430080}}})
+	assert.Equal(t, "range 168430335-168430080 ends before it starts", negatives.Build().Error())
+	assert.Nil(t, negatives.bitmap.Load())
+}
+
+func TestNegativesRetryAfterFailure(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	mockService.EXPECT().DatasetVersion().Return("2024-01-01 00:00:00", nil).AnyTimes()
+
+	dataset := &source{version: "2024-01-01 00:00:00", err: errors.New("connection refused")}
+	negatives := NewNegatives(dataset)
+	negatives.Retry = time.Hour
+	svc := negatives.Service(mockService)
+
+	//The failed build isn't run again by the next version checks
+	svc.DatasetVersion()
+	assert.Eventually(t, func() bool { return !negatives.building.Load() && negatives.retry.Load() != 0 }, time.Second, time.Millisecond)
+	svc.DatasetVersion()
+	svc.DatasetVersion()
+	assert.False(t, negatives.building.Load())
+	assert.Equal(t, 1, dataset.read)
+
+	//Once the wait is over it is
+	negatives.retry.Store(0)
+	dataset.err = nil
+	svc.DatasetVersion()
+	assert.Eventually(t, func() bool { return negatives.bitmap.Load() != nil }, time.Second, time.Millisecond)
+	assert.Equal(t, 2, dataset.read)
+}
@@ -0,0 +1,207 @@
+package cache
+
+import (
+	"container/list"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+)
+
+// Ranges is a size bounded cache of lookups by the range they were found in, one entry answers every address of its range.
+// Ranges of a scope don't overlap, they are kept in a treap ordered by scope and first address. Entries also expire after a while.
+type Ranges struct {
+	mutex  sync.Mutex
+	size   int
+	ttl    time.Duration
+	order  *list.List
+	root   *rangeNode
+	hits   uint64
+	misses uint64
+}
+
+type rangeEntry struct {
+	scope   string
+	from    uint32
+	to      uint32
+	data    *service.IPData
+	expires time.Time
+}
+
+// rangeNode is a node of the treap, a heap by priority and a search tree by scope and first address
+type rangeNode struct {
+	element     *list.Element
+	priority    uint32
+	left, right *rangeNode
+}
+
+func (n *rangeNode) entry() *rangeEntry {
+	return n.element.Value.(*rangeEntry)
+}
+
+// NewRanges creates a cache holding up to size ranges for ttl
+func NewRanges(size int, ttl time.Duration) *Ranges {
+	return &Ranges{size: size, ttl: ttl, order: list.New()}
+}
+
+// Get returns the data of the cached range holding the address, if any
+func (c *Ranges) Get(scope string, ip uint32) (*service.IPData, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	node := c.floor(scope, ip)
+	if node == nil || node.entry().scope != scope || ip > node.entry().to {
+		c.misses++
+		return nil, false
+	}
+	entry := node.entry()
+	if time.Now().After(entry.expires) {
+		c.remove(node)
+		c.misses++
+		return nil, false
+	}
+	c.order.MoveToFront(node.element)
+	c.hits++
+	return entry.data, true
+}
+
+// Add stores the data of the range from-to, evicting the least recently used range when full
+func (c *Ranges) Add(scope string, from uint32, to uint32, data *service.IPData) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if node := c.floor(scope, from); node != nil && node.entry().scope == scope && node.entry().from == from {
+		entry := node.entry()
+		entry.to = to
+		entry.data = data
+		entry.expires = time.Now().Add(c.ttl)
+		c.order.MoveToFront(node.element)
+		return
+	}
+
+	node := &rangeNode{
+		element: c.order.PushFront(&rangeEntry{
+			scope:   scope,
+			from:    from,
+			to:      to,
+			data:    data,
+			expires: time.Now().Add(c.ttl),
+		}),
+		priority: rand.Uint32(),
+	}
+	c.root = insert(c.root, node)
+
+	//Evict the least recently used
+	if c.order.Len() > c.size {
+		oldest := c.order.Back().Value.(*rangeEntry)
+		c.remove(c.floor(oldest.scope, oldest.from))
+	}
+}
+
+// Purge drops every range, the hits and misses are kept
+func (c *Ranges) Purge() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.order.Init()
+	c.root = nil
+}
+
+// Stats returns the hits and misses so far and the current amount of ranges
+func (c *Ranges) Stats() Stats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return Stats{Hits: c.hits, Misses: c.misses, Entries: c.order.Len()}
+}
+
+// floor finds the range of the scope starting at or right before the address
+func (c *Ranges) floor(scope string, ip uint32) *rangeNode {
+	var found *rangeNode
+	for node := c.root; node != nil; {
+		if before(node.entry(), scope, ip) || (node.entry().scope == scope && node.entry().from == ip) {
+			found = node
+			node = node.right
+		} else {
+			node = node.left
+		}
+	}
+	return found
+}
+
+func (c *Ranges) remove(node *rangeNode) {
+	entry := node.entry()
+	c.order.Remove(node.element)
+	c.root = remove(c.root, entry.scope, entry.from)
+}
+
+// before tells if the entry is ordered before the scope and address
+func before(entry *rangeEntry, scope string, ip uint32) bool {
+	if entry.scope != scope {
+		return entry.scope < scope
+	}
+	return entry.from < ip
+}
+
+// insert adds the node under root and rotates it up while its priority is higher than its parent's
+func insert(root *rangeNode, node *rangeNode) *rangeNode {
+	if root == nil {
+		return node
+	}
+	if before(node.entry(), root.entry().scope, root.entry().from) {
+		root.left = insert(root.left, node)
+		if root.left.priority > root.priority {
+			root = rotateRight(root)
+		}
+	} else {
+		root.right = insert(root.right, node)
+		if root.right.priority > root.priority {
+			root = rotateLeft(root)
+		}
+	}
+	return root
+}
+
+// remove drops the node of the scope and address under root, rotating it down until it is a leaf
+func remove(root *rangeNode, scope string, from uint32) *rangeNode {
+	if root == nil {
+		return nil
+	}
+	entry := root.entry()
+	switch {
+	case entry.scope == scope && entry.from == from:
+		if root.left == nil {
+			return root.right
+		}
+		if root.right == nil {
+			return root.left
+		}
+		if root.left.priority > root.right.priority {
+			root = rotateRight(root)
+			root.right = remove(root.right, scope, from)
+		} else {
+			root = rotateLeft(root)
+			root.left = remove(root.left, scope, from)
+		}
+	case before(entry, scope, from):
+		root.right = remove(root.right, scope, from)
+	default:
+		root.left = remove(root.left, scope, from)
+	}
+	return root
+}
+
+func rotateRight(root *rangeNode) *rangeNode {
+	left := root.left
+	root.left = left.right
+	left.right = root
+	return left
+}
+
+func rotateLeft(root *rangeNode) *rangeNode {
+	right := root.right
+	root.right = right.left
+	right.left = root
+	return right
+}
@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRangesMatchScan(t *testing.T) {
+
+	//Ranges of 100 addresses with gaps of 100 in two scopes, a tenth of them cached
+	cache := NewRanges(1000, time.Hour)
+	cached := map[uint32]*service.IPData{}
+	for _, from := range rand.Perm(1000)[:100] {
+		data := &service.IPData{ProxyType: "PUB"}
+		cache.Add("a", uint32(from*200), uint32(from*200+99), data)
+		cache.Add("b", uint32(from*200+100), uint32(from*200+199), &service.IPData{})
+		cached[uint32(from*200)] = data
+	}
+
+	for i := 0; i < 10000; i++ {
+		ip := uint32(rand.Intn(200000))
+		data, ok := cache.Get("a", ip)
+		expected, inRange := cached[ip-ip%200]
+		if ip%200 >= 100 {
+			inRange = false
+		}
+		assert.Equal(t, inRange, ok, ip)
+		if inRange {
+			assert.Same(t, expected, data, ip)
+		}
+	}
+}
+
+func TestRangesBounded(t *testing.T) {
+
+	cache := NewRanges(2, time.Hour)
+	cache.Add("", 0, 9, &service.IPData{ProxyType: "PUB"})
+	cache.Add("", 10, 19, &service.IPData{ProxyType: "VPN"})
+
+	//The first range was used last, the second one is evicted
+	_, ok := cache.Get("", 5)
+	assert.True(t, ok)
+	cache.Add("", 20, 29, &service.IPData{ProxyType: "TOR"})
+
+	_, ok = cache.Get("", 15)
+	assert.False(t, ok)
+	data, ok := cache.Get("", 25)
+	assert.True(t, ok)
+	assert.Equal(t, "TOR", data.ProxyType)
+	assert.Equal(t, 2, cache.Stats().Entries)
+
+	cache.Purge()
+	_, ok = cache.Get("", 5)
+	assert.False(t, ok)
+	assert.Equal(t, Stats{Hits: 2, Misses: 2, Entries: 0}, cache.Stats())
+}
+
+func TestRangesExpire(t *testing.T) {
+
+	cache := NewRanges(10, time.Millisecond)
+	cache.Add("", 0, 9, &service.IPData{ProxyType: "PUB"})
+	time.Sleep(5 * time.Millisecond)
+
+	_, ok := cache.Get("", 5)
+	assert.False(t, ok)
+	assert.Equal(t, 0, cache.Stats().Entries)
+}
+
+func TestRangesEvictedMatchScan(t *testing.T) {
+
+	//Far more ranges than the cache holds, the ones still cached answer right
+	cache := NewRanges(50, time.Hour)
+	for _, from := range rand.Perm(1000) {
+		cache.Add("", uint32(from*10), uint32(from*10+4), &service.IPData{ProxyType: string(rune('A' + from%26))})
+	}
+	assert.Equal(t, 50, cache.Stats().Entries)
+
+	found := 0
+	for ip := uint32(0); ip < 10000; ip++ {
+		data, ok := cache.Get("", ip)
+		if !ok {
+			continue
+		}
+		found++
+		assert.True(t, ip%10 < 5, ip)
+		assert.Equal(t, string(rune('A'+int(ip/10)%26)), data.ProxyType, ip)
+	}
+	assert.Equal(t, 250, found)
+}
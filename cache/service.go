@@ -0,0 +1,211 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	DEFAULTSIZE         = 100000
+	DEFAULTTTL          = time.Hour
+	DEFAULTNEGATIVETTL  = 10 * time.Minute
+	DEFAULTVERSIONCHECK = time.Minute
+	//ALLFIELDS keys the lookups of every field of the package tier
+	ALLFIELDS      = "*"
+	VERSIONKEY     = "version"
+	VERSIONCHANGED = "Dataset version changed, lookup cache flushed"
+)
+
+// Options sizes the lookup cache
+type Options struct {
+	//Size is the most ranges kept, and the most addresses that are not in the dataset
+	Size int
+	//TTL is how long a found range is kept
+	TTL time.Duration
+	//NegativeTTL is how long an address that is not in the dataset is kept
+	NegativeTTL time.Duration
+	//VersionCheck is how often the dataset version is read, the cache is flushed when it changes
+	VersionCheck time.Duration
+}
+
+// Lookups caches the address lookups of a service. Addresses found are kept by range, so a single query answers
+// every address of the range, and those that are not in the dataset one by one.
+// Concurrent lookups of the same address make a single query, and every lookup cached is dropped when the dataset version changes.
+type Lookups struct {
+	options Options
+	ranges  *Ranges
+	lru     *LRU
+	group   singleflight.Group
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+
+	mutex      sync.Mutex
+	version    string
+	versionErr error
+	checked    time.Time
+	//generation changes on every flush, lookups started before it are not cached
+	generation int
+}
+
+// NewLookups creates the cache filling in the defaults for missing options
+func NewLookups(options Options) *Lookups {
+	if options.Size == 0 {
+		options.Size = DEFAULTSIZE
+	}
+	if options.TTL == 0 {
+		options.TTL = DEFAULTTTL
+	}
+	if options.NegativeTTL == 0 {
+		options.NegativeTTL = DEFAULTNEGATIVETTL
+	}
+	if options.VersionCheck == 0 {
+		options.VersionCheck = DEFAULTVERSIONCHECK
+	}
+	return &Lookups{options: options, ranges: NewRanges(options.Size, options.TTL), lru: NewLRU(options.Size, options.NegativeTTL)}
+}
+
+// Service answers the lookups of the wrapped service from the cache, the other calls go through
+func (l *Lookups) Service(s service.Service) service.Service {
+	return &cached{Service: s, lookups: l}
+}
+
+// Stats returns the hits and misses so far and the current amount of ranges and addresses kept
+func (l *Lookups) Stats() Stats {
+	return Stats{
+		Hits:    l.hits.Load(),
+		Misses:  l.misses.Load(),
+		Entries: l.ranges.Stats().Entries + l.lru.Stats().Entries,
+	}
+}
+
+// checkVersion reads the dataset version once it is older than the check interval, flushing the cache when it changed.
+// Until then the last version read is returned.
+func (l *Lookups) checkVersion(s service.Service) (string, error) {
+	l.mutex.Lock()
+	if time.Since(l.checked) < l.options.VersionCheck {
+		defer l.mutex.Unlock()
+		return l.version, l.versionErr
+	}
+	l.mutex.Unlock()
+
+	value, err, _ := l.group.Do(VERSIONKEY, func() (interface{}, error) {
+		version, err := s.DatasetVersion()
+
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		l.checked = time.Now()
+		l.versionErr = err
+		if err != nil {
+			return l.version, err
+		}
+		if l.version != "" && version != l.version {
+			l.ranges.Purge()
+			l.lru.Purge()
+			l.generation++
+			slog.Info(VERSIONCHANGED, "from", l.version, "to", version)
+		}
+		l.version = version
+		return version, nil
+	})
+	return value.(string), err
+}
+
+// cached is the service seen through the cache, scoped services share it under their own keys
+type cached struct {
+	service.Service
+	lookups *Lookups
+	scope   string
+}
+
+func (c *cached) GetIPInfo(ip net.IP) (*service.IPData, error) {
+	return c.lookup(ip, nil)
+}
+
+func (c *cached) GetIPInfoFields(ip net.IP, fields []string) (*service.IPData, error) {
+	//No fields is not every field
+	if fields == nil {
+		fields = []string{}
+	}
+	return c.lookup(ip, fields)
+}
+
+// DatasetVersion is the last version read, it is read again once older than the check interval
+func (c *cached) DatasetVersion() (string, error) {
+	return c.lookups.checkVersion(c.Service)
+}
+
+// MaxAge keeps the scoped service cached, apart from the others since stale ranges are answered differently
+func (c *cached) MaxAge(days int) service.Service {
+	return &cached{Service: c.Service.MaxAge(days), lookups: c.lookups, scope: strconv.Itoa(days)}
+}
+
+// WithContext keeps the service scoped to the request cached
+func (c *cached) WithContext(ctx context.Context) service.Service {
+	return &cached{Service: c.Service.WithContext(ctx), lookups: c.lookups, scope: c.scope}
+}
+
+// lookup answers from the cache or runs the query once for every concurrent caller, nil fields are all of them.
+// Ranges found are kept for the TTL and addresses that are not in the dataset for the negative TTL, failures are not kept.
+func (c *cached) lookup(ip net.IP, fields []string) (*service.IPData, error) {
+	//Failing to read the version is not a reason to fail the lookup, it is read again on the next check
+	c.lookups.checkVersion(c.Service)
+
+	scope := c.scope + "/" + ALLFIELDS
+	if fields != nil {
+		scope = c.scope + "/" + strings.Join(fields, ",")
+	}
+	//Ranges are IPv4, like the dataset
+	decimalIP, ranged := service.IP2int(ip), ip.To4() != nil
+	if ranged {
+		if data, ok := c.lookups.ranges.Get(scope, decimalIP); ok {
+			c.lookups.hits.Add(1)
+			return data, nil
+		}
+	}
+	key := scope + "/" + ip.String()
+	if result, ok := c.lookups.lru.GetResult(key); ok {
+		c.lookups.hits.Add(1)
+		return result.Data, result.Err
+	}
+	c.lookups.misses.Add(1)
+
+	c.lookups.mutex.Lock()
+	generation := c.lookups.generation
+	c.lookups.mutex.Unlock()
+
+	value, err, _ := c.lookups.group.Do(strconv.Itoa(generation)+"/"+key, func() (interface{}, error) {
+		found, err := c.Service.GetIPInfoRange(ip, fields)
+		if err != nil && !service.IsNoResult(err) {
+			return nil, err
+		}
+
+		//A lookup of the dataset that was just replaced is not kept
+		c.lookups.mutex.Lock()
+		defer c.lookups.mutex.Unlock()
+		if generation != c.lookups.generation {
+			return found, err
+		}
+		switch {
+		case err != nil:
+			c.lookups.lru.AddResult(key, Result{Err: err}, c.lookups.options.NegativeTTL)
+		case ranged && found.IPFrom <= decimalIP && decimalIP <= found.IPTo:
+			c.lookups.ranges.Add(scope, found.IPFrom, found.IPTo, found.Data)
+		default:
+			c.lookups.lru.AddResult(key, Result{Data: found.Data}, c.lookups.options.TTL)
+		}
+		return found, err
+	})
+	if found, _ := value.(*service.IPDataRange); found != nil {
+		return found.Data, err
+	}
+	return nil, err
+}
@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nullc0rp/go-ip2proxy-api/mocks"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+	"github.com/stretchr/testify/assert"
+)
+
+// ranged is the data of the range 10.10.10.0 - 10.10.10.255
+func ranged(data *service.IPData) *service.IPDataRange {
+	return &service.IPDataRange{IPFrom: 168430080, IPTo: 168430335, Data: data}
+}
+
+func TestLookupsCached(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	mockService.EXPECT().DatasetVersion().Return("2024-01-01 00:00:00", nil).Times(1)
+	mockService.EXPECT().GetIPInfoRange(net.ParseIP("10.10.10.1"), nil).Return(ranged(&service.IPData{ProxyType: "PUB"}), nil).Times(1)
+	mockService.EXPECT().GetIPInfoRange(net.ParseIP("10.10.10.1"), []string{"proxy_type"}).Return(ranged(&service.IPData{ProxyType: "PUB"}), nil).Times(1)
+	mockService.EXPECT().GetIPInfoRange(net.ParseIP("10.10.20.2"), nil).Return(nil, service.NoResultError("test")).Times(1)
+	mockService.EXPECT().GetIPInfoRange(net.ParseIP("10.10.20.3"), nil).Return(nil, errors.New("some dirty info")).Times(2)
+
+	lookups := NewLookups(Options{})
+	svc := lookups.Service(mockService)
+
+	for i := 0; i < 2; i++ {
+		data, err := svc.GetIPInfo(net.ParseIP("10.10.10.1"))
+		assert.Nil(t, err)
+		assert.Equal(t, "PUB", data.ProxyType)
+
+		//Other fields are another lookup
+		data, err = svc.GetIPInfoFields(net.ParseIP("10.10.10.1"), []string{"proxy_type"})
+		assert.Nil(t, err)
+		assert.Equal(t, "PUB", data.ProxyType)
+
+		//Not in the dataset is cached as well
+		data, err = svc.GetIPInfo(net.ParseIP("10.10.20.2"))
+		assert.Nil(t, data)
+		assert.True(t, service.IsNoResult(err))
+
+		//Failures are not
+		_, err = svc.GetIPInfo(net.ParseIP("10.10.20.3"))
+		assert.Equal(t, "some dirty info", err.Error())
+	}
+
+	//The version is read once per check interval
+	version, err := svc.DatasetVersion()
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-01-01 00:00:00", version)
+
+	assert.Equal(t, Stats{Hits: 3, Misses: 5, Entries: 3}, lookups.Stats())
+}
+
+func TestLookupsScoped(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+	scoped := mocks.NewMockService(controller)
+
+	mockService.EXPECT().DatasetVersion().Return("2024-01-01 00:00:00", nil).AnyTimes()
+	mockService.EXPECT().MaxAge(30).Return(scoped).Times(2)
+	scoped.EXPECT().WithContext(gomock.Any()).Return(scoped).Times(1)
+	mockService.EXPECT().GetIPInfoRange(net.ParseIP("10.10.10.1"), nil).Return(ranged(&service.IPData{ProxyType: "PUB", LastSeen: "45"}), nil).Times(1)
+	scoped.EXPECT().GetIPInfoRange(net.ParseIP("10.10.10.1"), nil).Return(nil, &service.StaleError{LastSeen: "45"}).Times(1)
+
+	svc := NewLookups(Options{}).Service(mockService)
+
+	data, err := svc.GetIPInfo(net.ParseIP("10.10.10.1"))
+	assert.Nil(t, err)
+	assert.Equal(t, "45", data.LastSeen)
+
+	//The range is too old for the scoped service, the error is kept as is
+	for _, scopedService := range []service.Service{svc.MaxAge(30), svc.MaxAge(30).WithContext(context.Background())} {
+		data, err = scopedService.GetIPInfo(net.ParseIP("10.10.10.1"))
+		assert.Nil(t, data)
+		var stale *service.StaleError
+		assert.True(t, errors.As(err, &stale))
+		assert.Equal(t, "45", stale.LastSeen)
+	}
+}
+
+func TestLookupsCollapsed(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	//The query waits until every caller asked
+	release := make(chan struct{})
+	mockService.EXPECT().DatasetVersion().Return("2024-01-01 00:00:00", nil).AnyTimes()
+	mockService.EXPECT().GetIPInfoRange(net.ParseIP("10.10.10.1"), nil).DoAndReturn(func(ip net.IP, fields []string) (*service.IPDataRange, error) {
+		<-release
+		return ranged(&service.IPData{ProxyType: "PUB"}), nil
+	}).Times(1)
+
+	svc := NewLookups(Options{}).Service(mockService)
+	svc.DatasetVersion()
+
+	var callers sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		callers.Add(1)
+		go func() {
+			defer callers.Done()
+			data, err := svc.GetIPInfo(net.ParseIP("10.10.10.1"))
+			assert.Nil(t, err)
+			assert.Equal(t, "PUB", data.ProxyType)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	callers.Wait()
+}
+
+func TestLookupsFlushedOnNewVersion(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	version := "2024-01-01 00:00:00"
+	mockService.EXPECT().DatasetVersion().DoAndReturn(func() (string, error) { return version, nil }).AnyTimes()
+	gomock.InOrder(
+		mockService.EXPECT().GetIPInfoRange(net.ParseIP("10.10.10.1"), nil).Return(ranged(&service.IPData{ProxyType: "PUB"}), nil).Times(1),
+		mockService.EXPECT().GetIPInfoRange(net.ParseIP("10.10.10.1"), nil).Return(nil, service.NoResultError("test")).Times(1),
+	)
+
+	//The version is read on every lookup
+	lookups := NewLookups(Options{VersionCheck: time.Nanosecond})
+	svc := lookups.Service(mockService)
+
+	data, _ := svc.GetIPInfo(net.ParseIP("10.10.10.1"))
+	assert.Equal(t, "PUB", data.ProxyType)
+	data, _ = svc.GetIPInfo(net.ParseIP("10.10.10.1"))
+	assert.Equal(t, "PUB", data.ProxyType)
+
+	//The new dataset no longer has the range
+	version = "2024-02-01 00:00:00"
+	data, err := svc.GetIPInfo(net.ParseIP("10.10.10.1"))
+	assert.Nil(t, data)
+	assert.True(t, service.IsNoResult(err))
+	assert.Equal(t, 1, lookups.Stats().Entries)
+}
+
+func TestLookupsByRange(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	mockService.EXPECT().DatasetVersion().Return("2024-01-01 00:00:00", nil).AnyTimes()
+	mockService.EXPECT().GetIPInfoRange(net.ParseIP("10.10.10.1"), nil).Return(ranged(&service.IPData{ProxyType: "PUB"}), nil).Times(1)
+	mockService.EXPECT().GetIPInfoRange(net.ParseIP("10.10.11.1"), nil).Return(nil, service.NoResultError("test")).Times(1)
+
+	lookups := NewLookups(Options{})
+	svc := lookups.Service(mockService)
+
+	//Every address of the range is answered by the first query
+	for _, address := range []string{"10.10.10.1", "10.10.10.0", "10.10.10.200", "10.10.10.255"} {
+		data, err := svc.GetIPInfo(net.ParseIP(address))
+		assert.Nil(t, err, address)
+		assert.Equal(t, "PUB", data.ProxyType, address)
+	}
+
+	//Right after the range is another query
+	_, err := svc.GetIPInfo(net.ParseIP("10.10.11.1"))
+	assert.True(t, service.IsNoResult(err))
+
+	assert.Equal(t, Stats{Hits: 3, Misses: 2, Entries: 2}, lookups.Stats())
+}
@@ -0,0 +1,277 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nullc0rp/go-ip2proxy-api/cache"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+)
+
+const (
+	DEFAULTURL     = "https://localhost:8443"
+	DEFAULTTIMEOUT = 10 * time.Second
+	DEFAULTBACKOFF = 100 * time.Millisecond
+	//DEFAULTCACHETTL applies when CacheSize enables the cache
+	DEFAULTCACHETTL = 10 * time.Minute
+	APPJSON         = "application/json"
+	BADCAFILE       = "No certificates found in %s"
+	APIERROR        = "API error %d: %s"
+)
+
+// Options configures the client, zero values use the defaults
+type Options struct {
+	BaseURL string
+	//CAFile is a PEM bundle used to verify the server, the self signed dev certificate needs it
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
+	//Timeout applies to every attempt, use the context for an overall deadline
+	Timeout time.Duration
+	//MaxRetries is the amount of extra attempts after a network error or an unavailable server
+	MaxRetries int
+	Backoff    time.Duration
+	//CacheSize enables an in process cache for address lookups
+	CacheSize int
+	CacheTTL  time.Duration
+	//HTTPClient replaces the client built from the TLS options
+	HTTPClient *http.Client
+}
+
+// APIError is returned when the server answers with an error status
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf(APIERROR, e.StatusCode, e.Message)
+}
+
+// MyIPDataResult is the data of the caller's own address, with the header or hop it was resolved from
+type MyIPDataResult struct {
+	IP     string `json:"ip_address"`
+	Source string `json:"source"`
+	*service.IPData
+}
+
+// Client calls the API routes and decodes the responses into the service types
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	maxRetries int
+	backoff    time.Duration
+	cache      *cache.LRU
+}
+
+// NewClient creates a client, it fails only if the TLS files can't be loaded
+func NewClient(options Options) (*Client, error) {
+	if options.BaseURL == "" {
+		options.BaseURL = DEFAULTURL
+	}
+	if options.Timeout == 0 {
+		options.Timeout = DEFAULTTIMEOUT
+	}
+	if options.Backoff == 0 {
+		options.Backoff = DEFAULTBACKOFF
+	}
+	if options.CacheTTL == 0 {
+		options.CacheTTL = DEFAULTCACHETTL
+	}
+
+	httpClient := options.HTTPClient
+	if httpClient == nil {
+		tlsConfig, err := buildTLSConfig(options)
+		if err != nil {
+			return nil, err
+		}
+		httpClient = &http.Client{
+			Timeout:   options.Timeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		}
+	}
+
+	client := &Client{
+		baseURL:    strings.TrimRight(options.BaseURL, "/"),
+		httpClient: httpClient,
+		maxRetries: options.MaxRetries,
+		backoff:    options.Backoff,
+	}
+	if options.CacheSize > 0 {
+		client.cache = cache.NewLRU(options.CacheSize, options.CacheTTL)
+	}
+	return client, nil
+}
+
+// GetIPInfo gets the proxy data for an address, an address not in the dataset returns an APIError
+func (c *Client) GetIPInfo(ctx context.Context, ip net.IP) (*service.IPData, error) {
+	key := ip.String()
+	if c.cache != nil {
+		if data, ok := c.cache.Get(key); ok && data != nil {
+			return data, nil
+		}
+	}
+
+	result := &service.IPData{}
+	if err := c.get(ctx, "/ip/"+url.PathEscape(key), result); err != nil {
+		return nil, err
+	}
+
+	if c.cache != nil {
+		c.cache.Add(key, result)
+	}
+	return result, nil
+}
+
+// GetMyIPInfo gets the proxy data for the address the server sees for this client
+func (c *Client) GetMyIPInfo(ctx context.Context) (*MyIPDataResult, error) {
+	result := &MyIPDataResult{}
+	if err := c.get(ctx, "/me", result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetIPCountry gets up to limit addresses for a country, the server caps the limit
+func (c *Client) GetIPCountry(ctx context.Context, country string, limit int) (*service.IPCountryData, error) {
+	path := "/country/" + url.PathEscape(country)
+	if limit > 0 {
+		path = path + "?limit=" + strconv.Itoa(limit)
+	}
+	result := &service.IPCountryData{}
+	if err := c.get(ctx, path, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetISPCountry gets the ISP names for a country
+func (c *Client) GetISPCountry(ctx context.Context, country string) (*service.ISPCountryData, error) {
+	result := &service.ISPCountryData{}
+	if err := c.get(ctx, "/country/"+url.PathEscape(country)+"/isp", result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetCountryTotal gets the amount of addresses for a country
+func (c *Client) GetCountryTotal(ctx context.Context, country string) (*service.IPCountryTotal, error) {
+	result := &service.IPCountryTotal{}
+	if err := c.get(ctx, "/country/"+url.PathEscape(country)+"/total", result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MostProxyTypes gets the most common proxy types
+func (c *Client) MostProxyTypes(ctx context.Context) (*service.MostProxyTypeResult, error) {
+	result := &service.MostProxyTypeResult{}
+	if err := c.get(ctx, "/proxytypes", result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// get runs the request with retries and decodes the json body into result
+func (c *Client) get(ctx context.Context, path string, result interface{}) error {
+	var err error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			//Exponential backoff, cut short by the context
+			timer := time.NewTimer(c.backoff << uint(attempt-1))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		var body []byte
+		body, err = c.do(ctx, path)
+		if err == nil {
+			return json.Unmarshal(body, result)
+		}
+		if !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// do runs a single attempt
+func (c *Client) do(ctx context.Context, path string) ([]byte, error) {
+	request, err := http.NewRequest(http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	request = request.WithContext(ctx)
+	request.Header.Set("Accept", APPJSON)
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, &APIError{StatusCode: response.StatusCode, Message: string(body)}
+	}
+	return body, nil
+}
+
+// retryable only retries errors that may go away, the server answers 500 for bad input too
+func retryable(err error) bool {
+	var apiError *APIError
+	if errors.As(err, &apiError) {
+		switch apiError.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+// buildTLSConfig loads the CA bundle and client certificate if any
+func buildTLSConfig(options Options) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: options.InsecureSkipVerify}
+
+	if options.CAFile != "" {
+		pem, err := ioutil.ReadFile(options.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf(BADCAFILE, options.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if options.CertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(options.CertFile, options.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
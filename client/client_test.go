@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetIPInfoWithRetryAndCache(t *testing.T) {
+
+	calls := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = calls + 1
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		assert.Equal(t, "/ip/10.10.10.1", r.URL.Path)
+		w.Write([]byte("{\"proxy_type\":\"PUB\",\"country_code\":\"PL\"}"))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Options{
+		BaseURL:    server.URL,
+		HTTPClient: server.Client(),
+		MaxRetries: 2,
+		Backoff:    time.Millisecond,
+		CacheSize:  10,
+		CacheTTL:   time.Minute,
+	})
+	assert.Nil(t, err)
+
+	for i := 0; i < 2; i++ {
+		result, err := client.GetIPInfo(context.Background(), net.ParseIP("10.10.10.1"))
+		assert.Nil(t, err)
+		assert.Equal(t, "PUB", result.ProxyType)
+		assert.Equal(t, "PL", result.CountryCode)
+	}
+
+	//One failure, one success and then the cache
+	assert.Equal(t, 2, calls)
+}
+
+func TestCacheDefaultTTL(t *testing.T) {
+
+	calls := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = calls + 1
+		w.Write([]byte("{\"proxy_type\":\"PUB\"}"))
+	}))
+	defer server.Close()
+
+	//No TTL keeps the lookups for the default one
+	client, _ := NewClient(Options{
+		BaseURL:    server.URL,
+		HTTPClient: server.Client(),
+		CacheSize:  10,
+	})
+
+	for i := 0; i < 2; i++ {
+		result, err := client.GetIPInfo(context.Background(), net.ParseIP("10.10.10.1"))
+		assert.Nil(t, err)
+		assert.Equal(t, "PUB", result.ProxyType)
+	}
+	assert.Equal(t, 1, calls)
+}
+
+func TestGetIPCountryError(t *testing.T) {
+
+	calls := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = calls + 1
+		assert.Equal(t, "limit=10", r.URL.RawQuery)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("Service error"))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(Options{
+		BaseURL:    server.URL,
+		HTTPClient: server.Client(),
+		MaxRetries: 2,
+	})
+
+	result, err := client.GetIPCountry(context.Background(), "AR", 10)
+
+	assert.Nil(t, result)
+	assert.Equal(t, "API error 500: Service error", err.Error())
+	assert.Equal(t, 1, calls)
+}
+
+func TestMostProxyTypesTimeout(t *testing.T) {
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(Options{
+		BaseURL:    server.URL,
+		HTTPClient: server.Client(),
+		MaxRetries: 5,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := client.MostProxyTypes(ctx)
+
+	assert.NotNil(t, err)
+}
+
+func TestNewClientBadCA(t *testing.T) {
+
+	_, err := NewClient(Options{CAFile: "../config/certs/server.key"})
+
+	assert.Equal(t, "No certificates found in ../config/certs/server.key", err.Error())
+}
@@ -0,0 +1,134 @@
+package clientip
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	REMOTEADDR    = "RemoteAddr"
+	XFORWARDEDFOR = "X-Forwarded-For"
+	FORWARDED     = "Forwarded"
+	XREALIP       = "X-Real-IP"
+	BADCIDR       = "Ignoring bad trusted proxy CIDR: %s"
+)
+
+// Resolver finds the address of the client that originated a request.
+// Forwarding headers are only honored when the direct hop is one of the trusted proxies,
+// otherwise anyone could spoof their address by sending the header themselves.
+type Resolver struct {
+	TrustedProxies []*net.IPNet
+}
+
+// NewResolver builds a resolver from a list of CIDRs or plain addresses, bad entries are logged and skipped
+func NewResolver(trusted []string) *Resolver {
+	resolver := &Resolver{}
+	for _, entry := range trusted {
+		//Plain addresses are accepted as single host networks
+		if !strings.Contains(entry, "/") {
+			if strings.Contains(entry, ":") {
+				entry = entry + "/128"
+			} else {
+				entry = entry + "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(strings.TrimSpace(entry))
+		if err != nil {
+			log.Printf(BADCIDR, entry)
+			continue
+		}
+		resolver.TrustedProxies = append(resolver.TrustedProxies, network)
+	}
+	return resolver
+}
+
+// Resolve returns the client address and the name of the header it was taken from
+func (c *Resolver) Resolve(r *http.Request) (net.IP, string) {
+
+	//The direct hop is always known
+	remote := parseHost(r.RemoteAddr)
+	if remote == nil || !c.IsTrusted(remote) {
+		return remote, REMOTEADDR
+	}
+
+	//X-Forwarded-For holds a chain of addresses, one per hop, the closest hop is the last one
+	if ip := c.fromChain(headerValues(r, XFORWARDEDFOR)); ip != nil {
+		return ip, XFORWARDEDFOR
+	}
+
+	//Forwarded (RFC 7239) holds the same chain using "for=" parameters
+	if ip := c.fromChain(forwardedValues(r)); ip != nil {
+		return ip, FORWARDED
+	}
+
+	//X-Real-IP holds a single address set by the proxy
+	if ip := parseHost(r.Header.Get(XREALIP)); ip != nil {
+		return ip, XREALIP
+	}
+
+	return remote, REMOTEADDR
+}
+
+// IsTrusted checks if the address belongs to a trusted proxy
+func (c *Resolver) IsTrusted(ip net.IP) bool {
+	for _, network := range c.TrustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// fromChain walks the hops from the closest one and returns the first address not owned by a trusted proxy.
+// If every hop is trusted, the furthest one is the client.
+func (c *Resolver) fromChain(chain []string) net.IP {
+	var furthest net.IP
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip := parseHost(chain[i])
+		if ip == nil {
+			//A broken chain can't be trusted past this point
+			return furthest
+		}
+		if !c.IsTrusted(ip) {
+			return ip
+		}
+		furthest = ip
+	}
+	return furthest
+}
+
+// headerValues splits every instance of a comma separated header
+func headerValues(r *http.Request, name string) []string {
+	values := []string{}
+	for _, header := range r.Header.Values(name) {
+		for _, value := range strings.Split(header, ",") {
+			values = append(values, strings.TrimSpace(value))
+		}
+	}
+	return values
+}
+
+// forwardedValues extracts the "for=" parameter of every element in the Forwarded header
+func forwardedValues(r *http.Request) []string {
+	values := []string{}
+	for _, element := range headerValues(r, FORWARDED) {
+		for _, pair := range strings.Split(element, ";") {
+			pair = strings.TrimSpace(pair)
+			if len(pair) > 4 && strings.EqualFold(pair[:4], "for=") {
+				values = append(values, strings.Trim(pair[4:], "\""))
+			}
+		}
+	}
+	return values
+}
+
+// parseHost parses an address that may carry a port or IPv6 brackets
+func parseHost(value string) net.IP {
+	value = strings.TrimSpace(value)
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	return net.ParseIP(strings.Trim(value, "[]"))
+}
@@ -0,0 +1,65 @@
+package clientip
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveUntrustedHop(t *testing.T) {
+
+	resolver := NewResolver([]string{"10.0.0.0/8"})
+
+	r, _ := http.NewRequest("GET", "/me", nil)
+	r.RemoteAddr = "203.0.113.7:5555"
+	r.Header.Set(XFORWARDEDFOR, "198.51.100.1")
+
+	ip, source := resolver.Resolve(r)
+
+	assert.Equal(t, "203.0.113.7", ip.String())
+	assert.Equal(t, REMOTEADDR, source)
+}
+
+func TestResolveForwardedFor(t *testing.T) {
+
+	resolver := NewResolver([]string{"10.0.0.0/8", "192.0.2.1"})
+
+	r, _ := http.NewRequest("GET", "/me", nil)
+	r.RemoteAddr = "10.1.1.1:5555"
+	r.Header.Set(XFORWARDEDFOR, "1.2.3.4, 198.51.100.1, 192.0.2.1")
+
+	ip, source := resolver.Resolve(r)
+
+	assert.Equal(t, "198.51.100.1", ip.String())
+	assert.Equal(t, XFORWARDEDFOR, source)
+}
+
+func TestResolveForwarded(t *testing.T) {
+
+	resolver := NewResolver([]string{"10.0.0.0/8"})
+
+	r, _ := http.NewRequest("GET", "/me", nil)
+	r.RemoteAddr = "10.1.1.1:5555"
+	r.Header.Set(FORWARDED, "for=\"[2001:db8::1]:4711\";proto=https, for=10.2.2.2")
+
+	ip, source := resolver.Resolve(r)
+
+	assert.Equal(t, "2001:db8::1", ip.String())
+	assert.Equal(t, FORWARDED, source)
+}
+
+func TestResolveRealIP(t *testing.T) {
+
+	resolver := NewResolver([]string{"10.0.0.0/8", "not a cidr"})
+
+	r, _ := http.NewRequest("GET", "/me", nil)
+	r.RemoteAddr = "10.1.1.1:5555"
+	r.Header.Set(XREALIP, "198.51.100.9")
+
+	ip, source := resolver.Resolve(r)
+
+	assert.Equal(t, 1, len(resolver.TrustedProxies))
+	assert.Equal(t, "198.51.100.9", ip.String())
+	assert.Equal(t, XREALIP, source)
+}
@@ -0,0 +1,115 @@
+package main
+
+//Builds the lookup index served with INDEXFILE, from an IP2Proxy CSV file or from the database table.
+//
+//Usage:
+//	ip2proxy-index [-config env] -out FILE                  (reads the table of the configured database)
+//	ip2proxy-index -csv FILE -version VERSION -out FILE
+//
+//The server only uses an index of the dataset version the database reports, indexes built from a CSV
+//need the version of the table the CSV was imported into.
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/nullc0rp/go-ip2proxy-api/config"
+	"github.com/nullc0rp/go-ip2proxy-api/database"
+	"github.com/nullc0rp/go-ip2proxy-api/dataset"
+	"github.com/nullc0rp/go-ip2proxy-api/index"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+)
+
+const (
+	USAGE     = "Usage: ip2proxy-index [flags] -out FILE\n\nFlags:\n"
+	NOOUTPUT  = "-out is required"
+	NOVERSION = "-version is required for a CSV, use the version the database reports for its table"
+	BUILT     = "%d ranges of the dataset %q indexed in %s\n"
+)
+
+// Source is a dataset read in order, both the CSV and the database provide it
+type Source interface {
+	EachRange(visit func(found *service.IPDataRange) error) error
+}
+
+func main() {
+	csvFile := flag.String("csv", "", "IP2Proxy CSV file, replaces the database")
+	env := flag.String("config", "dev", "configuration of the database, config/<env>_config.json")
+	out := flag.String("out", "", "index file to write")
+	version := flag.String("version", "", "dataset version of the index, read from the database when not set")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), USAGE)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *out == "" {
+		fail(errors.New(NOOUTPUT))
+	}
+
+	var source Source
+	var fields []string
+	if *csvFile != "" {
+		if *version == "" {
+			fail(errors.New(NOVERSION))
+		}
+		loaded, err := dataset.Load(*csvFile)
+		if err != nil {
+			fail(err)
+		}
+		source, fields = loaded, loaded.Fields
+	} else {
+		configuration := config.GetConfig(*env)
+		db := &database.DatabaseImpl{
+			Server:   configuration.DBHOST,
+			User:     configuration.DBUSERNAME,
+			Password: configuration.DBPASSWORD,
+			Database: configuration.DBNAME,
+		}
+		db.Connect()
+		defer db.Disconnect()
+
+		columns, err := service.DetectColumns(db)
+		if err != nil {
+			fail(err)
+		}
+		table := service.ServiceImp{DB: db, Columns: columns, Version: configuration.DATASETVERSION}
+		if *version == "" {
+			if *version, err = table.DatasetVersion(); err != nil {
+				fail(err)
+			}
+		}
+		source, fields = table, columns
+		if fields == nil {
+			fields = service.PX7FIELDS
+		}
+	}
+
+	count, err := build(source, fields, *version, *out)
+	if err != nil {
+		fail(err)
+	}
+	fmt.Printf(BUILT, count, *version, *out)
+}
+
+// build writes the index of every range of the source, returning how many there were
+func build(source Source, fields []string, version string, out string) (int, error) {
+	builder := index.NewBuilder(fields, version)
+	count := 0
+	err := source.EachRange(func(found *service.IPDataRange) error {
+		count++
+		return builder.Add(found.IPFrom, found.IPTo, found.Data)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, builder.WriteFile(out)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
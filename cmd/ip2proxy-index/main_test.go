@@ -0,0 +1,35 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nullc0rp/go-ip2proxy-api/dataset"
+	"github.com/nullc0rp/go-ip2proxy-api/index"
+	"github.com/stretchr/testify/assert"
+)
+
+const SAMPLE = `"168430081","168430090","PUB","PL","Poland","Mazowieckie","Warsaw","Opera Software ASA","opera.com","DCH","1299","Opera"
+"168430100","168430110","VPN","DE","Germany","Berlin","Berlin","Opera Software ASA","opera.com","DCH","1299","Opera"
+`
+
+func TestBuildCSV(t *testing.T) {
+
+	backend, _ := dataset.Read(strings.NewReader(SAMPLE))
+	out := filepath.Join(t.TempDir(), "ip2proxy.idx")
+
+	count, err := build(backend, backend.Fields, "2024-01-01 00:00:00", out)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+
+	ix, err := index.Open(out)
+	assert.Nil(t, err)
+	defer ix.Close()
+
+	assert.Nil(t, ix.Check("2024-01-01 00:00:00", backend.Fields))
+	found, ok := ix.Find(168430105)
+	assert.True(t, ok)
+	assert.Equal(t, "Germany", found.Data.CountryName)
+	assert.Equal(t, "Opera", found.Data.AS)
+}
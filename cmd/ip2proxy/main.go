@@ -0,0 +1,208 @@
+package main
+
+//Command line client for ad-hoc lookups and reports.
+//It queries a running server, or a local IP2Proxy CSV file with -dataset.
+//
+//Usage:
+//	ip2proxy [flags] lookup <ip>...
+//	ip2proxy [flags] batch [file]     (reads stdin without file)
+//	ip2proxy [flags] list <country> [limit]
+//	ip2proxy [flags] total <country>
+//	ip2proxy [flags] isp <country>
+//	ip2proxy [flags] proxytypes
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nullc0rp/go-ip2proxy-api/client"
+	"github.com/nullc0rp/go-ip2proxy-api/dataset"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+)
+
+const (
+	USAGE        = "Usage: ip2proxy [flags] lookup|batch|list|total|isp|proxytypes [args]\n\nFlags:\n"
+	BADIP        = "bad IP address"
+	BADCOMMAND   = "Unknown command: %s"
+	MISSINGARG   = "Missing argument for %s"
+	DEFAULTLIMIT = 50
+)
+
+// Backend is the part of the service used by the commands, both the server and a local dataset provide it
+type Backend interface {
+	GetIPInfo(ip net.IP) (*service.IPData, error)
+	GetIPCountry(country string, limit int) (*service.IPCountryData, error)
+	GetISPCountry(country string) (*service.ISPCountryData, error)
+	GetCountryTotal(country string) (*service.IPCountryTotal, error)
+	MostProxyTypes() (*service.MostProxyTypeResult, error)
+}
+
+func main() {
+	server := flag.String("server", client.DEFAULTURL, "API server URL")
+	datasetFile := flag.String("dataset", "", "local IP2Proxy CSV file, replaces the server")
+	caFile := flag.String("ca", "", "CA bundle to verify the server")
+	insecure := flag.Bool("insecure", false, "skip server certificate verification")
+	timeout := flag.Duration("timeout", 10*time.Second, "timeout for each request")
+	retries := flag.Int("retries", 2, "retries for unavailable servers")
+	format := flag.String("format", TABLE, "output format: table, json or csv")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), USAGE)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	printer, err := NewPrinter(*format, os.Stdout)
+	if err != nil {
+		fail(err)
+	}
+
+	//Both backends answer through the same interface
+	var backend Backend
+	if *datasetFile != "" {
+		backend, err = dataset.Load(*datasetFile)
+	} else {
+		backend, err = NewRemote(client.Options{
+			BaseURL:            *server,
+			CAFile:             *caFile,
+			InsecureSkipVerify: *insecure,
+			MaxRetries:         *retries,
+		}, *timeout)
+	}
+	if err != nil {
+		fail(err)
+	}
+
+	if err := run(backend, printer, flag.Arg(0), flag.Args()[1:]); err != nil {
+		fail(err)
+	}
+}
+
+// run dispatches the subcommand
+func run(backend Backend, printer Printer, command string, args []string) error {
+	switch command {
+	case "lookup":
+		if len(args) == 0 {
+			return fmt.Errorf(MISSINGARG, command)
+		}
+		return printer.Lookups(lookupAll(backend, args), datasetFields(backend))
+
+	case "batch":
+		input := io.Reader(os.Stdin)
+		if len(args) > 0 && args[0] != "-" {
+			file, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			input = file
+		}
+		addresses, err := readAddresses(input)
+		if err != nil {
+			return err
+		}
+		return printer.Lookups(lookupAll(backend, addresses), datasetFields(backend))
+
+	case "list":
+		if len(args) == 0 {
+			return fmt.Errorf(MISSINGARG, command)
+		}
+		limit := DEFAULTLIMIT
+		if len(args) > 1 {
+			var err error
+			if limit, err = strconv.Atoi(args[1]); err != nil {
+				return err
+			}
+		}
+		result, err := backend.GetIPCountry(strings.ToUpper(args[0]), limit)
+		if err != nil {
+			return err
+		}
+		return printer.IPList(result)
+
+	case "total":
+		if len(args) == 0 {
+			return fmt.Errorf(MISSINGARG, command)
+		}
+		result, err := backend.GetCountryTotal(strings.ToUpper(args[0]))
+		if err != nil {
+			return err
+		}
+		return printer.Total(result)
+
+	case "isp":
+		if len(args) == 0 {
+			return fmt.Errorf(MISSINGARG, command)
+		}
+		result, err := backend.GetISPCountry(strings.ToUpper(args[0]))
+		if err != nil {
+			return err
+		}
+		return printer.ISPList(result)
+
+	case "proxytypes":
+		result, err := backend.MostProxyTypes()
+		if err != nil {
+			return err
+		}
+		return printer.ProxyTypes(result)
+	}
+
+	return fmt.Errorf(BADCOMMAND, command)
+}
+
+// datasetFields are the fields of a local dataset, nil for a server that doesn't tell them
+func datasetFields(backend Backend) []string {
+	if local, ok := backend.(*dataset.Dataset); ok {
+		return local.Fields
+	}
+	return nil
+}
+
+// lookupAll looks up every address, failures are reported per address instead of stopping the batch
+func lookupAll(backend Backend, addresses []string) []*Lookup {
+	lookups := []*Lookup{}
+	for _, address := range addresses {
+		lookup := &Lookup{IP: address}
+		ip := net.ParseIP(address)
+		if ip == nil {
+			lookup.Error = BADIP
+		} else if data, err := backend.GetIPInfo(ip); err != nil {
+			lookup.Error = err.Error()
+		} else {
+			lookup.IPData = data
+		}
+		lookups = append(lookups, lookup)
+	}
+	return lookups
+}
+
+// readAddresses reads one address per line, skipping blanks and comments
+func readAddresses(input io.Reader) ([]string, error) {
+	addresses := []string{}
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		addresses = append(addresses, line)
+	}
+	return addresses, scanner.Err()
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nullc0rp/go-ip2proxy-api/dataset"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+	"github.com/stretchr/testify/assert"
+)
+
+const SAMPLE = `"168430081","168430090","PUB","PL","Poland","Mazowieckie","Warsaw","Opera Software ASA","opera.com","DCH","1299","Opera"
+`
+
+func TestRunLookupCSV(t *testing.T) {
+
+	backend, _ := dataset.Read(strings.NewReader(SAMPLE))
+	out := &bytes.Buffer{}
+	printer, _ := NewPrinter(CSV, out)
+
+	err := run(backend, printer, "lookup", []string{"10.10.10.1", "nope"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "ip_address,proxy_type,country_code,country_name,region_name,city_name,isp,domain,usage_type,asn,as,error\n"+
+		"10.10.10.1,PUB,PL,Poland,Mazowieckie,Warsaw,Opera Software ASA,opera.com,DCH,1299,Opera,\n"+
+		"nope,,,,,,,,,,,bad IP address\n", out.String())
+}
+
+func TestRunLookupDatasetFields(t *testing.T) {
+
+	//A PX2 dataset only has the country, a PX8 one the last seen days as well
+	for sample, expected := range map[string]string{
+		`"168430081","168430090","PL","Poland"` + "\n": "ip_address,country_code,country_name,error\n" +
+			"10.10.10.1,PL,Poland,\n",
+		`"168430081","168430090","PUB","PL","Poland","Mazowieckie","Warsaw","Opera Software ASA","opera.com","DCH","1299","Opera","12"` + "\n": "ip_address,proxy_type,country_code,country_name,region_name,city_name,isp,domain,usage_type,asn,as,last_seen,error\n" +
+			"10.10.10.1,PUB,PL,Poland,Mazowieckie,Warsaw,Opera Software ASA,opera.com,DCH,1299,Opera,12,\n",
+	} {
+		backend, _ := dataset.Read(strings.NewReader(sample))
+		out := &bytes.Buffer{}
+		printer, _ := NewPrinter(CSV, out)
+
+		err := run(backend, printer, "lookup", []string{"10.10.10.1"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, expected, out.String())
+	}
+}
+
+func TestLookupsUnknownFields(t *testing.T) {
+
+	out := &bytes.Buffer{}
+	printer, _ := NewPrinter(CSV, out)
+
+	//The fields of a server are the PX7 ones and the ones it answered
+	err := printer.Lookups([]*Lookup{
+		{IP: "10.10.10.1", IPData: &service.IPData{ProxyType: "PUB", Threat: "SPAM"}},
+		{IP: "10.10.10.2", Error: "not found"},
+	}, nil)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "ip_address,proxy_type,country_code,country_name,region_name,city_name,isp,domain,usage_type,asn,as,threat,error\n"+
+		"10.10.10.1,PUB,,,,,,,,,,SPAM,\n"+
+		"10.10.10.2,,,,,,,,,,,,not found\n", out.String())
+}
+
+func TestRunTotalJSON(t *testing.T) {
+
+	backend, _ := dataset.Read(strings.NewReader(SAMPLE))
+	out := &bytes.Buffer{}
+	printer, _ := NewPrinter(JSON, out)
+
+	err := run(backend, printer, "total", []string{"pl"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "{\n  \"total_ip\": 10\n}\n", out.String())
+}
+
+func TestRunUnknownCommand(t *testing.T) {
+
+	printer, _ := NewPrinter(TABLE, &bytes.Buffer{})
+
+	err := run(nil, printer, "whois", nil)
+
+	assert.Equal(t, "Unknown command: whois", err.Error())
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+)
+
+const (
+	TABLE     = "table"
+	JSON      = "json"
+	CSV       = "csv"
+	BADFORMAT = "Unknown format: %s"
+)
+
+// Lookup is the result of a single address in lookup and batch commands
+type Lookup struct {
+	IP    string `json:"ip_address"`
+	Error string `json:"error,omitempty"`
+	*service.IPData
+}
+
+// Printer renders the command results in the selected format
+type Printer struct {
+	format string
+	out    io.Writer
+}
+
+// NewPrinter validates the format
+func NewPrinter(format string, out io.Writer) (Printer, error) {
+	switch format {
+	case TABLE, JSON, CSV:
+		return Printer{format: format, out: out}, nil
+	}
+	return Printer{}, fmt.Errorf(BADFORMAT, format)
+}
+
+// Lookups prints address lookups with a column for each field of the dataset, in IPData order.
+// Without the dataset fields, as from a server, the PX7 fields and the ones some lookup has are printed
+func (p Printer) Lookups(lookups []*Lookup, fields []string) error {
+	columns := []string{}
+	for _, field := range service.IPDATAFIELDS {
+		if hasField(fields, field, lookups) {
+			columns = append(columns, field)
+		}
+	}
+
+	header := append(append([]string{"ip_address"}, columns...), "error")
+	rows := [][]string{}
+	for _, lookup := range lookups {
+		data := lookup.IPData
+		if data == nil {
+			data = &service.IPData{}
+		}
+		row := []string{lookup.IP}
+		for _, field := range columns {
+			row = append(row, *data.FieldPointer(field))
+		}
+		rows = append(rows, append(row, lookup.Error))
+	}
+	return p.print(lookups, header, rows)
+}
+
+// hasField tells if the dataset has the field, guessed from the lookups when its fields are unknown
+func hasField(fields []string, field string, lookups []*Lookup) bool {
+	if fields == nil {
+		fields = service.PX7FIELDS
+		for _, lookup := range lookups {
+			if lookup.IPData != nil && *lookup.IPData.FieldPointer(field) != "" {
+				return true
+			}
+		}
+	}
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// IPList prints the addresses of a country
+func (p Printer) IPList(result *service.IPCountryData) error {
+	rows := [][]string{}
+	for _, ip := range result.IPList {
+		rows = append(rows, []string{ip.IP, ip.CountryName, ip.CityName})
+	}
+	return p.print(result, []string{"ip_address", "country_name", "city_name"}, rows)
+}
+
+// Total prints the address count of a country
+func (p Printer) Total(result *service.IPCountryTotal) error {
+	return p.print(result, []string{"total_ip"}, [][]string{{strconv.Itoa(result.Total)}})
+}
+
+// ISPList prints the ISP names of a country
+func (p Printer) ISPList(result *service.ISPCountryData) error {
+	rows := [][]string{}
+	for _, isp := range result.ISPList {
+		rows = append(rows, []string{isp.Name})
+	}
+	return p.print(result, []string{"isp"}, rows)
+}
+
+// ProxyTypes prints the proxy type stats
+func (p Printer) ProxyTypes(result *service.MostProxyTypeResult) error {
+	rows := [][]string{}
+	for _, proxyType := range result.ProxyTypeList {
+		rows = append(rows, []string{proxyType.ProxyType, strconv.Itoa(proxyType.Total)})
+	}
+	return p.print(result, []string{"proxy_type", "total"}, rows)
+}
+
+// print writes the value as json, or the rows as csv or an aligned table
+func (p Printer) print(value interface{}, header []string, rows [][]string) error {
+	switch p.format {
+	case JSON:
+		encoder := json.NewEncoder(p.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+
+	case CSV:
+		writer := csv.NewWriter(p.out)
+		writer.Write(header)
+		writer.WriteAll(rows)
+		return writer.Error()
+	}
+
+	writer := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, strings.ToUpper(strings.Join(header, "\t")))
+	for _, row := range rows {
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+	return writer.Flush()
+}
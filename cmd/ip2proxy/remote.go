@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/nullc0rp/go-ip2proxy-api/client"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+)
+
+// Remote adapts the API client to the Backend interface, each call gets its own timeout
+type Remote struct {
+	client  *client.Client
+	timeout time.Duration
+}
+
+// NewRemote creates the client for the server
+func NewRemote(options client.Options, timeout time.Duration) (*Remote, error) {
+	options.Timeout = timeout
+	apiClient, err := client.NewClient(options)
+	if err != nil {
+		return nil, err
+	}
+	return &Remote{client: apiClient, timeout: timeout}, nil
+}
+
+func (r *Remote) GetIPInfo(ip net.IP) (*service.IPData, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+	return r.client.GetIPInfo(ctx, ip)
+}
+
+func (r *Remote) GetIPCountry(country string, limit int) (*service.IPCountryData, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+	return r.client.GetIPCountry(ctx, country, limit)
+}
+
+func (r *Remote) GetISPCountry(country string) (*service.ISPCountryData, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+	return r.client.GetISPCountry(ctx, country)
+}
+
+func (r *Remote) GetCountryTotal(country string) (*service.IPCountryTotal, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+	return r.client.GetCountryTotal(ctx, country)
+}
+
+func (r *Remote) MostProxyTypes() (*service.MostProxyTypeResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+	return r.client.MostProxyTypes(ctx)
+}
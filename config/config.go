@@ -12,6 +12,46 @@ type Configuration struct {
 	DBPORT     string
 	DBHOST     string
 	DBNAME     string
+	GRPCPORT   string
+	//Logs responses that don't match the OpenAPI document
+	OPENAPIVALIDATION bool
+	//Addresses or CIDRs of the proxies allowed to set forwarding headers
+	TRUSTEDPROXIES []string
+	//Collector the traces are sent to over OTLP/gRPC, as host:port. Empty disables tracing
+	OTLPENDPOINT string
+	//Sends the traces without TLS, for a collector next to the server
+	OTLPINSECURE bool
+	//Lowest level logged: debug, info, warn or error. Queries are only logged at debug
+	LOGLEVEL string
+	//Where the JSON lines go: stdout, stderr or a file path
+	LOGOUTPUT string
+	//Level of some packages, like {"database": "debug"}
+	LOGPACKAGES map[string]string
+	//Access log format: common, combined or json
+	ACCESSLOGFORMAT string
+	//Where the access log goes: stdout, stderr or a file path, rotated by size
+	ACCESSLOGOUTPUT string
+	//Rotation of the access log file, the size in megabytes and how many and how old rotated files are kept
+	ACCESSLOGMAXSIZEMB  int
+	ACCESSLOGMAXBACKUPS int
+	ACCESSLOGMAXAGEDAYS int
+	//Rewrites the addresses written to logs, access records and traces: off, truncate or hash
+	PRIVACYMODE string
+	//Key of the hashes, so they can be matched across restarts. A random one is used when empty
+	PRIVACYSALT string
+	//Ranges found, and addresses not in the dataset, kept in memory. 100000 by default and none when negative
+	LOOKUPCACHESIZE int
+	//How long ranges found and addresses not in the dataset are kept, an hour and 10 minutes by default
+	LOOKUPCACHETTLSECONDS         int
+	LOOKUPCACHENEGATIVETTLSECONDS int
+	//Version of the loaded dataset, read from the ip2proxy_metadata row written by the import when empty
+	DATASETVERSION string
+	//How often the dataset version is read, the lookups kept are dropped when it changes. A minute by default
+	DATASETCHECKSECONDS int
+	//Lookup index built with ip2proxy-index, lookups are answered from it while it matches the dataset version. Empty disables it
+	INDEXFILE string
+	//Answers the addresses out of every range of the dataset from a 2 MB bitmap, built in the background at start and when the version changes
+	NEGATIVEFILTER bool
 }
 
 func GetConfig(params ...string) Configuration {
@@ -1,24 +1,30 @@
 package controller
 
 import (
-	"encoding/json"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"github.com/nullc0rp/go-ip2proxy-api/accesslog"
+	"github.com/nullc0rp/go-ip2proxy-api/clientip"
+	"github.com/nullc0rp/go-ip2proxy-api/render"
 	"github.com/nullc0rp/go-ip2proxy-api/service"
 )
 
 //Controller handles requests and filter common requests
 type ControllerImpl struct {
-	Service service.Service
+	Service  service.Service
+	Resolver *clientip.Resolver
+	//Logger writes the requests and their failures, slog.Default() when nil
+	Logger *slog.Logger
 }
 
 //Controller interface
 type Controller interface {
 	GetIpInfo(w http.ResponseWriter, r *http.Request)
+	GetMyIpInfo(w http.ResponseWriter, r *http.Request)
 	GetIpList(w http.ResponseWriter, r *http.Request)
 	GetISPCountry(w http.ResponseWriter, r *http.Request)
 	GetIPTotalCountry(w http.ResponseWriter, r *http.Request)
@@ -26,70 +32,199 @@ type Controller interface {
 }
 
 const (
-	MAXROWS      = 1000
-	SERVICEERROR = "Service error"
-	BADIPADDRESS = "Bad IP address"
-	ERRORMARSHAL = "Error Marshal"
-	CONTENTYPE   = "Content-Type"
-	APPJSON      = "application/json"
-	BADCOUNTRY   = "Bad country code"
-	ERRORLIMIT   = "Error parsing limit"
-	ERROR        = "Error"
-	COUNTRY      = "country"
-	ADDRESS      = "address"
+	MAXROWS       = 1000
+	SERVICEERROR  = "Service error"
+	BADIPADDRESS  = "Bad IP address"
+	NOIPV6        = "No data for IPv6 addresses, the dataset is IPv4 only"
+	ERRORMARSHAL  = "Error Marshal"
+	CONTENTYPE    = "Content-Type"
+	APPJSON       = "application/json"
+	TEXTPLAIN     = "text/plain; charset=utf-8"
+	BADCOUNTRY    = "Bad country code"
+	ERRORLIMIT    = "Error parsing limit"
+	ERROR         = "Error"
+	COUNTRY       = "country"
+	ADDRESS       = "address"
+	FIELDS        = "fields"
+	IPDATA        = "IPData"
+	NOTACCEPTABLE = "Not acceptable, the supported formats are json, csv, xml, msgpack and protobuf"
+	IPLIST        = "IPList"
+	ISPLIST       = "ISPList"
+	PROXYTYPELIST = "ProxyTypeList"
+	MAXAGEDAYS    = "max_age_days"
+	BADMAXAGE     = "Bad max_age_days, it must be a number of days from 1"
+	SOURCE        = "source"
+	ERRORKEY      = "error"
 )
 
+// logger is the configured logger or the default one
+func (c ControllerImpl) logger() *slog.Logger {
+	if c.Logger == nil {
+		return slog.Default()
+	}
+	return c.Logger
+}
+
 //GetIpInfo is the controller for IP Information endpoint
 func (c ControllerImpl) GetIpInfo(w http.ResponseWriter, r *http.Request) {
 
+	// Pick the response format
+	encoder, ok := render.Negotiate(r)
+	if !ok {
+		c.logger().WarnContext(r.Context(), NOTACCEPTABLE)
+		WriteErrorCode(w, http.StatusNotAcceptable, NOTACCEPTABLE)
+		return
+	}
+
+	// Leave out the ranges not seen lately when asked to
+	svc, ok := maxAge(c.Service, r)
+	if !ok {
+		c.logger().WarnContext(r.Context(), BADMAXAGE)
+		WriteErrorCode(w, http.StatusBadRequest, BADMAXAGE)
+		return
+	}
+
 	// Get path vars
 	vars := mux.Vars(r)
 
-	log.Printf("Received request for ip info: %s\n", vars[ADDRESS])
+	c.logger().DebugContext(r.Context(), "Received request for ip info", ADDRESS, vars[ADDRESS])
 
 	// Parse and validate ip address
 	ip := net.ParseIP(vars[ADDRESS])
 	if ip == nil {
-		log.Println(BADIPADDRESS)
+		c.logger().WarnContext(r.Context(), BADIPADDRESS)
 		WriteError(w, BADIPADDRESS)
 		return
 	}
 
+	// Get the selected fields, nil for all of them
+	fields, err := selectedFields(r)
+	if err != nil {
+		c.logger().WarnContext(r.Context(), err.Error())
+		WriteErrorCode(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// Get service data
-	result, err := c.Service.GetIPInfo(ip)
+	result, err := lookup(svc, ip, fields)
+	accesslog.SetResult(r.Context(), accesslog.Classify(result, err))
 	if err != nil {
-		log.Println(ERROR, ip, err)
+		c.logger().ErrorContext(r.Context(), SERVICEERROR, ADDRESS, ip.String(), ERRORKEY, err)
+		WriteError(w, SERVICEERROR)
+		return
+	}
+
+	//Only the selected fields are returned, in their order
+	var response interface{} = result
+	if fields != nil {
+		response = selection(result, fields)
+	}
+
+	//Encode result in the requested format
+	if err := render.Write(w, encoder, response); err != nil {
+		c.logger().ErrorContext(r.Context(), ERRORMARSHAL, ERRORKEY, err)
 		WriteError(w, SERVICEERROR)
 		return
 	}
+}
+
+// GetMyIpInfo is the controller for the caller's own IP Information endpoint
+func (c ControllerImpl) GetMyIpInfo(w http.ResponseWriter, r *http.Request) {
+
+	// Pick the response format
+	encoder, ok := render.Negotiate(r)
+	if !ok {
+		c.logger().WarnContext(r.Context(), NOTACCEPTABLE)
+		WriteErrorCode(w, http.StatusNotAcceptable, NOTACCEPTABLE)
+		return
+	}
+
+	// Leave out the ranges not seen lately when asked to
+	svc, ok := maxAge(c.Service, r)
+	if !ok {
+		c.logger().WarnContext(r.Context(), BADMAXAGE)
+		WriteErrorCode(w, http.StatusBadRequest, BADMAXAGE)
+		return
+	}
+
+	// Without a resolver no proxy is trusted and the direct hop is used
+	resolver := c.Resolver
+	if resolver == nil {
+		resolver = &clientip.Resolver{}
+	}
+
+	// Resolve the client address
+	ip, source := resolver.Resolve(r)
+	if ip == nil {
+		c.logger().WarnContext(r.Context(), BADIPADDRESS)
+		WriteError(w, BADIPADDRESS)
+		return
+	}
+
+	c.logger().DebugContext(r.Context(), "Received request for own ip info", ADDRESS, ip.String(), SOURCE, source)
+
+	// The lookup only reads IPv4 addresses, an IPv6 one would match the address of its last 4 bytes
+	if ip.To4() == nil {
+		accesslog.SetResult(r.Context(), accesslog.Classify(nil, service.NoResultError(NOIPV6)))
+		WriteErrorCode(w, http.StatusNotFound, NOIPV6)
+		return
+	}
 
-	//Parse result data in Json format
-	jData, err := json.Marshal(result)
+	// Get service data
+	result, err := svc.GetIPInfo(ip)
+	accesslog.SetResult(r.Context(), accesslog.Classify(result, err))
 	if err != nil {
-		log.Println(ERRORMARSHAL)
+		c.logger().ErrorContext(r.Context(), SERVICEERROR, ADDRESS, ip.String(), ERRORKEY, err)
 		WriteError(w, SERVICEERROR)
 		return
 	}
 
-	w.Header().Set(CONTENTYPE, APPJSON)
-	w.Write(jData)
+	//Result carrier
+	myIPData := &MyIPDataResult{
+		IP:     ip.String(),
+		Source: source,
+		IPData: result,
+	}
+
+	//Encode result in the requested format
+	if err := render.Write(w, encoder, myIPData); err != nil {
+		c.logger().ErrorContext(r.Context(), ERRORMARSHAL, ERRORKEY, err)
+		WriteError(w, SERVICEERROR)
+		return
+	}
 }
 
 //GetIpList is the controller to ammount of addresses determined by limit parameter or 50 by default.
 func (c ControllerImpl) GetIpList(w http.ResponseWriter, r *http.Request) {
 
+	// Pick the response format
+	encoder, ok := render.Negotiate(r)
+	if !ok {
+		c.logger().WarnContext(r.Context(), NOTACCEPTABLE)
+		WriteErrorCode(w, http.StatusNotAcceptable, NOTACCEPTABLE)
+		return
+	}
+
+	// Leave out the ranges not seen lately when asked to
+	svc, ok := maxAge(c.Service, r)
+	if !ok {
+		c.logger().WarnContext(r.Context(), BADMAXAGE)
+		WriteErrorCode(w, http.StatusBadRequest, BADMAXAGE)
+		return
+	}
+
 	vars := mux.Vars(r)
 
 	// Get and filter input values
 	country := OnlyCountryCode(vars[COUNTRY])
-	log.Printf("Received request for ip list by country %s\n", country)
+	c.logger().DebugContext(r.Context(), "Received request for ip list by country", COUNTRY, country)
 	limit := OnlyInt(r.URL.Query().Get("limit"))
-	log.Printf("Limit %s\n", limit)
+	c.logger().DebugContext(r.Context(), "Limit", LIMIT, limit)
 
 	//Parse limit
 	intLimit, err := strconv.Atoi(limit)
 	if err != nil {
-		log.Println(ERRORLIMIT, err)
+		c.logger().WarnContext(r.Context(), ERRORLIMIT, ERRORKEY, err)
 		intLimit = 0
 	}
 
@@ -99,108 +234,140 @@ func (c ControllerImpl) GetIpList(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get service data
-	result, err := c.Service.GetIPCountry(country, intLimit)
+	result, err := svc.GetIPCountry(country, intLimit)
 	if err != nil {
-		log.Println(ERROR, err)
+		c.logger().ErrorContext(r.Context(), SERVICEERROR, ERRORKEY, err)
 		WriteError(w, SERVICEERROR)
 		return
 	}
 
-	// Result json
-	jData, err := json.Marshal(result)
-	if err != nil {
-		log.Println(ERRORMARSHAL)
-		WriteError(w, SERVICEERROR)
-		return
+	//Stream the list in the requested format
+	if err := render.WriteList(w, encoder, result, IPLIST); err != nil {
+		c.logger().ErrorContext(r.Context(), ERRORMARSHAL, ERRORKEY, err)
 	}
-
-	w.Header().Set(CONTENTYPE, APPJSON)
-	w.Write(jData)
 }
 
 // GetISPCountry is the controller to get all the ISP by country
 func (c ControllerImpl) GetISPCountry(w http.ResponseWriter, r *http.Request) {
 
+	// Pick the response format
+	encoder, ok := render.Negotiate(r)
+	if !ok {
+		c.logger().WarnContext(r.Context(), NOTACCEPTABLE)
+		WriteErrorCode(w, http.StatusNotAcceptable, NOTACCEPTABLE)
+		return
+	}
+
+	// Leave out the ranges not seen lately when asked to
+	svc, ok := maxAge(c.Service, r)
+	if !ok {
+		c.logger().WarnContext(r.Context(), BADMAXAGE)
+		WriteErrorCode(w, http.StatusBadRequest, BADMAXAGE)
+		return
+	}
+
 	vars := mux.Vars(r)
 
 	// Get and filter input values
 	country := OnlyCountryCode(vars[COUNTRY])
-	log.Printf("Received request for isp list by country %s\n", country)
+	c.logger().DebugContext(r.Context(), "Received request for isp list by country", COUNTRY, country)
 	if len(country) < 2 {
-		log.Println(BADCOUNTRY)
+		c.logger().WarnContext(r.Context(), BADCOUNTRY)
 		WriteError(w, BADCOUNTRY)
 		return
 	}
 
 	//Get service data
-	result, err := c.Service.GetISPCountry(country)
+	result, err := svc.GetISPCountry(country)
 	if err != nil {
-		log.Println(ERROR, err)
+		c.logger().ErrorContext(r.Context(), SERVICEERROR, ERRORKEY, err)
 		WriteError(w, SERVICEERROR)
 		return
 	}
 
-	// Result json
-	jData, err := json.Marshal(result)
-	if err != nil {
-		WriteError(w, SERVICEERROR)
-		return
+	//Stream the list in the requested format
+	if err := render.WriteList(w, encoder, result, ISPLIST); err != nil {
+		c.logger().ErrorContext(r.Context(), ERRORMARSHAL, ERRORKEY, err)
 	}
-	w.Header().Set(CONTENTYPE, APPJSON)
-	w.Write(jData)
 }
 
 // GetIPTotalCountry is the controller to get the IP count for a country
 func (c ControllerImpl) GetIPTotalCountry(w http.ResponseWriter, r *http.Request) {
 
+	// Pick the response format
+	encoder, ok := render.Negotiate(r)
+	if !ok {
+		c.logger().WarnContext(r.Context(), NOTACCEPTABLE)
+		WriteErrorCode(w, http.StatusNotAcceptable, NOTACCEPTABLE)
+		return
+	}
+
+	// Leave out the ranges not seen lately when asked to
+	svc, ok := maxAge(c.Service, r)
+	if !ok {
+		c.logger().WarnContext(r.Context(), BADMAXAGE)
+		WriteErrorCode(w, http.StatusBadRequest, BADMAXAGE)
+		return
+	}
+
 	vars := mux.Vars(r)
 
 	// Get and filter input values
 	country := OnlyCountryCode(vars[COUNTRY])
-	log.Printf("Received request for ip count by country %s\n", country)
+	c.logger().DebugContext(r.Context(), "Received request for ip count by country", COUNTRY, country)
 	if len(country) < 2 {
-		log.Println(BADCOUNTRY)
+		c.logger().WarnContext(r.Context(), BADCOUNTRY)
 		WriteError(w, BADCOUNTRY)
 		return
 	}
 
 	//Get service data
-	result, err := c.Service.GetCountryTotal(country)
+	result, err := svc.GetCountryTotal(country)
 	if err != nil {
-		log.Println(ERROR, err)
+		c.logger().ErrorContext(r.Context(), SERVICEERROR, ERRORKEY, err)
 		WriteError(w, SERVICEERROR)
 		return
 	}
 
-	// Result json
-	jData, err := json.Marshal(result)
-	if err != nil {
+	//Encode result in the requested format
+	if err := render.Write(w, encoder, result); err != nil {
+		c.logger().ErrorContext(r.Context(), ERRORMARSHAL, ERRORKEY, err)
 		WriteError(w, SERVICEERROR)
 		return
 	}
-	w.Header().Set(CONTENTYPE, APPJSON)
-	w.Write(jData)
 }
 
 // GetMostProxyTypes is the controller to get the top 3 most proxy types
 func (c ControllerImpl) GetMostProxyTypes(w http.ResponseWriter, r *http.Request) {
 
-	log.Println("Received request for most proxy types")
+	// Pick the response format
+	encoder, ok := render.Negotiate(r)
+	if !ok {
+		c.logger().WarnContext(r.Context(), NOTACCEPTABLE)
+		WriteErrorCode(w, http.StatusNotAcceptable, NOTACCEPTABLE)
+		return
+	}
 
-	//Get service data
-	result, err := c.Service.MostProxyTypes()
-	if err != nil {
-		log.Println(ERROR, err)
-		WriteError(w, SERVICEERROR)
+	// Leave out the ranges not seen lately when asked to
+	svc, ok := maxAge(c.Service, r)
+	if !ok {
+		c.logger().WarnContext(r.Context(), BADMAXAGE)
+		WriteErrorCode(w, http.StatusBadRequest, BADMAXAGE)
 		return
 	}
 
-	// Result json
-	jData, err := json.Marshal(result)
+	c.logger().DebugContext(r.Context(), "Received request for most proxy types")
+
+	//Get service data
+	result, err := svc.MostProxyTypes()
 	if err != nil {
+		c.logger().ErrorContext(r.Context(), SERVICEERROR, ERRORKEY, err)
 		WriteError(w, SERVICEERROR)
 		return
 	}
-	w.Header().Set(CONTENTYPE, APPJSON)
-	w.Write(jData)
+
+	//Stream the list in the requested format
+	if err := render.WriteList(w, encoder, result, PROXYTYPELIST); err != nil {
+		c.logger().ErrorContext(r.Context(), ERRORMARSHAL, ERRORKEY, err)
+	}
 }
@@ -10,6 +10,7 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/gorilla/mux"
+	"github.com/nullc0rp/go-ip2proxy-api/clientip"
 	"github.com/nullc0rp/go-ip2proxy-api/mocks"
 	"github.com/nullc0rp/go-ip2proxy-api/service"
 	"github.com/stretchr/testify/assert"
@@ -501,3 +502,261 @@ func TestGetMostProxyTypesErrorService(t *testing.T) {
 
 	assert.Equal(t, string(w.Body.Bytes()), "Service error")
 }
+
+func TestGetMyIPInfoTrustedProxy(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	var controllerInstance Controller
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance = &ControllerImpl{
+		Service:  mockService,
+		Resolver: clientip.NewResolver([]string{"127.0.0.1"}),
+	}
+
+	r, _ := http.NewRequest("GET", "/me", nil)
+	r.RemoteAddr = "127.0.0.1:40000"
+	r.Header.Set("X-Forwarded-For", "10.10.10.1")
+	w := httptest.NewRecorder()
+
+	//Mock response
+	ipResponse := &service.IPData{
+		ProxyType: "PUB",
+	}
+
+	//Expects setup
+	mockService.EXPECT().GetIPInfo(net.ParseIP("10.10.10.1")).Return(ipResponse, nil)
+
+	controllerInstance.GetMyIpInfo(w, r)
+
+	assert.Equal(t, string(w.Body.Bytes()), "{\"ip_address\":\"10.10.10.1\",\"source\":\"X-Forwarded-For\",\"proxy_type\":\"PUB\",\"country_code\":\"\",\"country_name\":\"\",\"region_name\":\"\",\"city_name\":\"\",\"isp\":\"\",\"domain\":\"\",\"usage_type\":\"\",\"asn\":\"\",\"as\":\"\"}")
+}
+
+func TestGetMyIPInfoUntrusted(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	var controllerInstance Controller
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance = &ControllerImpl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/me", nil)
+	r.RemoteAddr = "127.0.0.1:40000"
+	r.Header.Set("X-Forwarded-For", "10.10.10.1")
+	w := httptest.NewRecorder()
+
+	//Expects setup
+	mockService.EXPECT().GetIPInfo(net.ParseIP("127.0.0.1")).Return(nil, errors.New("some dirty info"))
+
+	controllerInstance.GetMyIpInfo(w, r)
+
+	assert.Equal(t, string(w.Body.Bytes()), "Service error")
+}
+
+func TestGetMyIPInfoIPv6(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	var controllerInstance Controller
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance = &ControllerImpl{
+		Service:  mockService,
+		Resolver: clientip.NewResolver([]string{"127.0.0.1"}),
+	}
+
+	//The last 4 bytes are 10.10.10.1, the service is not asked
+	r, _ := http.NewRequest("GET", "/me", nil)
+	r.RemoteAddr = "127.0.0.1:40000"
+	r.Header.Set("X-Forwarded-For", "2001:db8::a0a:a01")
+	w := httptest.NewRecorder()
+
+	controllerInstance.GetMyIpInfo(w, r)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "No data for IPv6 addresses, the dataset is IPv4 only", w.Body.String())
+}
+
+func TestGetIPListCSV(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	var controllerInstance Controller
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance = &ControllerImpl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/country/AR?limit=2", nil)
+	r.Header.Set("Accept", "text/csv")
+	w := httptest.NewRecorder()
+
+	r = mux.SetURLVars(r, map[string]string{
+		"country": "AR",
+	})
+
+	//Mock response
+	ipListResponse := &service.IPCountryData{
+		Total: 2,
+		IPList: []*service.IPDataResult{
+			{IP: "1.0.4.1", CountryName: "Argentina", CityName: "Rosario"},
+			{IP: "1.0.4.2", CountryName: "Argentina", CityName: "Rosario"},
+		},
+	}
+
+	//Expects setup
+	mockService.EXPECT().GetIPCountry("AR", 2).Return(ipListResponse, nil)
+
+	controllerInstance.GetIpList(w, r)
+
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "ip_address,country_name,city_name\n1.0.4.1,Argentina,Rosario\n1.0.4.2,Argentina,Rosario\n", string(w.Body.Bytes()))
+}
+
+func TestGetIPInfoNotAcceptable(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	var controllerInstance Controller
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance = &ControllerImpl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/ip/10.10.10.1?format=yaml", nil)
+	w := httptest.NewRecorder()
+
+	r = mux.SetURLVars(r, map[string]string{
+		"address": "10.10.10.1",
+	})
+
+	controllerInstance.GetIpInfo(w, r)
+
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+}
+
+func TestGetIPInfoFields(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	var controllerInstance Controller
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance = &ControllerImpl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/ip/10.10.10.1?fields=country_code,proxy_type", nil)
+	w := httptest.NewRecorder()
+
+	r = mux.SetURLVars(r, map[string]string{
+		"address": "10.10.10.1",
+	})
+
+	//Expects setup, only the selected columns are looked up
+	mockService.EXPECT().GetIPInfoFields(net.ParseIP("10.10.10.1"), []string{"country_code", "proxy_type"}).Return(&service.IPData{ProxyType: "PUB", CountryCode: "PL"}, nil)
+
+	controllerInstance.GetIpInfo(w, r)
+
+	assert.Equal(t, "{\"country_code\":\"PL\",\"proxy_type\":\"PUB\"}", string(w.Body.Bytes()))
+}
+
+func TestGetIPInfoBadField(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	var controllerInstance Controller
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance = &ControllerImpl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/ip/10.10.10.1?fields=proxy_type,color", nil)
+	w := httptest.NewRecorder()
+
+	r = mux.SetURLVars(r, map[string]string{
+		"address": "10.10.10.1",
+	})
+
+	controllerInstance.GetIpInfo(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "Unknown field color", string(w.Body.Bytes()))
+}
+
+func TestGetIPTotalMaxAge(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	var controllerInstance Controller
+	mockService := mocks.NewMockService(controller)
+	scopedService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance = &ControllerImpl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/country/AR/total?max_age_days=7", nil)
+	w := httptest.NewRecorder()
+
+	r = mux.SetURLVars(r, map[string]string{
+		"country": "AR",
+	})
+
+	//Expects setup, only the fresh ranges are counted
+	mockService.EXPECT().MaxAge(7).Return(scopedService)
+	scopedService.EXPECT().GetCountryTotal("AR").Return(&service.IPCountryTotal{Total: 40}, nil)
+
+	controllerInstance.GetIPTotalCountry(w, r)
+
+	assert.Equal(t, "{\"total_ip\":40}", w.Body.String())
+}
+
+func TestGetIPTotalBadMaxAge(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	var controllerInstance Controller
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance = &ControllerImpl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/country/AR/total?max_age_days=week", nil)
+	w := httptest.NewRecorder()
+
+	r = mux.SetURLVars(r, map[string]string{
+		"country": "AR",
+	})
+
+	controllerInstance.GetIPTotalCountry(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, BADMAXAGE, w.Body.String())
+}
@@ -0,0 +1,697 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/nullc0rp/go-ip2proxy-api/accesslog"
+	"github.com/nullc0rp/go-ip2proxy-api/clientip"
+	"github.com/nullc0rp/go-ip2proxy-api/render"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+)
+
+// ControllerV2 serves the v2 routes, same service layer as v1 with the cleaned-up schema
+type ControllerV2 interface {
+	GetIpInfo(w http.ResponseWriter, r *http.Request)
+	GetMyIpInfo(w http.ResponseWriter, r *http.Request)
+	GetIpList(w http.ResponseWriter, r *http.Request)
+	GetISPCountry(w http.ResponseWriter, r *http.Request)
+	GetIPTotalCountry(w http.ResponseWriter, r *http.Request)
+	GetMostProxyTypes(w http.ResponseWriter, r *http.Request)
+	GetProviders(w http.ResponseWriter, r *http.Request)
+	GetProvider(w http.ResponseWriter, r *http.Request)
+	GetThreats(w http.ResponseWriter, r *http.Request)
+	GetThreat(w http.ResponseWriter, r *http.Request)
+	GetCodes(w http.ResponseWriter, r *http.Request)
+}
+
+// ControllerV2Impl wraps every response in the v2 envelope
+type ControllerV2Impl struct {
+	Service  service.Service
+	Resolver *clientip.Resolver
+	//Logger writes the requests and their failures, slog.Default() when nil
+	Logger *slog.Logger
+	//Version is the dataset version of the meta, read from the Service on every response when nil
+	Version *DatasetVersion
+}
+
+const (
+	APIV1        = "v1"
+	APIV2        = "v2"
+	DEFAULTLIMIT = 50
+	MAXOFFSET    = 9000
+	LIMIT        = "limit"
+	OFFSET       = "offset"
+	BADLIMIT     = "Bad limit, it must be between 1 and 1000"
+	BADOFFSET    = "Bad offset, it must be between 0 and 9000"
+	NOTFOUND     = "No data for country"
+	PROVIDER     = "provider"
+	BADPROVIDER  = "Bad provider name"
+	NOPROVIDER   = "No data for provider"
+	THREAT       = "threat"
+	ASN          = "asn"
+	BADASN       = "Bad ASN, it must be a number"
+	NOTHREAT     = "No data for threat"
+	DEPRECATION  = "Deprecation"
+	LINK         = "Link"
+	SUCCESSOR    = "<%s>; rel=\"successor-version\""
+
+	// Error codes of the v2 error model
+	CODEBADREQUEST = "bad_request"
+	CODENOTFOUND   = "not_found"
+	CODEINTERNAL   = "internal_error"
+	//CODENOTACCEPTABLE is answered in JSON, for formats that aren't supported or can't hold the data
+	CODENOTACCEPTABLE = "not_acceptable"
+)
+
+// NewRouter registers the REST routes of both versions.
+// v1 is served unprefixed and under /v1, flagged as deprecated, and v2 under /v2.
+func NewRouter(c Controller, v2 ControllerV2) *mux.Router {
+	r := mux.NewRouter()
+	for _, prefix := range []string{"", "/" + APIV1} {
+		r.HandleFunc(prefix+"/me", deprecated(traced("ControllerImpl.GetMyIpInfo", c.GetMyIpInfo))).Methods("GET")
+		r.HandleFunc(prefix+"/ip/{address:.*}", deprecated(traced("ControllerImpl.GetIpInfo", c.GetIpInfo))).Methods("GET")
+		r.HandleFunc(prefix+"/country/{country:[A-Z]+}", deprecated(traced("ControllerImpl.GetIpList", c.GetIpList))).Methods("GET")
+		r.HandleFunc(prefix+"/country/{country:[A-Z]+}/isp", deprecated(traced("ControllerImpl.GetISPCountry", c.GetISPCountry))).Methods("GET")
+		r.HandleFunc(prefix+"/country/{country:[A-Z]+}/total", deprecated(traced("ControllerImpl.GetIPTotalCountry", c.GetIPTotalCountry))).Methods("GET")
+		r.HandleFunc(prefix+"/proxytypes", deprecated(traced("ControllerImpl.GetMostProxyTypes", c.GetMostProxyTypes))).Methods("GET")
+	}
+
+	s := r.PathPrefix("/" + APIV2).Subrouter()
+	s.HandleFunc("/me", traced("ControllerV2Impl.GetMyIpInfo", v2.GetMyIpInfo)).Methods("GET")
+	s.HandleFunc("/ip/{address:.*}", traced("ControllerV2Impl.GetIpInfo", v2.GetIpInfo)).Methods("GET")
+	s.HandleFunc("/country/{country:[A-Z]+}", traced("ControllerV2Impl.GetIpList", v2.GetIpList)).Methods("GET")
+	s.HandleFunc("/country/{country:[A-Z]+}/isp", traced("ControllerV2Impl.GetISPCountry", v2.GetISPCountry)).Methods("GET")
+	s.HandleFunc("/country/{country:[A-Z]+}/total", traced("ControllerV2Impl.GetIPTotalCountry", v2.GetIPTotalCountry)).Methods("GET")
+	s.HandleFunc("/proxytypes", traced("ControllerV2Impl.GetMostProxyTypes", v2.GetMostProxyTypes)).Methods("GET")
+	s.HandleFunc("/provider", traced("ControllerV2Impl.GetProviders", v2.GetProviders)).Methods("GET")
+	s.HandleFunc("/provider/{provider}", traced("ControllerV2Impl.GetProvider", v2.GetProvider)).Methods("GET")
+	s.HandleFunc("/threat", traced("ControllerV2Impl.GetThreats", v2.GetThreats)).Methods("GET")
+	s.HandleFunc("/threat/{threat:[A-Z]+}", traced("ControllerV2Impl.GetThreat", v2.GetThreat)).Methods("GET")
+	s.HandleFunc("/codes", traced("ControllerV2Impl.GetCodes", v2.GetCodes)).Methods("GET")
+	return r
+}
+
+// deprecated points v1 callers to the same path under v2
+func deprecated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		successor := "/" + APIV2 + strings.TrimPrefix(r.URL.Path, "/"+APIV1)
+		w.Header().Set(DEPRECATION, "true")
+		w.Header().Set(LINK, fmt.Sprintf(SUCCESSOR, successor))
+		next(w, r)
+	}
+}
+
+// GetIpInfo is the v2 controller for IP Information, unknown addresses are found false
+func (c ControllerV2Impl) GetIpInfo(w http.ResponseWriter, r *http.Request) {
+
+	// Leave out the ranges not seen lately when asked to
+	svc, ok := maxAge(c.Service, r)
+	if !ok {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, BADMAXAGE)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	c.logger().DebugContext(r.Context(), "Received v2 request for ip info", ADDRESS, vars[ADDRESS])
+
+	// Parse and validate ip address
+	ip := net.ParseIP(vars[ADDRESS])
+	if ip == nil {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, BADIPADDRESS)
+		return
+	}
+
+	// Get the selected fields, left out fields are omitted like empty ones
+	fields, err := selectedFields(r)
+	if err != nil {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, err.Error())
+		return
+	}
+
+	c.writeIPData(w, r, svc, ip, "", fields)
+}
+
+// GetMyIpInfo is the v2 controller for the caller's own IP Information
+func (c ControllerV2Impl) GetMyIpInfo(w http.ResponseWriter, r *http.Request) {
+
+	// Leave out the ranges not seen lately when asked to
+	svc, ok := maxAge(c.Service, r)
+	if !ok {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, BADMAXAGE)
+		return
+	}
+
+	// Without a resolver no proxy is trusted and the direct hop is used
+	resolver := c.Resolver
+	if resolver == nil {
+		resolver = &clientip.Resolver{}
+	}
+
+	ip, source := resolver.Resolve(r)
+	if ip == nil {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, BADIPADDRESS)
+		return
+	}
+
+	c.logger().DebugContext(r.Context(), "Received v2 request for own ip info", ADDRESS, ip.String(), SOURCE, source)
+
+	// The lookup only reads IPv4 addresses, IPv6 clients are not found
+	if ip.To4() == nil {
+		accesslog.SetResult(r.Context(), accesslog.Classify(nil, service.NoResultError(NOIPV6)))
+		c.writeData(w, r, &V2IPData{IP: ip.String(), Source: source}, nil)
+		return
+	}
+
+	c.writeIPData(w, r, svc, ip, source, nil)
+}
+
+func (c ControllerV2Impl) writeIPData(w http.ResponseWriter, r *http.Request, svc service.Service, ip net.IP, source string, fields []string) {
+
+	// Get service data
+	result, err := lookup(svc, ip, fields)
+	accesslog.SetResult(r.Context(), accesslog.Classify(result, err))
+	if err != nil && !service.IsNoResult(err) {
+		c.logger().ErrorContext(r.Context(), SERVICEERROR, ADDRESS, ip.String(), ERRORKEY, err)
+		c.writeError(w, r, http.StatusInternalServerError, CODEINTERNAL, SERVICEERROR)
+		return
+	}
+
+	data := &V2IPData{IP: ip.String(), Source: source}
+	if err == nil && result != nil {
+		data.Found = true
+		data.ProxyType = result.ProxyType
+		data.CountryCode = result.CountryCode
+		data.CountryName = result.CountryName
+		data.RegionName = result.RegionName
+		data.CityName = result.CityName
+		data.ISP = result.ISP
+		data.Domain = result.Domain
+		data.UsageType = result.UsageType
+		data.ASN = result.ASN
+		data.AS = result.AS
+		data.LastSeen = result.LastSeen
+		data.Threat = result.Threat
+		data.Provider = result.Provider
+		data.FraudScore = result.FraudScore
+		data.ProxyTypeInfo = service.ProxyTypeCode(result.ProxyType)
+		data.UsageTypeInfo = service.UsageTypeCodes(result.UsageType)
+	}
+
+	//Stale ranges are not proxies, but their age is still told
+	var stale *service.StaleError
+	if errors.As(err, &stale) {
+		data.LastSeen = stale.LastSeen
+	}
+
+	c.writeData(w, r, data, nil)
+}
+
+// GetIpList is the v2 controller for a page of addresses of a country
+func (c ControllerV2Impl) GetIpList(w http.ResponseWriter, r *http.Request) {
+
+	// Leave out the ranges not seen lately when asked to
+	svc, ok := maxAge(c.Service, r)
+	if !ok {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, BADMAXAGE)
+		return
+	}
+
+	country, ok := countryCode(mux.Vars(r)[COUNTRY])
+	if !ok {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, BADCOUNTRY)
+		return
+	}
+	page, message := pagination(r)
+	if page == nil {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, message)
+		return
+	}
+
+	c.logger().DebugContext(r.Context(), "Received v2 request for ip list by country", COUNTRY, country)
+
+	//The service has no offset, so the addresses before the page are fetched and skipped
+	result, err := svc.GetIPCountry(country, page.Offset+page.Limit)
+	if err != nil && !service.IsNoResult(err) {
+		c.logger().ErrorContext(r.Context(), SERVICEERROR, ERRORKEY, err)
+		c.writeError(w, r, http.StatusInternalServerError, CODEINTERNAL, SERVICEERROR)
+		return
+	}
+
+	ips := []*V2IPResult{}
+	if result != nil {
+		for i := page.Offset; i < len(result.IPList); i++ {
+			ips = append(ips, &V2IPResult{
+				IP:          result.IPList[i].IP,
+				CountryName: result.IPList[i].CountryName,
+				CityName:    result.IPList[i].CityName,
+			})
+		}
+	}
+	page.Count = len(ips)
+
+	c.writeData(w, r, &V2CountryIPs{CountryCode: country, IPs: ips}, page)
+}
+
+// GetISPCountry is the v2 controller for a page of the ISP names of a country, sorted by name
+func (c ControllerV2Impl) GetISPCountry(w http.ResponseWriter, r *http.Request) {
+
+	// Leave out the ranges not seen lately when asked to
+	svc, ok := maxAge(c.Service, r)
+	if !ok {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, BADMAXAGE)
+		return
+	}
+
+	country, ok := countryCode(mux.Vars(r)[COUNTRY])
+	if !ok {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, BADCOUNTRY)
+		return
+	}
+	page, message := pagination(r)
+	if page == nil {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, message)
+		return
+	}
+
+	c.logger().DebugContext(r.Context(), "Received v2 request for isp list by country", COUNTRY, country)
+
+	result, err := svc.GetISPCountry(country)
+	if err != nil && !service.IsNoResult(err) {
+		c.logger().ErrorContext(r.Context(), SERVICEERROR, ERRORKEY, err)
+		c.writeError(w, r, http.StatusInternalServerError, CODEINTERNAL, SERVICEERROR)
+		return
+	}
+
+	//The names come from a set, sorting them keeps the pages stable
+	names := []string{}
+	if result != nil {
+		for _, isp := range result.ISPList {
+			names = append(names, isp.Name)
+		}
+	}
+	sort.Strings(names)
+
+	isps := []string{}
+	for i := page.Offset; i < len(names) && len(isps) < page.Limit; i++ {
+		isps = append(isps, names[i])
+	}
+	page.Count = len(isps)
+
+	c.writeData(w, r, &V2CountryISPs{CountryCode: country, ISPs: isps}, page)
+}
+
+// GetIPTotalCountry is the v2 controller for the address count of a country
+func (c ControllerV2Impl) GetIPTotalCountry(w http.ResponseWriter, r *http.Request) {
+
+	// Leave out the ranges not seen lately when asked to
+	svc, ok := maxAge(c.Service, r)
+	if !ok {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, BADMAXAGE)
+		return
+	}
+
+	country, ok := countryCode(mux.Vars(r)[COUNTRY])
+	if !ok {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, BADCOUNTRY)
+		return
+	}
+
+	c.logger().DebugContext(r.Context(), "Received v2 request for ip count by country", COUNTRY, country)
+
+	result, err := svc.GetCountryTotal(country)
+	if service.IsNoResult(err) {
+		c.writeError(w, r, http.StatusNotFound, CODENOTFOUND, NOTFOUND)
+		return
+	}
+	if err != nil {
+		c.logger().ErrorContext(r.Context(), SERVICEERROR, ERRORKEY, err)
+		c.writeError(w, r, http.StatusInternalServerError, CODEINTERNAL, SERVICEERROR)
+		return
+	}
+
+	c.writeData(w, r, &V2CountryTotal{CountryCode: country, TotalIPs: result.Total}, nil)
+}
+
+// GetMostProxyTypes is the v2 controller for the most common proxy types
+func (c ControllerV2Impl) GetMostProxyTypes(w http.ResponseWriter, r *http.Request) {
+
+	// Leave out the ranges not seen lately when asked to
+	svc, ok := maxAge(c.Service, r)
+	if !ok {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, BADMAXAGE)
+		return
+	}
+
+	c.logger().DebugContext(r.Context(), "Received v2 request for most proxy types")
+
+	result, err := svc.MostProxyTypes()
+	if err != nil && !service.IsNoResult(err) {
+		c.logger().ErrorContext(r.Context(), SERVICEERROR, ERRORKEY, err)
+		c.writeError(w, r, http.StatusInternalServerError, CODEINTERNAL, SERVICEERROR)
+		return
+	}
+
+	proxyTypes := []*V2ProxyType{}
+	if result != nil {
+		for _, proxyType := range result.ProxyTypeList {
+			proxyTypes = append(proxyTypes, &V2ProxyType{
+				ProxyType:     proxyType.ProxyType,
+				Total:         proxyType.Total,
+				ProxyTypeInfo: service.ProxyTypeCode(proxyType.ProxyType),
+			})
+		}
+	}
+
+	c.writeData(w, r, &V2ProxyTypes{ProxyTypes: proxyTypes}, nil)
+}
+
+// GetProviders is the v2 controller for a page of the VPN providers by address count, optionally of a country
+func (c ControllerV2Impl) GetProviders(w http.ResponseWriter, r *http.Request) {
+
+	// Leave out the ranges not seen lately when asked to
+	svc, ok := maxAge(c.Service, r)
+	if !ok {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, BADMAXAGE)
+		return
+	}
+
+	country, ok := countryFilter(r)
+	if !ok {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, BADCOUNTRY)
+		return
+	}
+	page, message := pagination(r)
+	if page == nil {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, message)
+		return
+	}
+
+	c.logger().DebugContext(r.Context(), "Received v2 request for providers", COUNTRY, country)
+
+	result, err := svc.GetProviders(country)
+	if err != nil && !service.IsNoResult(err) {
+		c.logger().ErrorContext(r.Context(), SERVICEERROR, ERRORKEY, err)
+		c.writeError(w, r, http.StatusInternalServerError, CODEINTERNAL, SERVICEERROR)
+		return
+	}
+
+	providers := []*V2Provider{}
+	if result != nil {
+		for i := page.Offset; i < len(result.ProviderList) && len(providers) < page.Limit; i++ {
+			provider := result.ProviderList[i]
+			providers = append(providers, &V2Provider{Name: provider.Name, Ranges: provider.Ranges, TotalIPs: provider.Total})
+		}
+	}
+	page.Count = len(providers)
+
+	c.writeData(w, r, &V2Providers{CountryCode: country, Providers: providers}, page)
+}
+
+// GetProvider is the v2 controller for a page of the ranges of a VPN provider as CIDR blocks, optionally of a country
+func (c ControllerV2Impl) GetProvider(w http.ResponseWriter, r *http.Request) {
+
+	// Leave out the ranges not seen lately when asked to
+	svc, ok := maxAge(c.Service, r)
+	if !ok {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, BADMAXAGE)
+		return
+	}
+
+	provider := mux.Vars(r)[PROVIDER]
+	if !providerName.MatchString(provider) {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, BADPROVIDER)
+		return
+	}
+	country, ok := countryFilter(r)
+	if !ok {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, BADCOUNTRY)
+		return
+	}
+	page, message := pagination(r)
+	if page == nil {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, message)
+		return
+	}
+
+	c.logger().DebugContext(r.Context(), "Received v2 request for ranges of provider", PROVIDER, provider)
+
+	//Like the address list, the ranges before the page are fetched and skipped
+	result, err := svc.GetProviderRanges(provider, country, page.Offset+page.Limit)
+	if err != nil && !service.IsNoResult(err) {
+		c.logger().ErrorContext(r.Context(), SERVICEERROR, ERRORKEY, err)
+		c.writeError(w, r, http.StatusInternalServerError, CODEINTERNAL, SERVICEERROR)
+		return
+	}
+	if result == nil || len(result.RangeList) == 0 {
+		c.writeError(w, r, http.StatusNotFound, CODENOTFOUND, NOPROVIDER)
+		return
+	}
+
+	ranges := []*V2Range{}
+	for i := page.Offset; i < len(result.RangeList); i++ {
+		ranges = append(ranges, v2Range(result.RangeList[i]))
+	}
+	page.Count = len(ranges)
+
+	c.writeData(w, r, &V2ProviderRanges{Provider: provider, CountryCode: country, Ranges: ranges}, page)
+}
+
+// GetThreats is the v2 controller for a page of the threat categories by address count, optionally of a country and an ASN
+func (c ControllerV2Impl) GetThreats(w http.ResponseWriter, r *http.Request) {
+
+	// Leave out the ranges not seen lately when asked to
+	svc, ok := maxAge(c.Service, r)
+	if !ok {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, BADMAXAGE)
+		return
+	}
+
+	country, ok := countryFilter(r)
+	if !ok {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, BADCOUNTRY)
+		return
+	}
+	asn, ok := asnFilter(r)
+	if !ok {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, BADASN)
+		return
+	}
+	page, message := pagination(r)
+	if page == nil {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, message)
+		return
+	}
+
+	c.logger().DebugContext(r.Context(), "Received v2 request for threats", COUNTRY, country, ASN, asn)
+
+	result, err := svc.GetThreats(country, asn)
+	if err != nil && !service.IsNoResult(err) {
+		c.logger().ErrorContext(r.Context(), SERVICEERROR, ERRORKEY, err)
+		c.writeError(w, r, http.StatusInternalServerError, CODEINTERNAL, SERVICEERROR)
+		return
+	}
+
+	threats := []*V2Threat{}
+	if result != nil {
+		for i := page.Offset; i < len(result.ThreatList) && len(threats) < page.Limit; i++ {
+			threat := result.ThreatList[i]
+			threats = append(threats, &V2Threat{Name: threat.Name, Ranges: threat.Ranges, TotalIPs: threat.Total})
+		}
+	}
+	page.Count = len(threats)
+
+	c.writeData(w, r, &V2Threats{CountryCode: country, ASN: asn, Threats: threats}, page)
+}
+
+// GetThreat is the v2 controller for a page of the ranges of a threat category as CIDR blocks, optionally of a country and an ASN
+func (c ControllerV2Impl) GetThreat(w http.ResponseWriter, r *http.Request) {
+
+	// Leave out the ranges not seen lately when asked to
+	svc, ok := maxAge(c.Service, r)
+	if !ok {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, BADMAXAGE)
+		return
+	}
+
+	threat := mux.Vars(r)[THREAT]
+	country, ok := countryFilter(r)
+	if !ok {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, BADCOUNTRY)
+		return
+	}
+	asn, ok := asnFilter(r)
+	if !ok {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, BADASN)
+		return
+	}
+	page, message := pagination(r)
+	if page == nil {
+		c.writeError(w, r, http.StatusBadRequest, CODEBADREQUEST, message)
+		return
+	}
+
+	c.logger().DebugContext(r.Context(), "Received v2 request for ranges of threat", THREAT, threat)
+
+	//Like the address list, the ranges before the page are fetched and skipped
+	result, err := svc.GetThreatRanges(threat, country, asn, page.Offset+page.Limit)
+	if err != nil && !service.IsNoResult(err) {
+		c.logger().ErrorContext(r.Context(), SERVICEERROR, ERRORKEY, err)
+		c.writeError(w, r, http.StatusInternalServerError, CODEINTERNAL, SERVICEERROR)
+		return
+	}
+	if result == nil || len(result.RangeList) == 0 {
+		c.writeError(w, r, http.StatusNotFound, CODENOTFOUND, NOTHREAT)
+		return
+	}
+
+	ranges := []*V2Range{}
+	for i := page.Offset; i < len(result.RangeList); i++ {
+		ranges = append(ranges, v2Range(result.RangeList[i]))
+	}
+	page.Count = len(ranges)
+
+	c.writeData(w, r, &V2ThreatRanges{Threat: threat, CountryCode: country, ASN: asn, Ranges: ranges}, page)
+}
+
+// GetCodes is the v2 controller for the descriptions of every proxy and usage type code
+func (c ControllerV2Impl) GetCodes(w http.ResponseWriter, r *http.Request) {
+
+	c.logger().DebugContext(r.Context(), "Received v2 request for codes")
+
+	c.writeData(w, r, &V2Codes{
+		ProxyTypes: service.SortedCodes(service.PROXYTYPES),
+		UsageTypes: service.SortedCodes(service.USAGETYPES),
+	}, nil)
+}
+
+// meta describes the response with the version of the data it came from
+func (c ControllerV2Impl) meta(r *http.Request, page *V2Pagination) V2Meta {
+	meta := V2Meta{APIVersion: APIV2, Pagination: page}
+	read := c.Service.DatasetVersion
+	if c.Version != nil {
+		read = c.Version.Get
+	}
+	version, err := read()
+	if err != nil {
+		//The version is informative, the data is still good
+		c.logger().WarnContext(r.Context(), SERVICEERROR, ERRORKEY, err)
+	}
+	meta.DatasetVersion = version
+	return meta
+}
+
+// logger is the configured logger or the default one
+func (c ControllerV2Impl) logger() *slog.Logger {
+	if c.Logger == nil {
+		return slog.Default()
+	}
+	return c.Logger
+}
+
+func (c ControllerV2Impl) writeData(w http.ResponseWriter, r *http.Request, data interface{}, page *V2Pagination) {
+	c.writeV2(w, r, http.StatusOK, &V2Response{Data: data, Meta: c.meta(r, page)})
+}
+
+func (c ControllerV2Impl) writeError(w http.ResponseWriter, r *http.Request, status int, code string, message string) {
+	c.logger().WarnContext(r.Context(), message)
+	c.writeV2(w, r, status, &V2Response{Error: &V2Error{Code: code, Message: message}, Meta: c.meta(r, nil)})
+}
+
+func (c ControllerV2Impl) writeV2(w http.ResponseWriter, r *http.Request, status int, response *V2Response) {
+	encoder, ok := render.Negotiate(r)
+	if !ok {
+		c.logger().WarnContext(r.Context(), NOTACCEPTABLE)
+		encoder, status, response = render.Default(), http.StatusNotAcceptable, notAcceptable(response.Meta)
+	}
+
+	err := render.WriteStatus(w, encoder, status, response)
+	if errors.Is(err, render.ErrNoMessage) {
+		//Errors keep their status, data without a protobuf message is not acceptable
+		c.logger().WarnContext(r.Context(), NOTACCEPTABLE, ERRORKEY, err)
+		if response.Error == nil {
+			status, response = http.StatusNotAcceptable, notAcceptable(response.Meta)
+		}
+		err = render.WriteStatus(w, render.Default(), status, response)
+	}
+	if err != nil {
+		c.logger().ErrorContext(r.Context(), ERRORMARSHAL, ERRORKEY, err)
+		WriteError(w, SERVICEERROR)
+	}
+}
+
+// notAcceptable is the error answered instead of a response, with its meta
+func notAcceptable(meta V2Meta) *V2Response {
+	return &V2Response{Error: &V2Error{Code: CODENOTACCEPTABLE, Message: NOTACCEPTABLE}, Meta: meta}
+}
+
+// countryCode accepts exactly two upper case letters
+func countryCode(input string) (string, bool) {
+	if len(input) != 2 || input[0] < 'A' || input[0] > 'Z' || input[1] < 'A' || input[1] > 'Z' {
+		return "", false
+	}
+	return input, true
+}
+
+// countryFilter reads the optional country parameter, it is false when it isn't a country code
+func countryFilter(r *http.Request) (string, bool) {
+	country := r.URL.Query().Get(COUNTRY)
+	if country == "" {
+		return "", true
+	}
+	return countryCode(country)
+}
+
+// asnFilter reads the optional asn parameter, it is false when it isn't a number
+func asnFilter(r *http.Request) (string, bool) {
+	asn := r.URL.Query().Get(ASN)
+	if asn == "" {
+		return "", true
+	}
+	return asn, OnlyInt(asn) == asn
+}
+
+// providerName keeps the names in the path to the characters providers are named with
+var providerName = regexp.MustCompile(`^[\w .&()+-]{1,100}$`)
+
+func v2Range(ipRange *service.IPRange) *V2Range {
+	return &V2Range{
+		IPFrom:      service.Int2IP(ipRange.IPFrom).String(),
+		IPTo:        service.Int2IP(ipRange.IPTo).String(),
+		CountryCode: ipRange.CountryCode,
+		CIDRs:       service.RangeCIDRs(ipRange.IPFrom, ipRange.IPTo),
+		LastSeen:    ipRange.LastSeen,
+	}
+}
+
+// pagination reads limit and offset, a nil page comes with the reason
+func pagination(r *http.Request) (*V2Pagination, string) {
+	page := &V2Pagination{Limit: DEFAULTLIMIT}
+
+	if limit := r.URL.Query().Get(LIMIT); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 || value > MAXROWS {
+			return nil, BADLIMIT
+		}
+		page.Limit = value
+	}
+
+	if offset := r.URL.Query().Get(OFFSET); offset != "" {
+		value, err := strconv.Atoi(offset)
+		if err != nil || value < 0 || value > MAXOFFSET {
+			return nil, BADOFFSET
+		}
+		page.Offset = value
+	}
+
+	return page, ""
+}
@@ -0,0 +1,778 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/nullc0rp/go-ip2proxy-api/mocks"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetIPInfoV2Happy(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance := &ControllerV2Impl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/v2/ip/10.10.10.1", nil)
+	w := httptest.NewRecorder()
+
+	r = mux.SetURLVars(r, map[string]string{
+		"address": "10.10.10.1",
+	})
+
+	//Expects setup
+	mockService.EXPECT().GetIPInfo(net.ParseIP("10.10.10.1")).Return(&service.IPData{ProxyType: "PUB", CountryName: "Javalandia", AS: "Opera"}, nil)
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil)
+
+	controllerInstance.GetIpInfo(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "{\"data\":{\"ip_address\":\"10.10.10.1\",\"found\":true,\"proxy_type\":\"PUB\",\"country_name\":\"Javalandia\",\"as_name\":\"Opera\",\"proxy_type_info\":{\"code\":\"PUB\",\"name\":\"Public proxy\",\"description\":\"Open proxies anyone can connect through\",\"risk\":\"high\"}},\"meta\":{\"api_version\":\"v2\",\"dataset_version\":\"2021-06-01 10:00:00\"}}", w.Body.String())
+}
+
+func TestGetIPInfoV2NotFound(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance := &ControllerV2Impl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/v2/ip/10.10.10.1", nil)
+	w := httptest.NewRecorder()
+
+	r = mux.SetURLVars(r, map[string]string{
+		"address": "10.10.10.1",
+	})
+
+	//Expects setup
+	mockService.EXPECT().GetIPInfo(net.ParseIP("10.10.10.1")).Return(nil, service.NoResultError("test"))
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil)
+
+	controllerInstance.GetIpInfo(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "{\"data\":{\"ip_address\":\"10.10.10.1\",\"found\":false},\"meta\":{\"api_version\":\"v2\",\"dataset_version\":\"2021-06-01 10:00:00\"}}", w.Body.String())
+}
+
+func TestGetIPInfoV2BadIP(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance := &ControllerV2Impl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/v2/ip/10.10.10.asd", nil)
+	w := httptest.NewRecorder()
+
+	r = mux.SetURLVars(r, map[string]string{
+		"address": "10.10.10.asd",
+	})
+
+	//The version is left out when it can't be read
+	mockService.EXPECT().DatasetVersion().Return("", errors.New("some dirty info"))
+
+	controllerInstance.GetIpInfo(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "{\"error\":{\"code\":\"bad_request\",\"message\":\"Bad IP address\"},\"meta\":{\"api_version\":\"v2\"}}", w.Body.String())
+}
+
+func TestGetMyIPInfoV2IPv6(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance := &ControllerV2Impl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/v2/me", nil)
+	r.RemoteAddr = "[2001:db8::a0a:a01]:40000"
+	w := httptest.NewRecorder()
+
+	//Expects setup, the address is not looked up
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil)
+
+	controllerInstance.GetMyIpInfo(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "{\"data\":{\"ip_address\":\"2001:db8::a0a:a01\",\"found\":false,\"source\":\"RemoteAddr\"},\"meta\":{\"api_version\":\"v2\",\"dataset_version\":\"2021-06-01 10:00:00\"}}", w.Body.String())
+}
+
+func TestDatasetVersionHeld(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller, the version is read once for both responses
+	controllerInstance := &ControllerV2Impl{
+		Service: mockService,
+		Version: &DatasetVersion{Service: mockService},
+	}
+
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil).Times(1)
+
+	for i := 0; i < 2; i++ {
+		r, _ := http.NewRequest("GET", "/v2/ip/nope", nil)
+		r = mux.SetURLVars(r, map[string]string{"address": "nope"})
+		w := httptest.NewRecorder()
+
+		controllerInstance.GetIpInfo(w, r)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "\"dataset_version\":\"2021-06-01 10:00:00\"")
+	}
+}
+
+func TestDatasetVersionFailedRead(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	gomock.InOrder(
+		mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil),
+		mockService.EXPECT().DatasetVersion().Return("", errors.New("some dirty info")),
+	)
+
+	//Read on every call, a failure tells the last version read
+	version := &DatasetVersion{Service: mockService, Interval: time.Nanosecond}
+	version.Get()
+	time.Sleep(time.Millisecond)
+	read, err := version.Get()
+
+	assert.Equal(t, "2021-06-01 10:00:00", read)
+	assert.Equal(t, "some dirty info", err.Error())
+}
+
+func TestGetIPListV2Page(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance := &ControllerV2Impl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/v2/country/AR?limit=2&offset=1", nil)
+	w := httptest.NewRecorder()
+
+	r = mux.SetURLVars(r, map[string]string{
+		"country": "AR",
+	})
+
+	//Mock response
+	ipListResponse := &service.IPCountryData{
+		Total: 3,
+		IPList: []*service.IPDataResult{
+			{IP: "1.0.4.1", CountryName: "Argentina", CityName: "Rosario"},
+			{IP: "1.0.4.2", CountryName: "Argentina", CityName: "Rosario"},
+			{IP: "1.0.4.3", CountryName: "Argentina", CityName: "Rosario"},
+		},
+	}
+
+	//Expects setup, the addresses before the offset are fetched too
+	mockService.EXPECT().GetIPCountry("AR", 3).Return(ipListResponse, nil)
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil)
+
+	controllerInstance.GetIpList(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "{\"data\":{\"country_code\":\"AR\",\"ips\":[{\"ip_address\":\"1.0.4.2\",\"country_name\":\"Argentina\",\"city_name\":\"Rosario\"},{\"ip_address\":\"1.0.4.3\",\"country_name\":\"Argentina\",\"city_name\":\"Rosario\"}]},\"meta\":{\"api_version\":\"v2\",\"dataset_version\":\"2021-06-01 10:00:00\",\"pagination\":{\"limit\":2,\"offset\":1,\"count\":2}}}", w.Body.String())
+}
+
+func TestGetIPListV2BadLimit(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance := &ControllerV2Impl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/v2/country/AR?limit=5000", nil)
+	w := httptest.NewRecorder()
+
+	r = mux.SetURLVars(r, map[string]string{
+		"country": "AR",
+	})
+
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil)
+
+	controllerInstance.GetIpList(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "\"code\":\"bad_request\"")
+}
+
+func TestGetISPCountryV2Sorted(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance := &ControllerV2Impl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/v2/country/AR/isp?limit=2", nil)
+	w := httptest.NewRecorder()
+
+	r = mux.SetURLVars(r, map[string]string{
+		"country": "AR",
+	})
+
+	//Mock response
+	ispListResponse := &service.ISPCountryData{
+		Total:   3,
+		ISPList: []*service.ISPDataResult{{Name: "Telstra"}, {Name: "Arnet"}, {Name: "Fibertel"}},
+	}
+
+	//Expects setup
+	mockService.EXPECT().GetISPCountry("AR").Return(ispListResponse, nil)
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil)
+
+	controllerInstance.GetISPCountry(w, r)
+
+	assert.Equal(t, "{\"data\":{\"country_code\":\"AR\",\"isps\":[\"Arnet\",\"Fibertel\"]},\"meta\":{\"api_version\":\"v2\",\"dataset_version\":\"2021-06-01 10:00:00\",\"pagination\":{\"limit\":2,\"offset\":0,\"count\":2}}}", w.Body.String())
+}
+
+func TestGetIPTotalV2NotFound(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance := &ControllerV2Impl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/v2/country/ZZ/total", nil)
+	w := httptest.NewRecorder()
+
+	r = mux.SetURLVars(r, map[string]string{
+		"country": "ZZ",
+	})
+
+	//Expects setup
+	mockService.EXPECT().GetCountryTotal("ZZ").Return(nil, service.NoResultError("test"))
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil)
+
+	controllerInstance.GetIPTotalCountry(w, r)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "{\"error\":{\"code\":\"not_found\",\"message\":\"No data for country\"},\"meta\":{\"api_version\":\"v2\",\"dataset_version\":\"2021-06-01 10:00:00\"}}", w.Body.String())
+}
+
+func TestGetMostProxyTypesV2ErrorService(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance := &ControllerV2Impl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/v2/proxytypes", nil)
+	w := httptest.NewRecorder()
+
+	//Expects setup
+	mockService.EXPECT().MostProxyTypes().Return(nil, errors.New("some dirty info"))
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil)
+
+	controllerInstance.GetMostProxyTypes(w, r)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "\"code\":\"internal_error\"")
+}
+
+func TestV1RoutesDeprecated(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	router := NewRouter(&ControllerImpl{Service: mockService}, &ControllerV2Impl{Service: mockService})
+
+	//Expects setup, both v1 paths run the same controller
+	mockService.EXPECT().GetCountryTotal("AR").Return(&service.IPCountryTotal{Total: 10}, nil).Times(2)
+	mockService.EXPECT().GetCountryTotal("PL").Return(&service.IPCountryTotal{Total: 10}, nil)
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil)
+
+	for _, path := range []string{"/country/AR/total", "/v1/country/AR/total"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+
+		assert.Equal(t, "{\"total_ip\":10}", w.Body.String())
+		assert.Equal(t, "true", w.Header().Get("Deprecation"))
+		assert.Equal(t, "</v2/country/AR/total>; rel=\"successor-version\"", w.Header().Get("Link"))
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/v2/country/PL/total", nil))
+
+	assert.Equal(t, "", w.Header().Get("Deprecation"))
+	assert.Contains(t, w.Body.String(), "\"total_ips\":10")
+}
+
+func TestGetProvidersV2Country(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance := &ControllerV2Impl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/v2/provider?country=AU&limit=1", nil)
+	w := httptest.NewRecorder()
+
+	//Mock response
+	providersResponse := &service.ProviderData{
+		ProviderList: []*service.ProviderTotal{{Name: "NordVPN", Ranges: 3, Total: 600}, {Name: "ExpressVPN", Ranges: 1, Total: 10}},
+	}
+
+	//Expects setup
+	mockService.EXPECT().GetProviders("AU").Return(providersResponse, nil)
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil)
+
+	controllerInstance.GetProviders(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "{\"data\":{\"country_code\":\"AU\",\"providers\":[{\"provider\":\"NordVPN\",\"ranges\":3,\"total_ips\":600}]},\"meta\":{\"api_version\":\"v2\",\"dataset_version\":\"2021-06-01 10:00:00\",\"pagination\":{\"limit\":1,\"offset\":0,\"count\":1}}}", w.Body.String())
+}
+
+func TestGetProvidersV2BadCountry(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance := &ControllerV2Impl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/v2/provider?country=au", nil)
+	w := httptest.NewRecorder()
+
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil)
+
+	controllerInstance.GetProviders(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Bad country code")
+}
+
+func TestGetProviderV2CIDRs(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance := &ControllerV2Impl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/v2/provider/Private Internet Access", nil)
+	w := httptest.NewRecorder()
+
+	r = mux.SetURLVars(r, map[string]string{
+		"provider": "Private Internet Access",
+	})
+
+	//Mock response, 1.0.4.0 to 1.0.5.2
+	rangesResponse := &service.IPRangeData{
+		Total:     1,
+		RangeList: []*service.IPRange{{IPFrom: 16778240, IPTo: 16778498, CountryCode: "AU"}},
+	}
+
+	//Expects setup
+	mockService.EXPECT().GetProviderRanges("Private Internet Access", "", 50).Return(rangesResponse, nil)
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil)
+
+	controllerInstance.GetProvider(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "{\"data\":{\"provider\":\"Private Internet Access\",\"ranges\":[{\"ip_from\":\"1.0.4.0\",\"ip_to\":\"1.0.5.2\",\"country_code\":\"AU\",\"cidrs\":[\"1.0.4.0/24\",\"1.0.5.0/31\",\"1.0.5.2/32\"]}]},\"meta\":{\"api_version\":\"v2\",\"dataset_version\":\"2021-06-01 10:00:00\",\"pagination\":{\"limit\":50,\"offset\":0,\"count\":1}}}", w.Body.String())
+}
+
+func TestGetProviderV2NotFound(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance := &ControllerV2Impl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/v2/provider/Nobody", nil)
+	w := httptest.NewRecorder()
+
+	r = mux.SetURLVars(r, map[string]string{
+		"provider": "Nobody",
+	})
+
+	//Expects setup
+	mockService.EXPECT().GetProviderRanges("Nobody", "", 50).Return(&service.IPRangeData{RangeList: []*service.IPRange{}}, nil)
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil)
+
+	controllerInstance.GetProvider(w, r)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "\"code\":\"not_found\"")
+}
+
+func TestGetProviderV2BadName(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance := &ControllerV2Impl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/v2/provider/x", nil)
+	w := httptest.NewRecorder()
+
+	r = mux.SetURLVars(r, map[string]string{
+		"provider": "x' OR '1'='1",
+	})
+
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil)
+
+	controllerInstance.GetProvider(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Bad provider name")
+}
+
+func TestGetThreatsV2ASN(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance := &ControllerV2Impl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/v2/threat?asn=1221", nil)
+	w := httptest.NewRecorder()
+
+	//Mock response
+	threatsResponse := &service.ThreatData{
+		ThreatList: []*service.ThreatTotal{{Name: "SCANNER", Ranges: 2, Total: 20}, {Name: "SPAM", Ranges: 1, Total: 1}},
+	}
+
+	//Expects setup
+	mockService.EXPECT().GetThreats("", "1221").Return(threatsResponse, nil)
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil)
+
+	controllerInstance.GetThreats(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "{\"data\":{\"asn\":\"1221\",\"threats\":[{\"threat\":\"SCANNER\",\"ranges\":2,\"total_ips\":20},{\"threat\":\"SPAM\",\"ranges\":1,\"total_ips\":1}]},\"meta\":{\"api_version\":\"v2\",\"dataset_version\":\"2021-06-01 10:00:00\",\"pagination\":{\"limit\":50,\"offset\":0,\"count\":2}}}", w.Body.String())
+}
+
+func TestGetThreatsV2BadASN(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance := &ControllerV2Impl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/v2/threat?asn=AS1221", nil)
+	w := httptest.NewRecorder()
+
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil)
+
+	controllerInstance.GetThreats(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), BADASN)
+}
+
+func TestGetThreatV2Page(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	router := NewRouter(&ControllerImpl{Service: mockService}, &ControllerV2Impl{Service: mockService})
+
+	//Mock response
+	rangesResponse := &service.IPRangeData{
+		Total: 2,
+		RangeList: []*service.IPRange{
+			{IPFrom: 16778240, IPTo: 16778495, CountryCode: "AU"},
+			{IPFrom: 168430081, IPTo: 168430081, CountryCode: "AU"},
+		},
+	}
+
+	//Expects setup, the ranges before the offset are fetched too
+	mockService.EXPECT().GetThreatRanges("SCANNER", "AU", "", 2).Return(rangesResponse, nil)
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/v2/threat/SCANNER?country=AU&limit=1&offset=1", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "{\"data\":{\"threat\":\"SCANNER\",\"country_code\":\"AU\",\"ranges\":[{\"ip_from\":\"10.10.10.1\",\"ip_to\":\"10.10.10.1\",\"country_code\":\"AU\",\"cidrs\":[\"10.10.10.1/32\"]}]},\"meta\":{\"api_version\":\"v2\",\"dataset_version\":\"2021-06-01 10:00:00\",\"pagination\":{\"limit\":1,\"offset\":1,\"count\":1}}}", w.Body.String())
+}
+
+func TestGetIPInfoV2Stale(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance := &ControllerV2Impl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/v2/ip/10.10.10.1?max_age_days=30", nil)
+	w := httptest.NewRecorder()
+
+	r = mux.SetURLVars(r, map[string]string{
+		"address": "10.10.10.1",
+	})
+
+	//Expects setup, the range was last seen too long ago
+	mockService.EXPECT().MaxAge(30).Return(mockService)
+	mockService.EXPECT().GetIPInfo(net.ParseIP("10.10.10.1")).Return(nil, &service.StaleError{LastSeen: "45"})
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil)
+
+	controllerInstance.GetIpInfo(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "{\"data\":{\"ip_address\":\"10.10.10.1\",\"found\":false,\"last_seen\":\"45\"},\"meta\":{\"api_version\":\"v2\",\"dataset_version\":\"2021-06-01 10:00:00\"}}", w.Body.String())
+}
+
+func TestGetMostProxyTypesV2BadMaxAge(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance := &ControllerV2Impl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/v2/proxytypes?max_age_days=0", nil)
+	w := httptest.NewRecorder()
+
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil)
+
+	controllerInstance.GetMostProxyTypes(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), BADMAXAGE)
+}
+
+func TestGetIPInfoV2UsageTypes(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	//Instance Controller
+	controllerInstance := &ControllerV2Impl{
+		Service: mockService,
+	}
+
+	r, _ := http.NewRequest("GET", "/v2/ip/10.10.10.1", nil)
+	w := httptest.NewRecorder()
+
+	r = mux.SetURLVars(r, map[string]string{
+		"address": "10.10.10.1",
+	})
+
+	//Expects setup, unknown codes are left undescribed
+	mockService.EXPECT().GetIPInfo(net.ParseIP("10.10.10.1")).Return(&service.IPData{ProxyType: "-", UsageType: "ISP/MOB/XYZ"}, nil)
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil)
+
+	controllerInstance.GetIpInfo(w, r)
+
+	response := &struct{ Data *V2IPData }{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), response))
+	assert.Nil(t, response.Data.ProxyTypeInfo)
+	assert.Equal(t, []*service.Code{service.USAGETYPES["ISP"], service.USAGETYPES["MOB"]}, response.Data.UsageTypeInfo)
+}
+
+func TestGetCodesV2(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	router := NewRouter(&ControllerImpl{Service: mockService}, &ControllerV2Impl{Service: mockService})
+
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/v2/codes", nil))
+
+	response := &struct{ Data *V2Codes }{}
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), response))
+	assert.Equal(t, len(service.PROXYTYPES), len(response.Data.ProxyTypes))
+	assert.Equal(t, "CPN", response.Data.ProxyTypes[0].Code)
+	assert.Equal(t, "high", response.Data.ProxyTypes[len(response.Data.ProxyTypes)-1].Risk)
+	assert.Equal(t, len(service.USAGETYPES), len(response.Data.UsageTypes))
+}
+
+func TestGetIPInfoV2CSV(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	router := NewRouter(&ControllerImpl{Service: mockService}, &ControllerV2Impl{Service: mockService})
+
+	mockService.EXPECT().GetIPInfo(net.ParseIP("10.10.10.1")).Return(&service.IPData{ProxyType: "PUB", CountryName: "Javalandia"}, nil)
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/v2/ip/10.10.10.1?format=csv", nil))
+
+	//The data alone, without the code descriptions
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get(CONTENTYPE))
+	assert.Equal(t, "ip_address,found,source,proxy_type,country_code,country_name,region_name,city_name,isp,domain,usage_type,asn,as_name,last_seen,threat,provider,fraud_score\n10.10.10.1,true,,PUB,,Javalandia,,,,,,,,,,,\n", w.Body.String())
+}
+
+func TestGetIPInfoV2XMLError(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	router := NewRouter(&ControllerImpl{Service: mockService}, &ControllerV2Impl{Service: mockService})
+
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil)
+
+	r := httptest.NewRequest("GET", "/v2/ip/10.10.10.asd", nil)
+	r.Header.Set("Accept", "application/xml")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get(CONTENTYPE))
+	assert.Equal(t, "<V2Response><data></data><error><code>bad_request</code><message>Bad IP address</message></error><meta><api_version>v2</api_version><dataset_version>2021-06-01 10:00:00</dataset_version><pagination></pagination></meta></V2Response>", w.Body.String())
+}
+
+func TestGetIPInfoV2NotAcceptable(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	router := NewRouter(&ControllerImpl{Service: mockService}, &ControllerV2Impl{Service: mockService})
+
+	mockService.EXPECT().GetIPInfo(net.ParseIP("10.10.10.1")).Return(&service.IPData{ProxyType: "PUB"}, nil)
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil)
+
+	r := httptest.NewRequest("GET", "/v2/ip/10.10.10.1", nil)
+	r.Header.Set("Accept", "image/png")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	assert.Equal(t, APPJSON, w.Header().Get(CONTENTYPE))
+	assert.Equal(t, "{\"error\":{\"code\":\"not_acceptable\",\"message\":\"Not acceptable, the supported formats are json, csv, xml, msgpack and protobuf\"},\"meta\":{\"api_version\":\"v2\",\"dataset_version\":\"2021-06-01 10:00:00\"}}", w.Body.String())
+}
+
+func TestGetCodesV2NoMessage(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	router := NewRouter(&ControllerImpl{Service: mockService}, &ControllerV2Impl{Service: mockService})
+
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/v2/codes?format=protobuf", nil))
+
+	//The codes have no protobuf message
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	assert.Equal(t, APPJSON, w.Header().Get(CONTENTYPE))
+	assert.Contains(t, w.Body.String(), "\"code\":\"not_acceptable\"")
+}
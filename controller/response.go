@@ -1,5 +1,12 @@
 package controller
 
+import (
+	"github.com/nullc0rp/go-ip2proxy-api/ip2proxypb"
+	"github.com/nullc0rp/go-ip2proxy-api/render"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+	"google.golang.org/protobuf/proto"
+)
+
 // IPDataResult info returned by controller
 type IPDataResult struct {
 	ProxyType   string
@@ -13,3 +20,29 @@ type IPDataResult struct {
 	ASN         string
 	AS          string
 }
+
+// MyIPDataResult info returned by controller for the caller's own address
+type MyIPDataResult struct {
+	IP     string `json:"ip_address"`
+	Source string `json:"source"`
+	*service.IPData
+}
+
+// Proto gives the gRPC lookup message for the protobuf format
+func (m *MyIPDataResult) Proto() proto.Message {
+	message := &ip2proxypb.LookupResponse{Ip: m.IP}
+	if m.IPData != nil {
+		message.Found = true
+		message.Data = render.ProtoIPData(m.IPData)
+	}
+	return message
+}
+
+// selection is the IP data with only the selected fields, in their order
+func selection(data *service.IPData, fields []string) *render.Record {
+	record := &render.Record{Name: IPDATA, Message: render.ProtoIPData(data)}
+	for _, field := range fields {
+		record.Fields = append(record.Fields, render.Field{Name: field, Value: *data.FieldPointer(field)})
+	}
+	return record
+}
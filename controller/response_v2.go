@@ -0,0 +1,243 @@
+package controller
+
+import (
+	"github.com/nullc0rp/go-ip2proxy-api/ip2proxypb"
+	"github.com/nullc0rp/go-ip2proxy-api/render"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+	"google.golang.org/protobuf/proto"
+)
+
+// V2Response is the envelope of every v2 response, data or error is set
+type V2Response struct {
+	Data  interface{} `json:"data,omitempty"`
+	Error *V2Error    `json:"error,omitempty"`
+	Meta  V2Meta      `json:"meta"`
+}
+
+// Payload is what CSV and protobuf write without the envelope, the error or else the data, lists as their items
+func (r *V2Response) Payload() interface{} {
+	if r.Error != nil {
+		return r.Error
+	}
+	if list, ok := r.Data.(render.Payload); ok {
+		return list.Payload()
+	}
+	return r.Data
+}
+
+// V2Error is the error model, the code is stable and meant for programs, the message for people
+type V2Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// V2Meta describes the response
+type V2Meta struct {
+	APIVersion     string        `json:"api_version"`
+	DatasetVersion string        `json:"dataset_version,omitempty"`
+	Pagination     *V2Pagination `json:"pagination,omitempty"`
+}
+
+// V2Pagination describes the page of a list, there is a next page when count equals limit
+type V2Pagination struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+	Count  int `json:"count"`
+}
+
+// V2IPData is the proxy data of an address
+type V2IPData struct {
+	IP          string `json:"ip_address"`
+	Found       bool   `json:"found"`
+	Source      string `json:"source,omitempty"`
+	ProxyType   string `json:"proxy_type,omitempty"`
+	CountryCode string `json:"country_code,omitempty"`
+	CountryName string `json:"country_name,omitempty"`
+	RegionName  string `json:"region_name,omitempty"`
+	CityName    string `json:"city_name,omitempty"`
+	ISP         string `json:"isp,omitempty"`
+	Domain      string `json:"domain,omitempty"`
+	UsageType   string `json:"usage_type,omitempty"`
+	ASN         string `json:"asn,omitempty"`
+	AS          string `json:"as_name,omitempty"`
+	LastSeen    string `json:"last_seen,omitempty"`
+	Threat      string `json:"threat,omitempty"`
+	Provider    string `json:"provider,omitempty"`
+	FraudScore  string `json:"fraud_score,omitempty"`
+	//Descriptions of the codes, left out for unknown ones
+	ProxyTypeInfo *service.Code   `json:"proxy_type_info,omitempty" csv:"-"`
+	UsageTypeInfo []*service.Code `json:"usage_type_info,omitempty" csv:"-"`
+}
+
+// Proto gives the gRPC lookup message for the protobuf format
+func (d *V2IPData) Proto() proto.Message {
+	message := &ip2proxypb.LookupResponse{Ip: d.IP, Found: d.Found}
+	if d.Found {
+		message.Data = &ip2proxypb.IPData{
+			ProxyType:   d.ProxyType,
+			CountryCode: d.CountryCode,
+			CountryName: d.CountryName,
+			RegionName:  d.RegionName,
+			CityName:    d.CityName,
+			Isp:         d.ISP,
+			Domain:      d.Domain,
+			UsageType:   d.UsageType,
+			Asn:         d.ASN,
+			As:          d.AS,
+			LastSeen:    d.LastSeen,
+			Threat:      d.Threat,
+			Provider:    d.Provider,
+			FraudScore:  d.FraudScore,
+		}
+	}
+	return message
+}
+
+// V2CountryIPs is a page of addresses of a country
+type V2CountryIPs struct {
+	CountryCode string        `json:"country_code"`
+	IPs         []*V2IPResult `json:"ips"`
+}
+
+// Payload is the page of addresses, CSV rows or protobuf messages
+func (c *V2CountryIPs) Payload() interface{} {
+	return c.IPs
+}
+
+// V2IPResult is an address of a country
+type V2IPResult struct {
+	IP          string `json:"ip_address"`
+	CountryName string `json:"country_name"`
+	CityName    string `json:"city_name"`
+}
+
+// Proto gives the message of the v1 country list
+func (i *V2IPResult) Proto() proto.Message {
+	return &ip2proxypb.CountryIP{Ip: i.IP, CountryName: i.CountryName, CityName: i.CityName}
+}
+
+// V2CountryISPs is a page of ISP names of a country
+type V2CountryISPs struct {
+	CountryCode string   `json:"country_code"`
+	ISPs        []string `json:"isps"`
+}
+
+// Payload is the page of names
+func (c *V2CountryISPs) Payload() interface{} {
+	return c.ISPs
+}
+
+// V2CountryTotal is the address count of a country
+type V2CountryTotal struct {
+	CountryCode string `json:"country_code"`
+	TotalIPs    int    `json:"total_ips"`
+}
+
+// Proto gives the message of the v1 country total
+func (t *V2CountryTotal) Proto() proto.Message {
+	return &ip2proxypb.CountryTotalResponse{Total: int64(t.TotalIPs)}
+}
+
+// V2ProxyTypes are the most common proxy types
+type V2ProxyTypes struct {
+	ProxyTypes []*V2ProxyType `json:"proxy_types"`
+}
+
+// Payload is the proxy type counts
+func (p *V2ProxyTypes) Payload() interface{} {
+	return p.ProxyTypes
+}
+
+// V2ProxyType is a proxy type count
+type V2ProxyType struct {
+	ProxyType     string        `json:"proxy_type"`
+	Total         int           `json:"total"`
+	ProxyTypeInfo *service.Code `json:"proxy_type_info,omitempty" csv:"-"`
+}
+
+// Proto gives the message of the gRPC proxy type stats
+func (p *V2ProxyType) Proto() proto.Message {
+	return &ip2proxypb.ProxyTypeStat{ProxyType: p.ProxyType, Total: int64(p.Total)}
+}
+
+// V2Providers are the VPN providers by address count, of a country when it was asked for
+type V2Providers struct {
+	CountryCode string        `json:"country_code,omitempty"`
+	Providers   []*V2Provider `json:"providers"`
+}
+
+// Payload is the page of providers
+func (p *V2Providers) Payload() interface{} {
+	return p.Providers
+}
+
+// V2Provider is the range and address count of a VPN provider
+type V2Provider struct {
+	Name     string `json:"provider"`
+	Ranges   int    `json:"ranges"`
+	TotalIPs int    `json:"total_ips"`
+}
+
+// V2ProviderRanges is a page of the ranges of a VPN provider
+type V2ProviderRanges struct {
+	Provider    string     `json:"provider"`
+	CountryCode string     `json:"country_code,omitempty"`
+	Ranges      []*V2Range `json:"ranges"`
+}
+
+// Payload is the page of ranges
+func (p *V2ProviderRanges) Payload() interface{} {
+	return p.Ranges
+}
+
+// V2Range is an address range and the CIDR blocks covering it
+type V2Range struct {
+	IPFrom      string   `json:"ip_from"`
+	IPTo        string   `json:"ip_to"`
+	CountryCode string   `json:"country_code"`
+	CIDRs       []string `json:"cidrs"`
+	LastSeen    string   `json:"last_seen,omitempty"`
+}
+
+// V2Threats are the threat categories by address count, of a country and an ASN when they were asked for
+type V2Threats struct {
+	CountryCode string      `json:"country_code,omitempty"`
+	ASN         string      `json:"asn,omitempty"`
+	Threats     []*V2Threat `json:"threats"`
+}
+
+// Payload is the page of threat categories
+func (t *V2Threats) Payload() interface{} {
+	return t.Threats
+}
+
+// V2Threat is the range and address count of a threat category
+type V2Threat struct {
+	Name     string `json:"threat"`
+	Ranges   int    `json:"ranges"`
+	TotalIPs int    `json:"total_ips"`
+}
+
+// V2ThreatRanges is a page of the ranges of a threat category
+type V2ThreatRanges struct {
+	Threat      string     `json:"threat"`
+	CountryCode string     `json:"country_code,omitempty"`
+	ASN         string     `json:"asn,omitempty"`
+	Ranges      []*V2Range `json:"ranges"`
+}
+
+// Payload is the page of ranges
+func (t *V2ThreatRanges) Payload() interface{} {
+	return t.Ranges
+}
+
+// V2Codes are the known proxy and usage type codes, sorted by code
+type V2Codes struct {
+	ProxyTypes []*service.Code `json:"proxy_types"`
+	UsageTypes []*service.Code `json:"usage_types"`
+}
+
+// Payload is the proxy types followed by the usage types
+func (c *V2Codes) Payload() interface{} {
+	return append(append([]*service.Code{}, c.ProxyTypes...), c.UsageTypes...)
+}
@@ -4,10 +4,20 @@ import (
 	"bytes"
 	"encoding/gob"
 	"log"
+	"net"
 	"net/http"
 	"regexp"
+	"strconv"
+
+	"github.com/nullc0rp/go-ip2proxy-api/logging"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracer names the spans of the handlers
+var tracer = otel.Tracer("github.com/nullc0rp/go-ip2proxy-api/controller")
+
 // GetBytes self explanatory
 func GetBytes(key interface{}) ([]byte, error) {
 	var buf bytes.Buffer
@@ -51,6 +61,56 @@ func OnlyInt(input string) string {
 
 //WriteError helps returning error
 func WriteError(w http.ResponseWriter, message string) {
-	w.WriteHeader(http.StatusInternalServerError)
+	WriteErrorCode(w, http.StatusInternalServerError, message)
+}
+
+//WriteErrorCode helps returning error with a status other than 500
+func WriteErrorCode(w http.ResponseWriter, status int, message string) {
+	w.Header().Set(CONTENTYPE, TEXTPLAIN)
+	w.WriteHeader(status)
 	w.Write([]byte(message))
 }
+
+// selectedFields reads the fields parameter, nil when there is none
+func selectedFields(r *http.Request) ([]string, error) {
+	if !r.URL.Query().Has(FIELDS) {
+		return nil, nil
+	}
+	return service.ParseFields(r.URL.Query().Get(FIELDS))
+}
+
+// lookup gets the proxy data of an address, only the selected columns when fields are given
+func lookup(s service.Service, ip net.IP, fields []string) (*service.IPData, error) {
+	if fields == nil {
+		return s.GetIPInfo(ip)
+	}
+	return s.GetIPInfoFields(ip, fields)
+}
+
+// maxAge scopes the service to the ranges seen in the last max_age_days, it is false for a bad value.
+// The service calls are also traced and logged under the request, when it is being recorded or logged.
+func maxAge(s service.Service, r *http.Request) (service.Service, bool) {
+	_, logged := logging.FromContext(r.Context())
+	if logged || trace.SpanFromContext(r.Context()).IsRecording() {
+		s = s.WithContext(r.Context())
+	}
+
+	value := r.URL.Query().Get(MAXAGEDAYS)
+	if value == "" {
+		return s, true
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 1 {
+		return nil, false
+	}
+	return s.MaxAge(days), true
+}
+
+// traced runs the handler in its own span, the service calls it makes are children of it
+func traced(name string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), name)
+		defer span.End()
+		next(w, r.WithContext(ctx))
+	}
+}
@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+)
+
+const DEFAULTVERSIONINTERVAL = time.Minute
+
+// DatasetVersion holds the dataset version told in the v2 meta, it is read from the service at most once per interval.
+// Until then, and when a read fails, the last version read is told, so responses don't query the database for it.
+type DatasetVersion struct {
+	Service service.Service
+	//Interval between reads, DEFAULTVERSIONINTERVAL when zero
+	Interval time.Duration
+
+	mutex   sync.Mutex
+	version string
+	read    time.Time
+}
+
+// Get returns the version, the error is only returned to the request that read it
+func (d *DatasetVersion) Get() (string, error) {
+	interval := d.Interval
+	if interval == 0 {
+		interval = DEFAULTVERSIONINTERVAL
+	}
+
+	//A single request reads it, the others tell the last one meanwhile
+	d.mutex.Lock()
+	if time.Since(d.read) < interval {
+		defer d.mutex.Unlock()
+		return d.version, nil
+	}
+	d.read = time.Now()
+	d.mutex.Unlock()
+
+	version, err := d.Service.DatasetVersion()
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if err != nil {
+		return d.version, err
+	}
+	d.version = version
+	return version, nil
+}
@@ -1,12 +1,19 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
+	"github.com/nullc0rp/go-ip2proxy-api/privacy"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 //Database interface
@@ -14,6 +21,8 @@ type Database interface {
 	Connect()
 	Disconnect()
 	Query(query string) (*sql.Rows, error)
+	// QueryContext is Query as part of a request, it is traced under the request span
+	QueryContext(ctx context.Context, query string) (*sql.Rows, error)
 }
 
 // Database connection "manager" main struct, holds the connection globally
@@ -23,12 +32,23 @@ type DatabaseImpl struct {
 	User       string
 	Password   string
 	Database   string
+	//Logger writes the failed queries, slog.Default() when nil
+	Logger *slog.Logger
+	//Anonymizer rewrites the addresses of the statements kept in the spans, nil keeps them
+	Anonymizer *privacy.Anonymizer
 }
 
 const (
-	NOCONNECTION = "Unable to connecto to database: %s"
+	NOCONNECTION = "Unable to connecto to database: %w"
+	DBSYSTEM     = "db.system"
+	DBNAME       = "db.name"
+	DBSTATEMENT  = "db.statement"
+	QUERYFAILED  = "Query failed"
 )
 
+// tracer names the spans of the queries
+var tracer = otel.Tracer("github.com/nullc0rp/go-ip2proxy-api/database")
+
 // Connect connects to database
 func (c *DatabaseImpl) Connect() {
 	var err error
@@ -59,16 +79,39 @@ func (c DatabaseImpl) Disconnect() {
 
 // Query launches a query against the database
 func (c DatabaseImpl) Query(query string) (*sql.Rows, error) {
+	return c.QueryContext(context.Background(), query)
+}
+
+// QueryContext launches a query against the database in a span holding the statement
+func (c DatabaseImpl) QueryContext(ctx context.Context, query string) (*sql.Rows, error) {
+	ctx, span := tracer.Start(ctx, "DatabaseImpl.Query", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String(DBSYSTEM, "mysql"),
+		attribute.String(DBNAME, c.Database),
+		attribute.String(DBSTATEMENT, c.Anonymizer.String(query)),
+	))
+	defer span.End()
+
 	// Prepare statement for reading data
-	results, err := c.Connection.Query(query)
+	results, err := c.Connection.QueryContext(ctx, query)
 	if err != nil {
-		log.Printf(err.Error()) // Error is logged for debug
-		return nil, NoConnectionError(err.Error())
+		c.logger().ErrorContext(ctx, QUERYFAILED, "error", err) // Error is logged for debug
+		message := c.Anonymizer.String(err.Error())
+		span.RecordError(errors.New(message))
+		span.SetStatus(codes.Error, message)
+		return nil, NoConnectionError(err)
 	}
 	return results, nil
 }
 
-//NoConnectionError returns a generic error for database
-func NoConnectionError(message string) error {
-	return fmt.Errorf(NOCONNECTION, message)
+// logger is the configured logger or the default one
+func (c DatabaseImpl) logger() *slog.Logger {
+	if c.Logger == nil {
+		return slog.Default()
+	}
+	return c.Logger
+}
+
+//NoConnectionError returns a generic error for database, wrapping the driver one
+func NoConnectionError(err error) error {
+	return fmt.Errorf(NOCONNECTION, err)
 }
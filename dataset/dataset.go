@@ -0,0 +1,478 @@
+package dataset
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+)
+
+const (
+	PX7COLUMNS = 12
+	BADLINE    = "Bad dataset line %d: %s"
+	BADCOLUMNS = "expected %d columns, got %d"
+	BADTIER    = "%d columns match no package tier"
+	BADHEADER  = "the header has no ip_from and ip_to"
+	TOPTYPES   = 3
+)
+
+// tierFields are the fields after ip_from and ip_to in the CSV of each package tier, by column count.
+// PX9 and PX10 share their columns, PX10 adds residential proxies to the same layout.
+var tierFields = map[int][]string{
+	4:  {"country_code", "country_name"},
+	5:  {"proxy_type", "country_code", "country_name"},
+	7:  {"proxy_type", "country_code", "country_name", "region_name", "city_name"},
+	8:  {"proxy_type", "country_code", "country_name", "region_name", "city_name", "isp"},
+	9:  {"proxy_type", "country_code", "country_name", "region_name", "city_name", "isp", "domain"},
+	10: {"proxy_type", "country_code", "country_name", "region_name", "city_name", "isp", "domain", "usage_type"},
+	12: service.PX7FIELDS,
+	13: service.IPDATAFIELDS[:11],
+	14: service.IPDATAFIELDS[:12],
+	15: service.IPDATAFIELDS[:13],
+	16: service.IPDATAFIELDS,
+}
+
+// layout is where the range and the fields are in each record
+type layout struct {
+	columns int
+	from    int
+	to      int
+	fields  []string
+	//positions holds the column of each field
+	positions []int
+}
+
+// Range is a row of the dataset, every address between From and To shares the same data
+type Range struct {
+	From uint32
+	To   uint32
+	Data *service.IPData
+}
+
+// Dataset holds an IP2Proxy CSV in memory, sorted by range, and answers the same queries as the database.
+// Only the IPv4 package is supported, like the database service.
+type Dataset struct {
+	Ranges []Range
+	//Fields are the IPData fields of the package tier
+	Fields []string
+	//Version identifies the loaded file, it is empty for datasets read from a stream
+	Version string
+	//MaxAgeDays leaves out the ranges last seen longer ago than that, zero keeps all of them
+	MaxAgeDays int
+}
+
+// Load reads a dataset file from disk
+func Load(path string) (*Dataset, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	dataset, err := Read(file)
+	if err != nil {
+		return nil, err
+	}
+
+	//The file name and modification time change on every download
+	if info, err := file.Stat(); err == nil {
+		dataset.Version = fmt.Sprintf("%s@%s", filepath.Base(path), info.ModTime().UTC().Format(time.RFC3339))
+	}
+	return dataset, nil
+}
+
+// Read parses an IP2Proxy CSV of any package tier, from PX1 to PX11.
+// The tier is taken from the amount of columns, or from a header row naming them when there is one.
+func Read(reader io.Reader) (*Dataset, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.ReuseRecord = true
+	csvReader.FieldsPerRecord = -1
+
+	dataset := &Dataset{}
+	var rows *layout
+	line := 0
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		line = line + 1
+		if err != nil {
+			return nil, fmt.Errorf(BADLINE, line, err)
+		}
+
+		//The first line sets the layout, it is a header when it doesn't start with a number
+		if rows == nil {
+			if _, err := strconv.ParseUint(record[0], 10, 32); err != nil {
+				if rows, err = headerLayout(record); err != nil {
+					return nil, fmt.Errorf(BADLINE, line, err)
+				}
+				dataset.Fields = rows.fields
+				continue
+			}
+			if rows, err = tierLayout(len(record)); err != nil {
+				return nil, fmt.Errorf(BADLINE, line, err)
+			}
+			dataset.Fields = rows.fields
+		}
+		if len(record) != rows.columns {
+			return nil, fmt.Errorf(BADLINE, line, fmt.Sprintf(BADCOLUMNS, rows.columns, len(record)))
+		}
+
+		from, err := strconv.ParseUint(record[rows.from], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf(BADLINE, line, err)
+		}
+		to, err := strconv.ParseUint(record[rows.to], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf(BADLINE, line, err)
+		}
+
+		data := &service.IPData{}
+		for i, field := range rows.fields {
+			*data.FieldPointer(field) = record[rows.positions[i]]
+		}
+		dataset.Ranges = append(dataset.Ranges, Range{
+			From: uint32(from),
+			To:   uint32(to),
+			Data: data,
+		})
+	}
+
+	//The files are sorted already, but lookups rely on it
+	sort.Slice(dataset.Ranges, func(i, j int) bool {
+		return dataset.Ranges[i].From < dataset.Ranges[j].From
+	})
+
+	return dataset, nil
+}
+
+// tierLayout is the layout of the package tier with that many columns
+func tierLayout(columns int) (*layout, error) {
+	fields, ok := tierFields[columns]
+	if !ok {
+		return nil, fmt.Errorf(BADTIER, columns)
+	}
+	rows := &layout{columns: columns, from: 0, to: 1, fields: fields}
+	for i := range fields {
+		rows.positions = append(rows.positions, i+2)
+	}
+	return rows, nil
+}
+
+// headerLayout takes the columns from their names, unknown columns are skipped
+func headerLayout(header []string) (*layout, error) {
+	rows := &layout{columns: len(header), from: -1, to: -1}
+	positions := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case service.IPFROM:
+			rows.from = i
+		case service.IPTO:
+			rows.to = i
+		default:
+			positions[name] = i
+		}
+	}
+	if rows.from < 0 || rows.to < 0 {
+		return nil, errors.New(BADHEADER)
+	}
+
+	//Fields in IPData order, whatever the order of the file
+	for _, field := range service.IPDATAFIELDS {
+		if i, ok := positions[field]; ok {
+			rows.fields = append(rows.fields, field)
+			rows.positions = append(rows.positions, i)
+		}
+	}
+	return rows, nil
+}
+
+// Find returns the range holding the address, or nil
+func (d *Dataset) Find(ip uint32) *Range {
+	//First range starting after the address, the candidate is the one before it
+	i := sort.Search(len(d.Ranges), func(i int) bool {
+		return d.Ranges[i].From > ip
+	})
+	if i == 0 || d.Ranges[i-1].To < ip {
+		return nil
+	}
+	return &d.Ranges[i-1]
+}
+
+// GetIPInfo finds the data for an address
+func (d *Dataset) GetIPInfo(ip net.IP) (*service.IPData, error) {
+	found := d.Find(service.IP2int(ip))
+	if found == nil {
+		return nil, service.NoResultError(service.CHECKDATA)
+	}
+	if d.stale(*found) {
+		return nil, &service.StaleError{LastSeen: found.Data.LastSeen}
+	}
+	//Copy, so callers can't change the dataset
+	ipdata := *found.Data
+	return &ipdata, nil
+}
+
+// GetIPInfoFields finds the range of an address and copies only the given fields
+func (d *Dataset) GetIPInfoFields(ip net.IP, fields []string) (*service.IPData, error) {
+	for _, field := range fields {
+		if (&service.IPData{}).FieldPointer(field) == nil {
+			return nil, &service.BadField{Field: field}
+		}
+	}
+	found := d.Find(service.IP2int(ip))
+	if found == nil {
+		return nil, service.NoResultError(service.CHECKDATA)
+	}
+	if d.stale(*found) {
+		return nil, &service.StaleError{LastSeen: found.Data.LastSeen}
+	}
+	return found.Data.Select(fields), nil
+}
+
+// GetIPInfoRange finds the range of an address, copying the given fields or all of them when nil
+func (d *Dataset) GetIPInfoRange(ip net.IP, fields []string) (*service.IPDataRange, error) {
+	data, err := d.GetIPInfo(ip)
+	if fields != nil {
+		data, err = d.GetIPInfoFields(ip, fields)
+	}
+	if err != nil {
+		return nil, err
+	}
+	found := d.Find(service.IP2int(ip))
+	return &service.IPDataRange{IPFrom: found.From, IPTo: found.To, Data: data}, nil
+}
+
+// GetIPCountry gets up to limit addresses for a country, following the database service
+func (d *Dataset) GetIPCountry(country string, limit int) (*service.IPCountryData, error) {
+	IPList := []*service.IPDataResult{}
+	counter := 0
+	for _, r := range d.Ranges {
+		if counter >= limit {
+			break
+		}
+		if r.Data.CountryCode != country || d.stale(r) {
+			continue
+		}
+		for ip := uint64(r.From); ip <= uint64(r.To) && counter < limit; ip++ {
+			IPList = append(IPList, &service.IPDataResult{
+				IP:          service.Int2IP(uint32(ip)).String(),
+				CountryName: r.Data.CountryName,
+				CityName:    r.Data.CityName,
+			})
+			counter = counter + 1
+		}
+	}
+	return &service.IPCountryData{IPList: IPList, Total: counter}, nil
+}
+
+// GetCountryRanges gets up to limit ranges for a country
+func (d *Dataset) GetCountryRanges(country string, limit int) (*service.IPRangeCountryData, error) {
+	rangeList := []*service.IPDataSimple{}
+	for _, r := range d.Ranges {
+		if len(rangeList) >= limit {
+			break
+		}
+		if r.Data.CountryCode == country && !d.stale(r) {
+			rangeList = append(rangeList, &service.IPDataSimple{
+				IPFrom:      r.From,
+				IPTo:        r.To,
+				CountryName: r.Data.CountryName,
+				CityName:    r.Data.CityName,
+			})
+		}
+	}
+	return &service.IPRangeCountryData{RangeList: rangeList, Total: len(rangeList)}, nil
+}
+
+// GetISPCountry gets the distinct ISP names for a country
+func (d *Dataset) GetISPCountry(country string) (*service.ISPCountryData, error) {
+	set := make(map[string]bool)
+	ISPList := []*service.ISPDataResult{}
+	for _, r := range d.Ranges {
+		if r.Data.CountryCode == country && !set[r.Data.ISP] && !d.stale(r) {
+			set[r.Data.ISP] = true
+			ISPList = append(ISPList, &service.ISPDataResult{Name: r.Data.ISP})
+		}
+	}
+	return &service.ISPCountryData{ISPList: ISPList, Total: len(set)}, nil
+}
+
+// GetCountryTotal counts the addresses of a country
+func (d *Dataset) GetCountryTotal(country string) (*service.IPCountryTotal, error) {
+	total := 0
+	for _, r := range d.Ranges {
+		if r.Data.CountryCode == country && !d.stale(r) {
+			total = total + int(uint64(r.To)+1-uint64(r.From))
+		}
+	}
+	return &service.IPCountryTotal{Total: total}, nil
+}
+
+// MostProxyTypes counts the ranges per proxy type and returns the top ones
+func (d *Dataset) MostProxyTypes() (*service.MostProxyTypeResult, error) {
+	counts := make(map[string]int)
+	for _, r := range d.Ranges {
+		if !d.stale(r) {
+			counts[r.Data.ProxyType] = counts[r.Data.ProxyType] + 1
+		}
+	}
+
+	mostProxyTypeList := []*service.MostProxyType{}
+	for proxyType, total := range counts {
+		mostProxyTypeList = append(mostProxyTypeList, &service.MostProxyType{
+			ProxyType: proxyType,
+			Total:     total,
+		})
+	}
+	sort.Slice(mostProxyTypeList, func(i, j int) bool {
+		if mostProxyTypeList[i].Total == mostProxyTypeList[j].Total {
+			return mostProxyTypeList[i].ProxyType < mostProxyTypeList[j].ProxyType
+		}
+		return mostProxyTypeList[i].Total > mostProxyTypeList[j].Total
+	})
+	if len(mostProxyTypeList) > TOPTYPES {
+		mostProxyTypeList = mostProxyTypeList[:TOPTYPES]
+	}
+
+	return &service.MostProxyTypeResult{ProxyTypeList: mostProxyTypeList}, nil
+}
+
+// GetProviders counts the ranges and addresses of every VPN provider, of a country when it is not empty
+func (d *Dataset) GetProviders(country string) (*service.ProviderData, error) {
+	providers := make(map[string]*service.ProviderTotal)
+	providerList := []*service.ProviderTotal{}
+	for _, r := range d.Ranges {
+		if r.Data.Provider == "" || r.Data.Provider == "-" || !d.matches(r, country, "") {
+			continue
+		}
+		provider, ok := providers[r.Data.Provider]
+		if !ok {
+			provider = &service.ProviderTotal{Name: r.Data.Provider}
+			providers[r.Data.Provider] = provider
+			providerList = append(providerList, provider)
+		}
+		provider.Ranges = provider.Ranges + 1
+		provider.Total = provider.Total + int(uint64(r.To)+1-uint64(r.From))
+	}
+	sort.SliceStable(providerList, func(i, j int) bool {
+		return providerList[i].Total > providerList[j].Total
+	})
+	return &service.ProviderData{ProviderList: providerList}, nil
+}
+
+// GetProviderRanges gets up to limit ranges of a VPN provider, of a country when it is not empty
+func (d *Dataset) GetProviderRanges(provider string, country string, limit int) (*service.IPRangeData, error) {
+	rangeList := []*service.IPRange{}
+	for _, r := range d.Ranges {
+		if len(rangeList) >= limit {
+			break
+		}
+		if r.Data.Provider == provider && d.matches(r, country, "") {
+			rangeList = append(rangeList, &service.IPRange{IPFrom: r.From, IPTo: r.To, CountryCode: r.Data.CountryCode, LastSeen: r.Data.LastSeen})
+		}
+	}
+	return &service.IPRangeData{RangeList: rangeList, Total: len(rangeList)}, nil
+}
+
+// GetThreats counts the ranges and addresses of every threat category, of a country and an ASN when they are not empty
+func (d *Dataset) GetThreats(country string, asn string) (*service.ThreatData, error) {
+	threats := make(map[string]*service.ThreatTotal)
+	threatList := []*service.ThreatTotal{}
+	for _, r := range d.Ranges {
+		if r.Data.Threat == "" || r.Data.Threat == "-" || !d.matches(r, country, asn) {
+			continue
+		}
+		threat, ok := threats[r.Data.Threat]
+		if !ok {
+			threat = &service.ThreatTotal{Name: r.Data.Threat}
+			threats[r.Data.Threat] = threat
+			threatList = append(threatList, threat)
+		}
+		threat.Ranges = threat.Ranges + 1
+		threat.Total = threat.Total + int(uint64(r.To)+1-uint64(r.From))
+	}
+	sort.SliceStable(threatList, func(i, j int) bool {
+		return threatList[i].Total > threatList[j].Total
+	})
+	return &service.ThreatData{ThreatList: threatList}, nil
+}
+
+// GetThreatRanges gets up to limit ranges of a threat category, of a country and an ASN when they are not empty
+func (d *Dataset) GetThreatRanges(threat string, country string, asn string, limit int) (*service.IPRangeData, error) {
+	rangeList := []*service.IPRange{}
+	for _, r := range d.Ranges {
+		if len(rangeList) >= limit {
+			break
+		}
+		if r.Data.Threat == threat && d.matches(r, country, asn) {
+			rangeList = append(rangeList, &service.IPRange{IPFrom: r.From, IPTo: r.To, CountryCode: r.Data.CountryCode, LastSeen: r.Data.LastSeen})
+		}
+	}
+	return &service.IPRangeData{RangeList: rangeList, Total: len(rangeList)}, nil
+}
+
+// matches tells if a fresh range is of the country and ASN, empty ones match any
+func (d *Dataset) matches(r Range, country string, asn string) bool {
+	return (country == "" || r.Data.CountryCode == country) && (asn == "" || r.Data.ASN == asn) && !d.stale(r)
+}
+
+// stale tells if the range was last seen longer ago than MaxAgeDays
+func (d *Dataset) stale(r Range) bool {
+	return service.Stale(r.Data.LastSeen, d.MaxAgeDays)
+}
+
+// MaxAge is a view of the dataset without the ranges last seen longer ago than days
+func (d *Dataset) MaxAge(days int) service.Service {
+	scoped := *d
+	scoped.MaxAgeDays = days
+	return &scoped
+}
+
+// WithContext returns the dataset itself, lookups in memory are not traced
+func (d *Dataset) WithContext(ctx context.Context) service.Service {
+	return d
+}
+
+// DatasetVersion returns the version of the loaded file
+func (d *Dataset) DatasetVersion() (string, error) {
+	if d.Version == "" {
+		return service.UNKNOWNVERSION, nil
+	}
+	return d.Version, nil
+}
+
+// EachRange visits every range in order with a copy of its data, like the database service.
+// It stops at the first error of visit, which is returned.
+func (d *Dataset) EachRange(visit func(found *service.IPDataRange) error) error {
+	for _, r := range d.Ranges {
+		ipdata := *r.Data
+		if err := visit(&service.IPDataRange{IPFrom: r.From, IPTo: r.To, Data: &ipdata}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EachBounds visits the bounds of every range in order, like the database service.
+// It stops at the first error of visit, which is returned.
+func (d *Dataset) EachBounds(visit func(from uint32, to uint32) error) error {
+	for _, r := range d.Ranges {
+		if err := visit(r.From, r.To); err != nil {
+			return err
+		}
+	}
+	return nil
+}
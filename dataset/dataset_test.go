@@ -0,0 +1,153 @@
+package dataset
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+	"github.com/stretchr/testify/assert"
+)
+
+const SAMPLE = `"16778241","16778241","PUB","AU","Australia","Victoria","Melbourne","Telstra","telstra.com","ISP","1221","Telstra Corporation"
+"16778242","16778249","VPN","AU","Australia","Victoria","Melbourne","Telstra","telstra.com","DCH","1221","Telstra Corporation"
+"168430081","168430090","PUB","PL","Poland","Mazowieckie","Warsaw","Opera Software ASA","opera.com","DCH","1299","Opera"
+`
+
+func TestGetIPInfo(t *testing.T) {
+
+	dataset, err := Read(strings.NewReader(SAMPLE))
+	assert.Nil(t, err)
+
+	result, err := dataset.GetIPInfo(net.ParseIP("10.10.10.5"))
+	assert.Nil(t, err)
+	assert.Equal(t, "Warsaw", result.CityName)
+
+	result, err = dataset.GetIPInfo(net.ParseIP("10.10.10.91"))
+	assert.Nil(t, result)
+	assert.True(t, service.IsNoResult(err))
+
+	//Only the selected fields are copied
+	result, _ = dataset.GetIPInfoFields(net.ParseIP("10.10.10.5"), []string{"isp"})
+	assert.Equal(t, &service.IPData{ISP: "Opera Software ASA"}, result)
+	//With the bounds of the range
+	found, err := dataset.GetIPInfoRange(net.ParseIP("10.10.10.5"), []string{"isp"})
+	assert.Nil(t, err)
+	assert.Equal(t, &service.IPDataRange{IPFrom: 168430081, IPTo: 168430090, Data: &service.IPData{ISP: "Opera Software ASA"}}, found)
+	found, _ = dataset.GetIPInfoRange(net.ParseIP("10.10.10.5"), nil)
+	assert.Equal(t, "Warsaw", found.Data.CityName)
+}
+
+func TestCountryQueries(t *testing.T) {
+
+	dataset, _ := Read(strings.NewReader(SAMPLE))
+
+	list, _ := dataset.GetIPCountry("AU", 3)
+	assert.Equal(t, 3, list.Total)
+	assert.Equal(t, "1.0.4.3", list.IPList[2].IP)
+
+	total, _ := dataset.GetCountryTotal("AU")
+	assert.Equal(t, 9, total.Total)
+
+	isps, _ := dataset.GetISPCountry("AU")
+	assert.Equal(t, 1, isps.Total)
+
+	proxyTypes, _ := dataset.MostProxyTypes()
+	assert.Equal(t, "PUB", proxyTypes.ProxyTypeList[0].ProxyType)
+	assert.Equal(t, 2, proxyTypes.ProxyTypeList[0].Total)
+
+	//Only files loaded from disk carry a version
+	version, _ := dataset.DatasetVersion()
+	assert.Equal(t, service.UNKNOWNVERSION, version)
+}
+
+func TestReadBadLine(t *testing.T) {
+
+	_, err := Read(strings.NewReader("\"1\",\"2\",\"PUB\"\n"))
+
+	assert.Equal(t, "Bad dataset line 1: 3 columns match no package tier", err.Error())
+
+	//The first line sets the tier for the rest
+	_, err = Read(strings.NewReader("\"1\",\"2\",\"AU\",\"Australia\"\n\"3\",\"4\",\"PUB\"\n"))
+
+	assert.Equal(t, "Bad dataset line 2: expected 4 columns, got 3", err.Error())
+
+	_, err = Read(strings.NewReader("from,to,country_code\n1,2,AU\n"))
+
+	assert.Equal(t, "Bad dataset line 1: the header has no ip_from and ip_to", err.Error())
+}
+
+func TestReadTiers(t *testing.T) {
+
+	//PX1 only has the country
+	dataset, err := Read(strings.NewReader("\"16778241\",\"16778241\",\"AU\",\"Australia\"\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"country_code", "country_name"}, dataset.Fields)
+
+	result, _ := dataset.GetIPInfo(net.ParseIP("1.0.4.1"))
+	assert.Equal(t, &service.IPData{CountryCode: "AU", CountryName: "Australia"}, result)
+
+	//PX11 adds the last seen days, threat, provider and fraud score
+	dataset, err = Read(strings.NewReader("\"16778241\",\"16778241\",\"VPN\",\"AU\",\"Australia\",\"Victoria\",\"Melbourne\",\"Telstra\",\"telstra.com\",\"DCH\",\"1221\",\"Telstra Corporation\",\"3\",\"SPAM\",\"NordVPN\",\"85\"\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, service.IPDATAFIELDS, dataset.Fields)
+
+	result, _ = dataset.GetIPInfo(net.ParseIP("1.0.4.1"))
+	assert.Equal(t, "3", result.LastSeen)
+	assert.Equal(t, "SPAM", result.Threat)
+	assert.Equal(t, "NordVPN", result.Provider)
+	assert.Equal(t, "85", result.FraudScore)
+}
+
+func TestReadHeader(t *testing.T) {
+
+	//Named columns may come in any order, unknown ones are skipped
+	dataset, err := Read(strings.NewReader("IP_TO,ip_from,threat,extra,country_code\n16778249,16778241,BOTNET,x,AU\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"country_code", "threat"}, dataset.Fields)
+
+	result, _ := dataset.GetIPInfo(net.ParseIP("1.0.4.9"))
+	assert.Equal(t, &service.IPData{CountryCode: "AU", Threat: "BOTNET"}, result)
+}
+
+func TestProviders(t *testing.T) {
+
+	dataset, err := Read(strings.NewReader("ip_from,ip_to,country_code,provider\n16778240,16778495,AU,NordVPN\n16778496,16778497,AU,ExpressVPN\n168430081,168430090,PL,NordVPN\n168430091,168430091,PL,-\n"))
+	assert.Nil(t, err)
+
+	providers, _ := dataset.GetProviders("")
+	assert.Equal(t, []*service.ProviderTotal{{Name: "NordVPN", Ranges: 2, Total: 266}, {Name: "ExpressVPN", Ranges: 1, Total: 2}}, providers.ProviderList)
+
+	ranges, _ := dataset.GetProviderRanges("NordVPN", "PL", 10)
+	assert.Equal(t, []*service.IPRange{{IPFrom: 168430081, IPTo: 168430090, CountryCode: "PL"}}, ranges.RangeList)
+}
+
+func TestThreats(t *testing.T) {
+
+	dataset, err := Read(strings.NewReader("ip_from,ip_to,country_code,asn,threat\n16778240,16778495,AU,1221,SCANNER\n16778496,16778497,AU,1221,SPAM\n168430081,168430090,PL,1299,SCANNER\n"))
+	assert.Nil(t, err)
+
+	threats, _ := dataset.GetThreats("", "1221")
+	assert.Equal(t, []*service.ThreatTotal{{Name: "SCANNER", Ranges: 1, Total: 256}, {Name: "SPAM", Ranges: 1, Total: 2}}, threats.ThreatList)
+
+	ranges, _ := dataset.GetThreatRanges("SCANNER", "PL", "", 10)
+	assert.Equal(t, []*service.IPRange{{IPFrom: 168430081, IPTo: 168430090, CountryCode: "PL"}}, ranges.RangeList)
+}
+
+func TestMaxAge(t *testing.T) {
+
+	dataset, err := Read(strings.NewReader("ip_from,ip_to,proxy_type,country_code,last_seen\n16778240,16778495,VPN,AU,45\n168430081,168430090,PUB,AU,3\n"))
+	assert.Nil(t, err)
+
+	scoped := dataset.MaxAge(30)
+
+	_, err = scoped.GetIPInfo(net.ParseIP("1.0.4.1"))
+	assert.Equal(t, "45", err.(*service.StaleError).LastSeen)
+
+	total, _ := scoped.GetCountryTotal("AU")
+	assert.Equal(t, 10, total.Total)
+
+	//The dataset itself keeps every range
+	total, _ = dataset.GetCountryTotal("AU")
+	assert.Equal(t, 266, total.Total)
+}
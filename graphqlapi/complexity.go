@@ -0,0 +1,173 @@
+package graphqlapi
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+const (
+	//LISTCOST is the assumed size of a list field without a limit argument
+	LISTCOST       = 10
+	TOOCOMPLEX     = "Query complexity %d exceeds the limit of %d"
+	TOODEEP        = "Query depth exceeds the limit of %d"
+	FRAGMENTCYCLE  = "Fragment %s spreads itself"
+	LIMITARGUMENT  = "limit"
+	ADDRESSESFIELD = "addresses"
+)
+
+// listFields are the fields returning lists, their selections are paid once per item
+var listFields = map[string]bool{
+	"ips":        true,
+	"isps":       true,
+	"peers":      true,
+	"ranges":     true,
+	"proxyTypes": true,
+}
+
+// complexity estimates the cost of a query before running it.
+// Every field costs one, and the selections under a list field are multiplied by its size,
+// taken from the limit or addresses argument, or LISTCOST when unknown.
+// A fragment spread is a step deeper, and the cost of a fragment is counted once per depth.
+type complexity struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	maxDepth  int
+	//costs of the fragments already counted, by name and depth
+	costs map[spread]int
+	//visiting are the fragments being counted, a spread of one of them is a cycle
+	visiting map[string]bool
+}
+
+type spread struct {
+	name  string
+	depth int
+}
+
+// checkComplexity fails when the query is too expensive or too deep
+func checkComplexity(document *ast.Document, variables map[string]interface{}, maxComplexity int, maxDepth int) error {
+	c := &complexity{
+		fragments: make(map[string]*ast.FragmentDefinition),
+		variables: variables,
+		maxDepth:  maxDepth,
+		costs:     make(map[spread]int),
+		visiting:  make(map[string]bool),
+	}
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			c.fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	total := 0
+	for _, definition := range document.Definitions {
+		if operation, ok := definition.(*ast.OperationDefinition); ok {
+			cost, err := c.selectionSet(operation.SelectionSet, 1)
+			if err != nil {
+				return err
+			}
+			total = total + cost
+		}
+	}
+
+	if total > maxComplexity {
+		return fmt.Errorf(TOOCOMPLEX, total, maxComplexity)
+	}
+	return nil
+}
+
+func (c *complexity) selectionSet(selectionSet *ast.SelectionSet, depth int) (int, error) {
+	if selectionSet == nil {
+		return 0, nil
+	}
+	if depth > c.maxDepth {
+		return 0, fmt.Errorf(TOODEEP, c.maxDepth)
+	}
+
+	total := 0
+	for _, selection := range selectionSet.Selections {
+		var cost int
+		var err error
+		switch s := selection.(type) {
+		case *ast.Field:
+			cost, err = c.field(s, depth)
+		case *ast.InlineFragment:
+			cost, err = c.selectionSet(s.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			cost, err = c.fragment(s.Name.Value, depth+1)
+		}
+		if err != nil {
+			return 0, err
+		}
+		total = total + cost
+	}
+	return total, nil
+}
+
+// fragment counts the selections of a fragment spread at a depth, once
+func (c *complexity) fragment(name string, depth int) (int, error) {
+	fragment, ok := c.fragments[name]
+	if !ok {
+		return 0, nil
+	}
+	key := spread{name: name, depth: depth}
+	if cost, ok := c.costs[key]; ok {
+		return cost, nil
+	}
+	if c.visiting[name] {
+		return 0, fmt.Errorf(FRAGMENTCYCLE, name)
+	}
+
+	c.visiting[name] = true
+	cost, err := c.selectionSet(fragment.SelectionSet, depth)
+	delete(c.visiting, name)
+	if err != nil {
+		return 0, err
+	}
+	c.costs[key] = cost
+	return cost, nil
+}
+
+func (c *complexity) field(field *ast.Field, depth int) (int, error) {
+	children, err := c.selectionSet(field.SelectionSet, depth+1)
+	if err != nil {
+		return 0, err
+	}
+	if listFields[field.Name.Value] {
+		children = children * c.listSize(field)
+	}
+	return 1 + children, nil
+}
+
+// listSize reads the size of a list from its arguments
+func (c *complexity) listSize(field *ast.Field) int {
+	for _, argument := range field.Arguments {
+		value := argument.Value
+		if variable, ok := value.(*ast.Variable); ok {
+			switch v := c.variables[variable.Name.Value].(type) {
+			case float64:
+				return rangesLimit(int(v))
+			case int:
+				return rangesLimit(v)
+			case []interface{}:
+				return len(v)
+			}
+			continue
+		}
+		switch argument.Name.Value {
+		case LIMITARGUMENT:
+			if intValue, ok := value.(*ast.IntValue); ok {
+				//Counted like the resolver reads it, a negative limit would lower the cost of the whole query
+				if size, err := strconv.Atoi(intValue.Value); err == nil {
+					return rangesLimit(size)
+				}
+			}
+		case ADDRESSESFIELD:
+			if listValue, ok := value.(*ast.ListValue); ok {
+				return len(listValue.Values)
+			}
+		}
+	}
+	return LISTCOST
+}
@@ -0,0 +1,272 @@
+package graphqlapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/nullc0rp/go-ip2proxy-api/mocks"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuerySharesCountryCalls(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	handler, err := NewHandler(mockService)
+	assert.Nil(t, err)
+
+	//Expects setup, the country data is fetched once for both addresses
+	mockService.EXPECT().GetIPInfo(net.ParseIP("10.10.10.1")).Return(&service.IPData{ProxyType: "PUB", CountryCode: "PL", CountryName: "Poland", ISP: "Opera", ASN: "1299"}, nil)
+	mockService.EXPECT().GetIPInfo(net.ParseIP("10.10.10.2")).Return(&service.IPData{ProxyType: "VPN", CountryCode: "PL", CountryName: "Poland", ISP: "Orange"}, nil)
+	mockService.EXPECT().GetCountryTotal("PL").Return(&service.IPCountryTotal{Total: 42}, nil).Times(1)
+	mockService.EXPECT().GetISPCountry("PL").Return(&service.ISPCountryData{Total: 2, ISPList: []*service.ISPDataResult{{Name: "Opera"}, {Name: "Orange"}}}, nil).Times(1)
+
+	body := `{"query": "{ ips(addresses: [\"10.10.10.1\", \"10.10.10.2\"]) { proxyType country { name total } isp { peers { name } } } }"}`
+	r, _ := http.NewRequest("POST", "/graphql", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, `{"data":{"ips":[{"country":{"name":"Poland","total":42},"isp":{"peers":[{"name":"Orange"}]},"proxyType":"PUB"},{"country":{"name":"Poland","total":42},"isp":{"peers":[{"name":"Opera"}]},"proxyType":"VPN"}]}}`, w.Body.String())
+}
+
+func TestQueryRepeatedAddresses(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	handler, _ := NewHandler(mockService)
+
+	//An address asked for several times, in a list and an alias, is looked up once
+	mockService.EXPECT().GetIPInfo(net.ParseIP("10.10.10.1")).Return(&service.IPData{ProxyType: "PUB"}, nil).Times(1)
+
+	body := `{"query": "{ ips(addresses: [\"10.10.10.1\", \"10.10.10.1\"]) { proxyType } again: ip(address: \"10.10.10.1\") { proxyType } }"}`
+	r, _ := http.NewRequest("POST", "/graphql", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, `{"data":{"again":{"proxyType":"PUB"},"ips":[{"proxyType":"PUB"},{"proxyType":"PUB"}]}}`, w.Body.String())
+}
+
+func TestQueryNotFound(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	handler, _ := NewHandler(mockService)
+
+	mockService.EXPECT().GetIPInfo(net.ParseIP("10.10.10.1")).Return(nil, service.NoResultError(service.CHECKDATA))
+
+	r, _ := http.NewRequest("GET", "/graphql?query="+strings.ReplaceAll("{ ip(address: \"10.10.10.1\") { found asn { number } } }", " ", "%20"), nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, `{"data":{"ip":{"asn":null,"found":false}}}`, w.Body.String())
+}
+
+func TestQueryTooComplex(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	handler, _ := NewHandler(mocks.NewMockService(controller))
+	handler.MaxComplexity = 100
+
+	body := `{"query": "query($c: String!) { country(code: $c) { ranges(limit: 200) { ipFrom ipTo } } }", "variables": {"c": "AR"}}`
+	r, _ := http.NewRequest("POST", "/graphql", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	assert.Contains(t, w.Body.String(), "Query complexity 402 exceeds the limit of 100")
+}
+
+func TestQueryNegativeLimitCounted(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	handler, _ := NewHandler(mocks.NewMockService(controller))
+	handler.MaxComplexity = 1000
+
+	//The negative limit lists DEFAULTLIMIT ranges, it can't pay for the expensive field
+	body := `{"query": "{ country(code: \"AR\") { cheap: ranges(limit: -100000) { ipFrom } ranges(limit: 1000) { ipFrom ipTo } } }"}`
+	r, _ := http.NewRequest("POST", "/graphql", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	assert.Contains(t, w.Body.String(), "Query complexity 2053 exceeds the limit of 1000")
+}
+
+func TestQueryTooDeep(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	handler, _ := NewHandler(mocks.NewMockService(controller))
+	handler.MaxDepth = 3
+
+	body := `{"query": "{ country(code: \"AR\") { isps { peers { peers { name } } } } }"}`
+	r, _ := http.NewRequest("POST", "/graphql", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	assert.Contains(t, w.Body.String(), "Query depth exceeds the limit of 3")
+}
+
+func TestQueryMaxAge(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+	agedService := mocks.NewMockService(controller)
+
+	handler, _ := NewHandler(mockService)
+
+	//Expects setup, the country below the address is read with the same max age
+	mockService.EXPECT().MaxAge(30).Return(agedService).Times(2)
+	agedService.EXPECT().GetIPInfo(net.ParseIP("10.10.10.1")).Return(&service.IPData{ProxyType: "PUB", CountryCode: "PL"}, nil)
+	agedService.EXPECT().GetCountryTotal("PL").Return(&service.IPCountryTotal{Total: 7}, nil)
+
+	body := `{"query": "{ ip(address: \"10.10.10.1\", maxAgeDays: 30) { proxyType country { total } } }"}`
+	r, _ := http.NewRequest("POST", "/graphql", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, `{"data":{"ip":{"country":{"total":7},"proxyType":"PUB"}}}`, w.Body.String())
+}
+
+func TestQueryProxyTypesMaxAge(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+	agedService := mocks.NewMockService(controller)
+
+	handler, _ := NewHandler(mockService)
+
+	//Expects setup
+	mockService.EXPECT().MaxAge(30).Return(agedService)
+	agedService.EXPECT().MostProxyTypes().Return(&service.MostProxyTypeResult{ProxyTypeList: []*service.MostProxyType{{ProxyType: "PUB", Total: 3}}}, nil)
+
+	body := `{"query": "{ proxyTypes(maxAgeDays: 30) { proxyType total } }"}`
+	r, _ := http.NewRequest("POST", "/graphql", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, `{"data":{"proxyTypes":[{"proxyType":"PUB","total":3}]}}`, w.Body.String())
+}
+
+func TestQueryStaleLastSeen(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+	agedService := mocks.NewMockService(controller)
+
+	handler, _ := NewHandler(mockService)
+
+	//Expects setup, the stale range is not found but keeps its age
+	mockService.EXPECT().MaxAge(30).Return(agedService)
+	agedService.EXPECT().GetIPInfo(net.ParseIP("10.10.10.1")).Return(nil, &service.StaleError{LastSeen: "45"})
+
+	body := `{"query": "{ ip(address: \"10.10.10.1\", maxAgeDays: 30) { found proxyType lastSeen } }"}`
+	r, _ := http.NewRequest("POST", "/graphql", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, `{"data":{"ip":{"found":false,"lastSeen":"45","proxyType":null}}}`, w.Body.String())
+}
+
+func TestQueryBadMaxAge(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	handler, _ := NewHandler(mocks.NewMockService(controller))
+
+	body := `{"query": "{ country(code: \"PL\", maxAgeDays: 0) { total } }"}`
+	r, _ := http.NewRequest("POST", "/graphql", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	assert.Contains(t, w.Body.String(), BADMAXAGE)
+}
+
+func TestQueryFragmentCycle(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	handler, _ := NewHandler(mocks.NewMockService(controller))
+
+	//Refused by the validation, before the cost is counted
+	body := `{"query": "query { ...F } fragment F on Query { ...F }"}`
+	r, _ := http.NewRequest("POST", "/graphql", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	assert.Contains(t, w.Body.String(), `Cannot spread fragment \"F\" within itself.`)
+
+	//Counting alone doesn't loop either
+	document, _ := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte("query { ...F } fragment F on Query { ...G } fragment G on Query { ...F }")})})
+	assert.NotNil(t, checkComplexity(document, nil, DEFAULTCOMPLEXITY, 100))
+}
+
+func TestQueryFragmentsSpreadTwice(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	handler, _ := NewHandler(mocks.NewMockService(controller))
+	handler.MaxDepth = 100
+
+	//Every fragment spreads the next one twice, 2^30 fields when expanded
+	query := "query { ...F0 } fragment F30 on Query { proxyTypes { total } }"
+	for i := 0; i < 30; i++ {
+		query += fmt.Sprintf(" fragment F%d on Query { ...F%d ...F%d }", i, i+1, i+1)
+	}
+	body, _ := json.Marshal(map[string]string{"query": query})
+	r, _ := http.NewRequest("POST", "/graphql", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	assert.Contains(t, w.Body.String(), "Query complexity")
+	assert.Contains(t, w.Body.String(), "exceeds the limit of 1000")
+}
@@ -0,0 +1,113 @@
+package graphqlapi
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+)
+
+const (
+	DEFAULTCOMPLEXITY = 1000
+	DEFAULTDEPTH      = 8
+	MAXBODY           = 1 << 20
+	BADREQUEST        = "Bad request"
+	CONTENTYPE        = "Content-Type"
+	APPJSON           = "application/json"
+	ERROR             = "Error"
+)
+
+// Handler serves GraphQL queries over GET and POST
+type Handler struct {
+	Service       service.Service
+	Schema        graphql.Schema
+	MaxComplexity int
+	MaxDepth      int
+}
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// NewHandler builds the schema with the default limits
+func NewHandler(serviceInstance service.Service) (*Handler, error) {
+	schema, err := NewSchema()
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{
+		Service:       serviceInstance,
+		Schema:        schema,
+		MaxComplexity: DEFAULTCOMPLEXITY,
+		MaxDepth:      DEFAULTDEPTH,
+	}, nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	// Get the query from the url or the body
+	query := request{}
+	if r.Method == http.MethodGet {
+		query.Query = r.URL.Query().Get("query")
+		query.OperationName = r.URL.Query().Get("operationName")
+		if variables := r.URL.Query().Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &query.Variables); err != nil {
+				http.Error(w, BADREQUEST, http.StatusBadRequest)
+				return
+			}
+		}
+	} else if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MAXBODY)).Decode(&query); err != nil {
+		http.Error(w, BADREQUEST, http.StatusBadRequest)
+		return
+	}
+
+	result := h.execute(r, query)
+
+	//Parse result data in Json format
+	jData, err := json.Marshal(result)
+	if err != nil {
+		log.Println(ERROR, err)
+		http.Error(w, BADREQUEST, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(CONTENTYPE, APPJSON)
+	w.Write(jData)
+}
+
+// execute validates the query and checks its cost before running it with a fresh loader
+func (h *Handler) execute(r *http.Request, query request) *graphql.Result {
+	document, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(query.Query)}),
+	})
+	if err != nil {
+		return &graphql.Result{Errors: []gqlerrors.FormattedError{gqlerrors.FormatError(err)}}
+	}
+
+	//Fragment cycles are refused on their own first, the other rules and the cost check would recurse forever on them
+	if validation := graphql.ValidateDocument(&h.Schema, document, []graphql.ValidationRuleFn{graphql.NoFragmentCyclesRule}); !validation.IsValid {
+		return &graphql.Result{Errors: validation.Errors}
+	}
+	if validation := graphql.ValidateDocument(&h.Schema, document, nil); !validation.IsValid {
+		return &graphql.Result{Errors: validation.Errors}
+	}
+
+	if err := checkComplexity(document, query.Variables, h.MaxComplexity, h.MaxDepth); err != nil {
+		return &graphql.Result{Errors: []gqlerrors.FormattedError{gqlerrors.FormatError(err)}}
+	}
+
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.Schema,
+		AST:           document,
+		OperationName: query.OperationName,
+		Args:          query.Variables,
+		Context:       withLoader(r.Context(), h.Service),
+	})
+}
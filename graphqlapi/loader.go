@@ -0,0 +1,136 @@
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+)
+
+// loader lives for a single request and collapses repeated service calls.
+// A query asking for the country total of a hundred addresses in the same country
+// runs a single total query instead of one per address.
+// It doesn't batch different calls: ips looks up every distinct address on its own,
+// through the lookup cache and index of the service when they are enabled.
+type loader struct {
+	service service.Service
+	mutex   sync.Mutex
+	calls   map[string]*call
+}
+
+type call struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+type loaderKey struct{}
+
+func newLoader(serviceInstance service.Service) *loader {
+	return &loader{
+		service: serviceInstance,
+		calls:   make(map[string]*call),
+	}
+}
+
+// withLoader stores a new loader in the request context
+func withLoader(ctx context.Context, serviceInstance service.Service) context.Context {
+	return context.WithValue(ctx, loaderKey{}, newLoader(serviceInstance))
+}
+
+// loaderFrom gets the request loader, every resolver runs under one
+func loaderFrom(ctx context.Context) *loader {
+	return ctx.Value(loaderKey{}).(*loader)
+}
+
+// load runs fetch once per key, concurrent callers wait for the first one
+func (l *loader) load(key string, fetch func() (interface{}, error)) (interface{}, error) {
+	l.mutex.Lock()
+	if c, ok := l.calls[key]; ok {
+		l.mutex.Unlock()
+		<-c.done
+		return c.value, c.err
+	}
+	c := &call{done: make(chan struct{})}
+	l.calls[key] = c
+	l.mutex.Unlock()
+
+	c.value, c.err = fetch()
+	close(c.done)
+	return c.value, c.err
+}
+
+// aged is the service leaving out the ranges last seen more than days ago, every range for 0
+func (l *loader) aged(days int) service.Service {
+	if days == 0 {
+		return l.service
+	}
+	return l.service.MaxAge(days)
+}
+
+// ipInfo gets the address data, nil when the address is not in the dataset.
+// For a range left out by its age the data is nil and lastSeen keeps the age, like v2 answers it
+func (l *loader) ipInfo(ip net.IP, days int) (data *service.IPData, lastSeen string, err error) {
+	value, err := l.load(fmt.Sprintf("ip:%s:%d", ip, days), func() (interface{}, error) {
+		data, err := l.aged(days).GetIPInfo(ip)
+		var stale *service.StaleError
+		if errors.As(err, &stale) {
+			return &ipNode{LastSeen: stale.LastSeen}, nil
+		}
+		if service.IsNoResult(err) {
+			return &ipNode{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &ipNode{Data: data, LastSeen: data.LastSeen}, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	node := value.(*ipNode)
+	return node.Data, node.LastSeen, nil
+}
+
+func (l *loader) countryTotal(country string, days int) (*service.IPCountryTotal, error) {
+	value, err := l.load(fmt.Sprintf("total:%s:%d", country, days), func() (interface{}, error) {
+		return l.aged(days).GetCountryTotal(country)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*service.IPCountryTotal), nil
+}
+
+func (l *loader) countryISPs(country string, days int) (*service.ISPCountryData, error) {
+	value, err := l.load(fmt.Sprintf("isp:%s:%d", country, days), func() (interface{}, error) {
+		return l.aged(days).GetISPCountry(country)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*service.ISPCountryData), nil
+}
+
+func (l *loader) countryRanges(country string, limit int, days int) (*service.IPRangeCountryData, error) {
+	value, err := l.load(fmt.Sprintf("ranges:%s:%d:%d", country, limit, days), func() (interface{}, error) {
+		return l.aged(days).GetCountryRanges(country, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*service.IPRangeCountryData), nil
+}
+
+func (l *loader) proxyTypes(days int) (*service.MostProxyTypeResult, error) {
+	value, err := l.load(fmt.Sprintf("proxytypes:%d", days), func() (interface{}, error) {
+		return l.aged(days).MostProxyTypes()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*service.MostProxyTypeResult), nil
+}
@@ -0,0 +1,356 @@
+package graphqlapi
+
+import (
+	"errors"
+	"net"
+
+	"github.com/graphql-go/graphql"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+)
+
+const (
+	BADIPADDRESS = "Bad IP address"
+	BADCOUNTRY   = "Bad country code"
+	BADMAXAGE    = "Bad maxAgeDays, it must be a number of days from 1"
+	//MAXAGEARGUMENT leaves out the ranges last seen longer ago, for the field and the ones below it
+	MAXAGEARGUMENT = "maxAgeDays"
+	DEFAULTLIMIT   = 50
+	MAXROWS        = 1000
+)
+
+// Sources handed from a resolver to the fields of its type, MaxAgeDays is passed down from the query field
+type ipNode struct {
+	Address string
+	Data    *service.IPData
+	//LastSeen is kept for the ranges left out by their age, their Data is nil
+	LastSeen   string
+	MaxAgeDays int
+}
+
+type countryNode struct {
+	Code       string
+	Name       string
+	MaxAgeDays int
+}
+
+type ispNode struct {
+	Name        string
+	CountryCode string
+	MaxAgeDays  int
+}
+
+type asnNode struct {
+	Number string
+	Name   string
+}
+
+var ipType, countryType, ispType, asnType, proxyTypeStatType, rangeType *graphql.Object
+
+func init() {
+	proxyTypeStatType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "ProxyTypeStat",
+		Fields: graphql.Fields{
+			"proxyType": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*service.MostProxyType).ProxyType, nil
+			}},
+			"total": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*service.MostProxyType).Total, nil
+			}},
+		},
+	})
+
+	rangeType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "IPRange",
+		Fields: graphql.Fields{
+			"ipFrom": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return service.Int2IP(p.Source.(*service.IPDataSimple).IPFrom).String(), nil
+			}},
+			"ipTo": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return service.Int2IP(p.Source.(*service.IPDataSimple).IPTo).String(), nil
+			}},
+			"cityName": &graphql.Field{Type: graphql.String, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*service.IPDataSimple).CityName, nil
+			}},
+		},
+	})
+
+	asnType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "ASN",
+		Fields: graphql.Fields{
+			"number": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*asnNode).Number, nil
+			}},
+			"name": &graphql.Field{Type: graphql.String, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*asnNode).Name, nil
+			}},
+		},
+	})
+
+	//Country and ISP reference each other, so their fields are thunks
+	countryType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Country",
+		Fields: (graphql.FieldsThunk)(func() graphql.Fields {
+			return graphql.Fields{
+				"code": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(*countryNode).Code, nil
+				}},
+				"name":   &graphql.Field{Type: graphql.String, Resolve: resolveCountryName},
+				"total":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: resolveCountryTotal},
+				"isps":   &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(ispType))), Resolve: resolveCountryISPs},
+				"ranges": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(rangeType))), Args: limitArgs(), Resolve: resolveCountryRanges},
+			}
+		}),
+	})
+
+	ispType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "ISP",
+		Fields: (graphql.FieldsThunk)(func() graphql.Fields {
+			return graphql.Fields{
+				"name": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(*ispNode).Name, nil
+				}},
+				"country": &graphql.Field{Type: countryType, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					node := p.Source.(*ispNode)
+					return &countryNode{Code: node.CountryCode, MaxAgeDays: node.MaxAgeDays}, nil
+				}},
+				"peers": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(ispType))), Resolve: resolveISPPeers},
+			}
+		}),
+	})
+
+	ipType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "IP",
+		Fields: graphql.Fields{
+			"address": &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*ipNode).Address, nil
+			}},
+			"found": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*ipNode).Data != nil, nil
+			}},
+			"proxyType":  ipDataField(func(d *service.IPData) string { return d.ProxyType }),
+			"regionName": ipDataField(func(d *service.IPData) string { return d.RegionName }),
+			"cityName":   ipDataField(func(d *service.IPData) string { return d.CityName }),
+			"domain":     ipDataField(func(d *service.IPData) string { return d.Domain }),
+			"usageType":  ipDataField(func(d *service.IPData) string { return d.UsageType }),
+			"lastSeen": &graphql.Field{Type: graphql.String, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				node := p.Source.(*ipNode)
+				if node.Data == nil && node.LastSeen == "" {
+					return nil, nil
+				}
+				return node.LastSeen, nil
+			}},
+			"threat":     ipDataField(func(d *service.IPData) string { return d.Threat }),
+			"provider":   ipDataField(func(d *service.IPData) string { return d.Provider }),
+			"fraudScore": ipDataField(func(d *service.IPData) string { return d.FraudScore }),
+			"country": &graphql.Field{Type: countryType, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				node := p.Source.(*ipNode)
+				if node.Data == nil {
+					return nil, nil
+				}
+				return &countryNode{Code: node.Data.CountryCode, Name: node.Data.CountryName, MaxAgeDays: node.MaxAgeDays}, nil
+			}},
+			"isp": &graphql.Field{Type: ispType, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				node := p.Source.(*ipNode)
+				if node.Data == nil {
+					return nil, nil
+				}
+				return &ispNode{Name: node.Data.ISP, CountryCode: node.Data.CountryCode, MaxAgeDays: node.MaxAgeDays}, nil
+			}},
+			"asn": &graphql.Field{Type: asnType, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				data := p.Source.(*ipNode).Data
+				if data == nil {
+					return nil, nil
+				}
+				return &asnNode{Number: data.ASN, Name: data.AS}, nil
+			}},
+		},
+	})
+}
+
+// NewSchema builds the query schema, resolvers reach the service through the request loader
+func NewSchema() (graphql.Schema, error) {
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"ip": &graphql.Field{
+					Type: graphql.NewNonNull(ipType),
+					Args: graphql.FieldConfigArgument{
+						"address":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+						MAXAGEARGUMENT: &graphql.ArgumentConfig{Type: graphql.Int},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						days, err := maxAgeDays(p)
+						if err != nil {
+							return nil, err
+						}
+						return resolveIP(p, p.Args["address"].(string), days)
+					},
+				},
+				"ips": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(ipType))),
+					Args: graphql.FieldConfigArgument{
+						ADDRESSESFIELD: &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+						MAXAGEARGUMENT: &graphql.ArgumentConfig{Type: graphql.Int},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						days, err := maxAgeDays(p)
+						if err != nil {
+							return nil, err
+						}
+						nodes := []*ipNode{}
+						for _, address := range p.Args[ADDRESSESFIELD].([]interface{}) {
+							node, err := resolveIP(p, address.(string), days)
+							if err != nil {
+								return nil, err
+							}
+							nodes = append(nodes, node)
+						}
+						return nodes, nil
+					},
+				},
+				"country": &graphql.Field{
+					Type: graphql.NewNonNull(countryType),
+					Args: graphql.FieldConfigArgument{
+						"code":         &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+						MAXAGEARGUMENT: &graphql.ArgumentConfig{Type: graphql.Int},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						code := p.Args["code"].(string)
+						if len(code) != 2 || code[0] < 'A' || code[0] > 'Z' || code[1] < 'A' || code[1] > 'Z' {
+							return nil, errors.New(BADCOUNTRY)
+						}
+						days, err := maxAgeDays(p)
+						if err != nil {
+							return nil, err
+						}
+						return &countryNode{Code: code, MaxAgeDays: days}, nil
+					},
+				},
+				"proxyTypes": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(proxyTypeStatType))),
+					Args: graphql.FieldConfigArgument{
+						MAXAGEARGUMENT: &graphql.ArgumentConfig{Type: graphql.Int},
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						days, err := maxAgeDays(p)
+						if err != nil {
+							return nil, err
+						}
+						result, err := loaderFrom(p.Context).proxyTypes(days)
+						if err != nil {
+							return nil, err
+						}
+						return result.ProxyTypeList, nil
+					},
+				},
+			},
+		}),
+	})
+}
+
+func resolveIP(p graphql.ResolveParams, address string, days int) (*ipNode, error) {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return nil, errors.New(BADIPADDRESS)
+	}
+	data, lastSeen, err := loaderFrom(p.Context).ipInfo(ip, days)
+	if err != nil {
+		return nil, err
+	}
+	return &ipNode{Address: ip.String(), Data: data, LastSeen: lastSeen, MaxAgeDays: days}, nil
+}
+
+// maxAgeDays reads the maxAgeDays argument, 0 when it is missing
+func maxAgeDays(p graphql.ResolveParams) (int, error) {
+	days, ok := p.Args[MAXAGEARGUMENT].(int)
+	if !ok {
+		return 0, nil
+	}
+	if days < 1 {
+		return 0, errors.New(BADMAXAGE)
+	}
+	return days, nil
+}
+
+// resolveCountryName uses the name from the address data, or the first range of the country
+func resolveCountryName(p graphql.ResolveParams) (interface{}, error) {
+	node := p.Source.(*countryNode)
+	if node.Name != "" {
+		return node.Name, nil
+	}
+	result, err := loaderFrom(p.Context).countryRanges(node.Code, 1, node.MaxAgeDays)
+	if err != nil || len(result.RangeList) == 0 {
+		return nil, err
+	}
+	return result.RangeList[0].CountryName, nil
+}
+
+func resolveCountryTotal(p graphql.ResolveParams) (interface{}, error) {
+	node := p.Source.(*countryNode)
+	result, err := loaderFrom(p.Context).countryTotal(node.Code, node.MaxAgeDays)
+	if err != nil {
+		return nil, err
+	}
+	return result.Total, nil
+}
+
+func resolveCountryISPs(p graphql.ResolveParams) (interface{}, error) {
+	node := p.Source.(*countryNode)
+	return countryISPs(p, node.Code, "", node.MaxAgeDays)
+}
+
+func resolveCountryRanges(p graphql.ResolveParams) (interface{}, error) {
+	limit, _ := p.Args[LIMITARGUMENT].(int)
+	node := p.Source.(*countryNode)
+	result, err := loaderFrom(p.Context).countryRanges(node.Code, rangesLimit(limit), node.MaxAgeDays)
+	if err != nil {
+		return nil, err
+	}
+	return result.RangeList, nil
+}
+
+// rangesLimit is the amount of ranges listed for a limit argument, DEFAULTLIMIT when missing or out of 1 to MAXROWS
+func rangesLimit(limit int) int {
+	if limit <= 0 || limit > MAXROWS {
+		return DEFAULTLIMIT
+	}
+	return limit
+}
+
+// resolveISPPeers lists the other ISPs of the same country
+func resolveISPPeers(p graphql.ResolveParams) (interface{}, error) {
+	node := p.Source.(*ispNode)
+	return countryISPs(p, node.CountryCode, node.Name, node.MaxAgeDays)
+}
+
+func countryISPs(p graphql.ResolveParams, country string, skip string, days int) ([]*ispNode, error) {
+	result, err := loaderFrom(p.Context).countryISPs(country, days)
+	if err != nil {
+		return nil, err
+	}
+	nodes := []*ispNode{}
+	for _, isp := range result.ISPList {
+		if isp.Name != skip {
+			nodes = append(nodes, &ispNode{Name: isp.Name, CountryCode: country, MaxAgeDays: days})
+		}
+	}
+	return nodes, nil
+}
+
+// ipDataField resolves a plain field of the address data, null when not found
+func ipDataField(get func(*service.IPData) string) *graphql.Field {
+	return &graphql.Field{Type: graphql.String, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		data := p.Source.(*ipNode).Data
+		if data == nil {
+			return nil, nil
+		}
+		return get(data), nil
+	}}
+}
+
+func limitArgs() graphql.FieldConfigArgument {
+	return graphql.FieldConfigArgument{
+		LIMITARGUMENT: &graphql.ArgumentConfig{Type: graphql.Int},
+	}
+}
@@ -0,0 +1,229 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"strings"
+
+	"github.com/nullc0rp/go-ip2proxy-api/ip2proxypb"
+	"github.com/nullc0rp/go-ip2proxy-api/render"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	DEFAULTLIMIT = 50
+	MAXROWS      = 1000
+	SERVICENAME  = "ip2proxy.v1.IP2Proxy"
+	BADIPADDRESS = "Bad IP address"
+	BADCOUNTRY   = "Bad country code"
+	SERVICEERROR = "Service error"
+	ERROR        = "Error"
+)
+
+// Server implements the gRPC contract on top of the same service used by the REST controllers
+type Server struct {
+	ip2proxypb.UnimplementedIP2ProxyServer
+	Service service.Service
+}
+
+// NewGRPCServer registers the API and the standard health service.
+// The returned health server should be switched to NOT_SERVING on shutdown.
+func NewGRPCServer(serviceInstance service.Service, creds credentials.TransportCredentials) (*grpc.Server, *health.Server) {
+	options := []grpc.ServerOption{}
+	if creds != nil {
+		options = append(options, grpc.Creds(creds))
+	}
+	grpcServer := grpc.NewServer(options...)
+
+	ip2proxypb.RegisterIP2ProxyServer(grpcServer, &Server{Service: serviceInstance})
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus(SERVICENAME, grpc_health_v1.HealthCheckResponse_SERVING)
+	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
+
+	return grpcServer, healthServer
+}
+
+// Lookup gets the proxy data for a single address
+func (s *Server) Lookup(ctx context.Context, request *ip2proxypb.LookupRequest) (*ip2proxypb.LookupResponse, error) {
+	response, err := s.lookup(request.GetIp(), request.GetFields(), request.GetMaxAgeDays())
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// BatchLookup answers each address on the stream, a failed lookup is reported in the response and the stream goes on
+func (s *Server) BatchLookup(stream ip2proxypb.IP2Proxy_BatchLookupServer) error {
+	for {
+		request, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		response, err := s.lookup(request.GetIp(), request.GetFields(), request.GetMaxAgeDays())
+		if err != nil {
+			response = &ip2proxypb.LookupResponse{
+				Ip:    request.GetIp(),
+				Error: status.Convert(err).Message(),
+			}
+		}
+		if err := stream.Send(response); err != nil {
+			return err
+		}
+	}
+}
+
+// ListCountryRanges streams the ranges of a country
+func (s *Server) ListCountryRanges(request *ip2proxypb.CountryRequest, stream ip2proxypb.IP2Proxy_ListCountryRangesServer) error {
+	country, err := countryCode(request.GetCountryCode())
+	if err != nil {
+		return err
+	}
+
+	// Default limit value
+	limit := int(request.GetLimit())
+	if limit == 0 || limit > MAXROWS {
+		limit = DEFAULTLIMIT
+	}
+
+	result, err := s.scoped(request.GetMaxAgeDays()).GetCountryRanges(country, limit)
+	if err != nil {
+		log.Println(ERROR, err)
+		return status.Error(codes.Internal, SERVICEERROR)
+	}
+
+	for _, r := range result.RangeList {
+		err := stream.Send(&ip2proxypb.IPRange{
+			IpFrom:      service.Int2IP(r.IPFrom).String(),
+			IpTo:        service.Int2IP(r.IPTo).String(),
+			CountryName: r.CountryName,
+			CityName:    r.CityName,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CountryISPs lists the ISP names of a country
+func (s *Server) CountryISPs(ctx context.Context, request *ip2proxypb.CountryRequest) (*ip2proxypb.CountryISPsResponse, error) {
+	country, err := countryCode(request.GetCountryCode())
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.scoped(request.GetMaxAgeDays()).GetISPCountry(country)
+	if err != nil {
+		log.Println(ERROR, err)
+		return nil, status.Error(codes.Internal, SERVICEERROR)
+	}
+
+	response := &ip2proxypb.CountryISPsResponse{Total: int64(result.Total)}
+	for _, isp := range result.ISPList {
+		response.Isps = append(response.Isps, isp.Name)
+	}
+	return response, nil
+}
+
+// CountryTotal counts the addresses of a country
+func (s *Server) CountryTotal(ctx context.Context, request *ip2proxypb.CountryRequest) (*ip2proxypb.CountryTotalResponse, error) {
+	country, err := countryCode(request.GetCountryCode())
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.scoped(request.GetMaxAgeDays()).GetCountryTotal(country)
+	if err != nil {
+		log.Println(ERROR, err)
+		return nil, status.Error(codes.Internal, SERVICEERROR)
+	}
+
+	return &ip2proxypb.CountryTotalResponse{Total: int64(result.Total)}, nil
+}
+
+// ProxyTypeStats gets the most common proxy types
+func (s *Server) ProxyTypeStats(ctx context.Context, request *ip2proxypb.ProxyTypeStatsRequest) (*ip2proxypb.ProxyTypeStatsResponse, error) {
+	result, err := s.scoped(request.GetMaxAgeDays()).MostProxyTypes()
+	if err != nil {
+		log.Println(ERROR, err)
+		return nil, status.Error(codes.Internal, SERVICEERROR)
+	}
+
+	response := &ip2proxypb.ProxyTypeStatsResponse{}
+	for _, proxyType := range result.ProxyTypeList {
+		response.ProxyTypes = append(response.ProxyTypes, &ip2proxypb.ProxyTypeStat{
+			ProxyType: proxyType.ProxyType,
+			Total:     int64(proxyType.Total),
+		})
+	}
+	return response, nil
+}
+
+// lookup validates the address and maps the service result, an address not in the dataset is not an error
+func (s *Server) lookup(address string, fields []string, maxAgeDays uint32) (*ip2proxypb.LookupResponse, error) {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return nil, status.Error(codes.InvalidArgument, BADIPADDRESS)
+	}
+
+	//Only the selected columns are looked up, the other fields are left empty
+	var result *service.IPData
+	var err error
+	if len(fields) == 0 {
+		result, err = s.scoped(maxAgeDays).GetIPInfo(ip)
+	} else {
+		fields, err = service.ParseFields(strings.Join(fields, ","))
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		result, err = s.scoped(maxAgeDays).GetIPInfoFields(ip, fields)
+	}
+	var stale *service.StaleError
+	if errors.As(err, &stale) {
+		return &ip2proxypb.LookupResponse{Ip: ip.String(), Data: &ip2proxypb.IPData{LastSeen: stale.LastSeen}}, nil
+	}
+	if service.IsNoResult(err) {
+		return &ip2proxypb.LookupResponse{Ip: ip.String()}, nil
+	}
+	if err != nil {
+		log.Println(ERROR, ip, err)
+		return nil, status.Error(codes.Internal, SERVICEERROR)
+	}
+
+	return &ip2proxypb.LookupResponse{
+		Ip:    ip.String(),
+		Found: true,
+		Data:  render.ProtoIPData(result),
+	}, nil
+}
+
+// scoped is the service without the ranges last seen longer ago than maxAgeDays, zero keeps all of them
+func (s *Server) scoped(maxAgeDays uint32) service.Service {
+	if maxAgeDays == 0 {
+		return s.Service
+	}
+	return s.Service.MaxAge(int(maxAgeDays))
+}
+
+// countryCode validates the country the same way the REST controllers filter it
+func countryCode(input string) (string, error) {
+	if len(input) != 2 || input[0] < 'A' || input[0] > 'Z' || input[1] < 'A' || input[1] > 'Z' {
+		return "", status.Error(codes.InvalidArgument, BADCOUNTRY)
+	}
+	return input, nil
+}
@@ -0,0 +1,188 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nullc0rp/go-ip2proxy-api/ip2proxypb"
+	"github.com/nullc0rp/go-ip2proxy-api/mocks"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dial starts the server on an in-memory listener
+func dial(t *testing.T, serviceInstance service.Service) *grpc.ClientConn {
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer, _ := NewGRPCServer(serviceInstance, nil)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when dialing", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestLookup(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	mockService.EXPECT().GetIPInfo(net.ParseIP("10.10.10.1")).Return(&service.IPData{ProxyType: "PUB", ISP: "Opera"}, nil)
+	mockService.EXPECT().GetIPInfo(net.ParseIP("10.10.10.2")).Return(nil, service.NoResultError(service.CHECKDATA))
+
+	client := ip2proxypb.NewIP2ProxyClient(dial(t, mockService))
+
+	response, err := client.Lookup(context.Background(), &ip2proxypb.LookupRequest{Ip: "10.10.10.1"})
+	assert.Nil(t, err)
+	assert.True(t, response.Found)
+	assert.Equal(t, "Opera", response.Data.Isp)
+
+	response, err = client.Lookup(context.Background(), &ip2proxypb.LookupRequest{Ip: "10.10.10.2"})
+	assert.Nil(t, err)
+	assert.False(t, response.Found)
+
+	_, err = client.Lookup(context.Background(), &ip2proxypb.LookupRequest{Ip: "10.10.10.asd"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestLookupMaxAge(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	//The service is scoped to the age asked for
+	mockService.EXPECT().MaxAge(30).Return(mockService)
+	mockService.EXPECT().GetIPInfo(net.ParseIP("10.10.10.1")).Return(nil, &service.StaleError{LastSeen: "45"})
+
+	client := ip2proxypb.NewIP2ProxyClient(dial(t, mockService))
+
+	response, err := client.Lookup(context.Background(), &ip2proxypb.LookupRequest{Ip: "10.10.10.1", MaxAgeDays: 30})
+	assert.Nil(t, err)
+	assert.False(t, response.Found)
+	assert.Equal(t, "45", response.Data.LastSeen)
+}
+
+func TestBatchLookup(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	mockService.EXPECT().GetIPInfo(net.ParseIP("10.10.10.1")).Return(&service.IPData{ProxyType: "PUB"}, nil)
+	mockService.EXPECT().GetIPInfo(net.ParseIP("10.10.10.3")).Return(nil, errors.New("some dirty info"))
+
+	client := ip2proxypb.NewIP2ProxyClient(dial(t, mockService))
+	stream, err := client.BatchLookup(context.Background())
+	assert.Nil(t, err)
+
+	for _, ip := range []string{"10.10.10.1", "nope", "10.10.10.3"} {
+		stream.Send(&ip2proxypb.LookupRequest{Ip: ip})
+	}
+	stream.CloseSend()
+
+	responses := []*ip2proxypb.LookupResponse{}
+	for {
+		response, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		responses = append(responses, response)
+	}
+
+	assert.Equal(t, 3, len(responses))
+	assert.Equal(t, "PUB", responses[0].Data.ProxyType)
+	assert.Equal(t, BADIPADDRESS, responses[1].Error)
+	assert.Equal(t, SERVICEERROR, responses[2].Error)
+}
+
+func TestBatchLookupFields(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	mockService.EXPECT().GetIPInfoFields(net.ParseIP("10.10.10.1"), []string{"proxy_type", "country_code"}).Return(&service.IPData{ProxyType: "PUB", CountryCode: "PL"}, nil)
+
+	client := ip2proxypb.NewIP2ProxyClient(dial(t, mockService))
+	stream, err := client.BatchLookup(context.Background())
+	assert.Nil(t, err)
+
+	stream.Send(&ip2proxypb.LookupRequest{Ip: "10.10.10.1", Fields: []string{"proxy_type", "country_code"}})
+	stream.Send(&ip2proxypb.LookupRequest{Ip: "10.10.10.1", Fields: []string{"color"}})
+	stream.CloseSend()
+
+	response, err := stream.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, "PL", response.Data.CountryCode)
+
+	//Unknown fields fail that lookup only
+	response, err = stream.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, "Unknown field color", response.Error)
+}
+
+func TestListCountryRanges(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	mockService.EXPECT().GetCountryRanges("AU", DEFAULTLIMIT).Return(&service.IPRangeCountryData{
+		Total:     1,
+		RangeList: []*service.IPDataSimple{{IPFrom: 16778241, IPTo: 16778249, CountryName: "Australia"}},
+	}, nil)
+
+	client := ip2proxypb.NewIP2ProxyClient(dial(t, mockService))
+	stream, err := client.ListCountryRanges(context.Background(), &ip2proxypb.CountryRequest{CountryCode: "AU"})
+	assert.Nil(t, err)
+
+	r, err := stream.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, "1.0.4.1", r.IpFrom)
+	assert.Equal(t, "1.0.4.9", r.IpTo)
+
+	_, err = stream.Recv()
+	assert.Equal(t, io.EOF, err)
+
+	_, err = client.CountryTotal(context.Background(), &ip2proxypb.CountryRequest{CountryCode: "A'"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestHealth(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	client := grpc_health_v1.NewHealthClient(dial(t, mocks.NewMockService(controller)))
+
+	response, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: SERVICENAME})
+
+	assert.Nil(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, response.Status)
+}
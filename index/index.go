@@ -0,0 +1,331 @@
+package index
+
+//Binary index of a dataset, looked up in place so a new instance is ready as soon as the file is mapped.
+//
+//Layout, every number little endian:
+//	header    magic "IP2PXIDX", format, fields bitmask, ranges, strings, version string, CRC-32C of the body, body size
+//	offsets   strings+1 uint32, where each string starts in the string data and where the last one ends
+//	strings   the deduplicated strings of every range, "" is always string 0
+//	padding   up to 4 bytes
+//	ranges    ip_from, ip_to and a string per field of the bitmask, uint32 each, sorted by ip_from
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+)
+
+const (
+	MAGIC = "IP2PXIDX"
+	//FORMATVERSION changes with the layout, older files are refused
+	FORMATVERSION = 1
+	HEADERSIZE    = 40
+	BADORDER      = "Range %d-%d is not after the previous one, ending at %d"
+	BADINDEX      = "Bad index %s: %s"
+	BADVERSION    = "The index is of the dataset %q, the database has %q"
+	BADFIELDS     = "The index has the fields %v, the database has %v"
+)
+
+// crc32c is the table of the checksum, computed by the CPU on most platforms
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+type header struct {
+	Magic    [8]byte
+	Format   uint32
+	Fields   uint32
+	Ranges   uint32
+	Strings  uint32
+	Version  uint32
+	Checksum uint32
+	BodySize uint64
+}
+
+// Builder collects the ranges of an index, in order
+type Builder struct {
+	fields []string
+	//version is the string of the dataset version
+	version uint32
+	ids     map[string]uint32
+	strings []string
+	records []uint32
+	last    uint32
+	count   int
+}
+
+// NewBuilder starts an index of the fields of a package tier, for the dataset version
+func NewBuilder(fields []string, version string) *Builder {
+	b := &Builder{ids: map[string]uint32{}}
+	for _, field := range service.IPDATAFIELDS {
+		if contains(fields, field) {
+			b.fields = append(b.fields, field)
+		}
+	}
+	b.id("")
+	b.version = b.id(version)
+	return b
+}
+
+// Add appends a range, after the previous one
+func (b *Builder) Add(from uint32, to uint32, data *service.IPData) error {
+	if (b.count > 0 && from <= b.last) || to < from {
+		return fmt.Errorf(BADORDER, from, to, b.last)
+	}
+	b.records = append(b.records, from, to)
+	for _, field := range b.fields {
+		b.records = append(b.records, b.id(*data.FieldPointer(field)))
+	}
+	b.last = to
+	b.count++
+	return nil
+}
+
+// id is the number of a string in the table, added on first use
+func (b *Builder) id(value string) uint32 {
+	id, ok := b.ids[value]
+	if !ok {
+		id = uint32(len(b.strings))
+		b.ids[value] = id
+		b.strings = append(b.strings, value)
+	}
+	return id
+}
+
+// WriteTo writes the index
+func (b *Builder) WriteTo(w io.Writer) (int64, error) {
+	var body bytes.Buffer
+
+	//String offsets and data
+	offset := uint32(0)
+	for _, value := range b.strings {
+		binary.Write(&body, binary.LittleEndian, offset)
+		offset += uint32(len(value))
+	}
+	binary.Write(&body, binary.LittleEndian, offset)
+	for _, value := range b.strings {
+		body.WriteString(value)
+	}
+	for body.Len()%4 != 0 {
+		body.WriteByte(0)
+	}
+
+	binary.Write(&body, binary.LittleEndian, b.records)
+
+	head := header{
+		Format:   FORMATVERSION,
+		Fields:   mask(b.fields),
+		Ranges:   uint32(b.count),
+		Strings:  uint32(len(b.strings)),
+		Version:  b.version,
+		Checksum: crc32.Checksum(body.Bytes(), crc32c),
+		BodySize: uint64(body.Len()),
+	}
+	copy(head.Magic[:], MAGIC)
+
+	if err := binary.Write(w, binary.LittleEndian, head); err != nil {
+		return 0, err
+	}
+	written, err := body.WriteTo(w)
+	return HEADERSIZE + written, err
+}
+
+// WriteFile writes the index next to the path and moves it there, servers opening the path never see half a file
+func (b *Builder) WriteFile(path string) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := b.WriteTo(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// Index is an index file mapped in memory
+type Index struct {
+	data    []byte
+	head    header
+	fields  []string
+	offsets []byte
+	strings []byte
+	ranges  []byte
+	//record is the size of a range
+	record int
+	//stale is set once the database moved to another dataset
+	stale atomic.Bool
+}
+
+// Open maps an index file, checking its format and checksum before it is used
+func Open(path string) (*Index, error) {
+	data, err := mmap(path)
+	if err != nil {
+		return nil, err
+	}
+	index, err := parse(data)
+	if err != nil {
+		munmap(data)
+		return nil, fmt.Errorf(BADINDEX, path, err)
+	}
+	return index, nil
+}
+
+// parse checks the header, the checksum and every string reference of the ranges, so lookups can't read out of bounds
+func parse(data []byte) (*Index, error) {
+	if len(data) < HEADERSIZE {
+		return nil, errors.New("too short")
+	}
+	index := &Index{data: data}
+	binary.Read(bytes.NewReader(data[:HEADERSIZE]), binary.LittleEndian, &index.head)
+	head := index.head
+	body := data[HEADERSIZE:]
+
+	switch {
+	case string(head.Magic[:]) != MAGIC:
+		return nil, errors.New("not an index")
+	case head.Format != FORMATVERSION:
+		return nil, fmt.Errorf("format %d, expected %d", head.Format, FORMATVERSION)
+	case head.BodySize != uint64(len(body)):
+		return nil, fmt.Errorf("%d bytes, expected %d", len(body), head.BodySize)
+	case crc32.Checksum(body, crc32c) != head.Checksum:
+		return nil, errors.New("checksum mismatch")
+	}
+
+	for i, field := range service.IPDATAFIELDS {
+		if head.Fields&(1<<i) != 0 {
+			index.fields = append(index.fields, field)
+		}
+	}
+	index.record = 4 * (2 + len(index.fields))
+
+	//Sections
+	offsetsSize := 4 * (uint64(head.Strings) + 1)
+	if offsetsSize > uint64(len(body)) || head.Strings == 0 || head.Version >= head.Strings {
+		return nil, errors.New("bad string table")
+	}
+	index.offsets = body[:offsetsSize]
+	stringsSize := uint64(binary.LittleEndian.Uint32(index.offsets[offsetsSize-4:]))
+	rangesStart := (offsetsSize + stringsSize + 3) &^ 3
+	if rangesStart+uint64(head.Ranges)*uint64(index.record) != uint64(len(body)) {
+		return nil, errors.New("bad sections")
+	}
+	index.strings = body[offsetsSize : offsetsSize+stringsSize]
+	index.ranges = body[rangesStart:]
+
+	//Every string is inside the string data, offsets[0] <= offsets[1] <= ... <= len(strings). The last offset is its size
+	previous := binary.LittleEndian.Uint32(index.offsets)
+	for i := uint32(1); i <= head.Strings; i++ {
+		end := binary.LittleEndian.Uint32(index.offsets[4*i:])
+		if end < previous || uint64(end) > stringsSize {
+			return nil, errors.New("bad string table")
+		}
+		previous = end
+	}
+	for i := 0; i < int(head.Ranges); i++ {
+		record := index.ranges[i*index.record : (i+1)*index.record]
+		if i > 0 && binary.LittleEndian.Uint32(record) <= binary.LittleEndian.Uint32(index.ranges[i*index.record-index.record+4:]) {
+			return nil, errors.New("ranges out of order")
+		}
+		for field := range index.fields {
+			if binary.LittleEndian.Uint32(record[8+4*field:]) >= head.Strings {
+				return nil, errors.New("bad string reference")
+			}
+		}
+	}
+	return index, nil
+}
+
+// Close unmaps the file, the index can't be used anymore
+func (i *Index) Close() error {
+	return munmap(i.data)
+}
+
+// Version is the version of the dataset the index was built from
+func (i *Index) Version() string {
+	return i.string(i.head.Version)
+}
+
+// Fields are the IPData fields of the package tier
+func (i *Index) Fields() []string {
+	return i.fields
+}
+
+// Len is the amount of ranges
+func (i *Index) Len() int {
+	return int(i.head.Ranges)
+}
+
+// Check refuses an index of another dataset than the one of the database, or of another package tier than its columns
+func (i *Index) Check(version string, fields []string) error {
+	if i.Version() != version {
+		return fmt.Errorf(BADVERSION, i.Version(), version)
+	}
+	if i.head.Fields != mask(fields) {
+		return fmt.Errorf(BADFIELDS, i.fields, fields)
+	}
+	return nil
+}
+
+// Find looks up the range holding the address
+func (i *Index) Find(ip uint32) (*service.IPDataRange, bool) {
+	count := int(i.head.Ranges)
+	//First range starting after the address, the one before may hold it
+	after := sort.Search(count, func(n int) bool {
+		return binary.LittleEndian.Uint32(i.ranges[n*i.record:]) > ip
+	})
+	if after == 0 {
+		return nil, false
+	}
+	record := i.ranges[(after-1)*i.record : after*i.record]
+	found := &service.IPDataRange{
+		IPFrom: binary.LittleEndian.Uint32(record),
+		IPTo:   binary.LittleEndian.Uint32(record[4:]),
+		Data:   &service.IPData{},
+	}
+	if ip > found.IPTo {
+		return nil, false
+	}
+	for n, field := range i.fields {
+		*found.Data.FieldPointer(field) = i.string(binary.LittleEndian.Uint32(record[8+4*n:]))
+	}
+	return found, true
+}
+
+// string copies a string out of the table, the mapping goes away on Close
+func (i *Index) string(id uint32) string {
+	start := binary.LittleEndian.Uint32(i.offsets[4*id:])
+	end := binary.LittleEndian.Uint32(i.offsets[4*(id+1):])
+	return string(i.strings[start:end])
+}
+
+func mask(fields []string) uint32 {
+	var mask uint32
+	for i, field := range service.IPDATAFIELDS {
+		if contains(fields, field) {
+			mask |= 1 << i
+		}
+	}
+	return mask
+}
+
+func contains(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
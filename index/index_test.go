@@ -0,0 +1,196 @@
+package index
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nullc0rp/go-ip2proxy-api/mocks"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+	"github.com/stretchr/testify/assert"
+)
+
+const VERSION = "2024-01-01 00:00:00"
+
+// FIELDS are the ones of PX4 and the last seen days
+var FIELDS = []string{"proxy_type", "country_code", "country_name", "region_name", "last_seen"}
+
+// build writes an index of two ranges, 10.10.10.0/24 and 10.10.12.0/24, with the same country
+func build(t *testing.T) string {
+	builder := NewBuilder(FIELDS, VERSION)
+	assert.Nil(t, builder.Add(168430080, 168430335, &service.IPData{ProxyType: "PUB", CountryCode: "PL", CountryName: "Poland", RegionName: "Mazowieckie"}))
+	assert.Nil(t, builder.Add(168430592, 168430847, &service.IPData{ProxyType: "VPN", CountryCode: "PL", CountryName: "Poland", LastSeen: "45", ISP: "Opera"}))
+
+	path := filepath.Join(t.TempDir(), "ip2proxy.idx")
+	assert.Nil(t, builder.WriteFile(path))
+	return path
+}
+
+func TestRoundTrip(t *testing.T) {
+
+	ix, err := Open(build(t))
+	assert.Nil(t, err)
+	defer ix.Close()
+
+	assert.Equal(t, VERSION, ix.Version())
+	assert.Equal(t, FIELDS, ix.Fields())
+	assert.Equal(t, 2, ix.Len())
+	assert.Nil(t, ix.Check(VERSION, FIELDS))
+	assert.Equal(t, `The index is of the dataset "2024-01-01 00:00:00", the database has "2024-02-01 00:00:00"`, ix.Check("2024-02-01 00:00:00", FIELDS).Error())
+	assert.Equal(t, "The index has the fields [proxy_type country_code country_name region_name last_seen], the database has [proxy_type country_code]", ix.Check(VERSION, FIELDS[:2]).Error())
+
+	found, ok := ix.Find(168430081)
+	assert.True(t, ok)
+	assert.Equal(t, &service.IPDataRange{IPFrom: 168430080, IPTo: 168430335, Data: &service.IPData{ProxyType: "PUB", CountryCode: "PL", CountryName: "Poland", RegionName: "Mazowieckie"}}, found)
+
+	//Only the fields of the tier are kept
+	found, ok = ix.Find(168430847)
+	assert.True(t, ok)
+	assert.Equal(t, &service.IPData{ProxyType: "VPN", CountryCode: "PL", CountryName: "Poland", LastSeen: "45"}, found.Data)
+
+	//Before, between and after the ranges
+	for _, ip := range []uint32{0, 168430079, 168430336, 168430591, 168430848, 4294967295} {
+		_, ok = ix.Find(ip)
+		assert.False(t, ok, ip)
+	}
+}
+
+func TestStringsDeduplicated(t *testing.T) {
+
+	builder := NewBuilder([]string{"country_code"}, VERSION)
+	for i := uint32(0); i < 100; i++ {
+		builder.Add(i*10, i*10+4, &service.IPData{CountryCode: "PL"})
+	}
+	var out bytes.Buffer
+	_, err := builder.WriteTo(&out)
+	assert.Nil(t, err)
+
+	//Header, 4 offsets, "" "PL" and the version padded, 100 ranges of 3 numbers
+	assert.Equal(t, HEADERSIZE+16+24+100*12, out.Len())
+}
+
+func TestOutOfOrderRefused(t *testing.T) {
+
+	builder := NewBuilder([]string{"proxy_type"}, VERSION)
+	assert.Nil(t, builder.Add(10, 19, &service.IPData{}))
+	assert.Equal(t, "Range 15-30 is not after the previous one, ending at 19", builder.Add(15, 30, &service.IPData{}).Error())
+	assert.NotNil(t, builder.Add(40, 39, &service.IPData{}))
+}
+
+func TestCorruptionRefused(t *testing.T) {
+
+	path := build(t)
+	original, _ := os.ReadFile(path)
+
+	for name, corrupt := range map[string]func(data []byte) []byte{
+		"not an index":      func(data []byte) []byte { data[0] = 'X'; return data },
+		"format 2":          func(data []byte) []byte { data[8] = 2; return data },
+		"checksum mismatch": func(data []byte) []byte { data[len(data)-1] ^= 1; return data },
+		"bytes, expected":   func(data []byte) []byte { return data[:len(data)-4] },
+		"too short":         func(data []byte) []byte { return data[:10] },
+		"bad string table":  func(data []byte) []byte { return checksummed(data, func(body []byte) { body[0] = 0xff }) },
+	} {
+		data := corrupt(append([]byte{}, original...))
+		os.WriteFile(path, data, 0644)
+
+		_, err := Open(path)
+		assert.NotNil(t, err, name)
+		if err != nil {
+			assert.Contains(t, err.Error(), name)
+		}
+	}
+}
+
+// checksummed changes the body of an index and fixes its checksum, so the damage is found by the other checks
+func checksummed(data []byte, change func(body []byte)) []byte {
+	change(data[HEADERSIZE:])
+	binary.LittleEndian.PutUint32(data[28:], crc32.Checksum(data[HEADERSIZE:], crc32c))
+	return data
+}
+
+func TestService(t *testing.T) {
+
+	ix, _ := Open(build(t))
+	defer ix.Close()
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+	scoped := mocks.NewMockService(controller)
+
+	mockService.EXPECT().MaxAge(30).Return(scoped).Times(2)
+	mockService.EXPECT().GetCountryTotal("PL").Return(&service.IPCountryTotal{Total: 512}, nil).Times(1)
+
+	svc := ix.Service(mockService)
+
+	//Lookups don't reach the database
+	data, err := svc.GetIPInfo(net.ParseIP("10.10.10.1"))
+	assert.Nil(t, err)
+	assert.Equal(t, "Mazowieckie", data.RegionName)
+
+	data, err = svc.GetIPInfoFields(net.ParseIP("10.10.12.1"), []string{"proxy_type"})
+	assert.Nil(t, err)
+	assert.Equal(t, &service.IPData{ProxyType: "VPN"}, data)
+
+	_, err = svc.GetIPInfoFields(net.ParseIP("10.10.12.1"), []string{"nope"})
+	var badField *service.BadField
+	assert.True(t, errors.As(err, &badField))
+
+	found, err := svc.GetIPInfoRange(net.ParseIP("10.10.12.1"), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(168430592), found.IPFrom)
+	assert.Equal(t, "VPN", found.Data.ProxyType)
+
+	found, err = svc.GetIPInfoRange(net.ParseIP("10.10.12.1"), []string{"country_code"})
+	assert.Nil(t, err)
+	assert.Equal(t, &service.IPDataRange{IPFrom: 168430592, IPTo: 168430847, Data: &service.IPData{CountryCode: "PL"}}, found)
+
+	_, err = svc.GetIPInfo(net.ParseIP("10.10.11.1"))
+	assert.True(t, service.IsNoResult(err))
+
+	//The second range wasn't seen in the last 30 days
+	_, err = svc.MaxAge(30).GetIPInfo(net.ParseIP("10.10.12.1"))
+	var stale *service.StaleError
+	assert.True(t, errors.As(err, &stale))
+	_, err = svc.MaxAge(30).GetIPInfoRange(net.ParseIP("10.10.12.1"), []string{"proxy_type"})
+	assert.True(t, errors.As(err, &stale))
+
+	//Other calls go through
+	total, err := svc.GetCountryTotal("PL")
+	assert.Nil(t, err)
+	assert.Equal(t, 512, total.Total)
+}
+
+func TestServiceStale(t *testing.T) {
+
+	ix, _ := Open(build(t))
+	defer ix.Close()
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	version := VERSION
+	mockService.EXPECT().DatasetVersion().DoAndReturn(func() (string, error) { return version, nil }).Times(2)
+	mockService.EXPECT().GetIPInfo(net.ParseIP("10.10.10.1")).Return(&service.IPData{ProxyType: "TOR"}, nil).Times(1)
+
+	svc := ix.Service(mockService)
+
+	svc.DatasetVersion()
+	data, _ := svc.GetIPInfo(net.ParseIP("10.10.10.1"))
+	assert.Equal(t, "PUB", data.ProxyType)
+
+	//A new dataset was imported, the index is left aside
+	version = "2024-02-01 00:00:00"
+	svc.DatasetVersion()
+	data, _ = svc.GetIPInfo(net.ParseIP("10.10.10.1"))
+	assert.Equal(t, "TOR", data.ProxyType)
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package index
+
+import "os"
+
+// mmap reads the whole file where memory mapping is not available
+func mmap(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+func munmap(data []byte) error {
+	return nil
+}
@@ -0,0 +1,105 @@
+package index
+
+import (
+	"context"
+	"log/slog"
+	"net"
+
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+)
+
+const INDEXSTALE = "Dataset version changed, lookups go to the database until the index is rebuilt"
+
+// Service answers the address lookups of the wrapped service from the index, the other calls go through.
+// Once the wrapped service reports another dataset version the index is left aside and every lookup goes through as well.
+func (i *Index) Service(next service.Service) service.Service {
+	return &indexed{Service: next, index: i}
+}
+
+// indexed is the service seen through the index, scoped services keep their max age
+type indexed struct {
+	service.Service
+	index      *Index
+	maxAgeDays int
+}
+
+func (s *indexed) GetIPInfo(ip net.IP) (*service.IPData, error) {
+	if s.index.stale.Load() {
+		return s.Service.GetIPInfo(ip)
+	}
+	found, err := s.find(ip)
+	if err != nil {
+		return nil, err
+	}
+	return found.Data, nil
+}
+
+func (s *indexed) GetIPInfoFields(ip net.IP, fields []string) (*service.IPData, error) {
+	if s.index.stale.Load() {
+		return s.Service.GetIPInfoFields(ip, fields)
+	}
+	if err := badField(fields); err != nil {
+		return nil, err
+	}
+	found, err := s.find(ip)
+	if err != nil {
+		return nil, err
+	}
+	return found.Data.Select(fields), nil
+}
+
+func (s *indexed) GetIPInfoRange(ip net.IP, fields []string) (*service.IPDataRange, error) {
+	if s.index.stale.Load() {
+		return s.Service.GetIPInfoRange(ip, fields)
+	}
+	if err := badField(fields); err != nil {
+		return nil, err
+	}
+	found, err := s.find(ip)
+	if err != nil {
+		return nil, err
+	}
+	if fields != nil {
+		found.Data = found.Data.Select(fields)
+	}
+	return found, nil
+}
+
+// DatasetVersion is the version of the wrapped service, the index is left aside the first time it differs
+func (s *indexed) DatasetVersion() (string, error) {
+	version, err := s.Service.DatasetVersion()
+	if err == nil && version != s.index.Version() && s.index.stale.CompareAndSwap(false, true) {
+		slog.Warn(INDEXSTALE, "index", s.index.Version(), "database", version)
+	}
+	return version, err
+}
+
+func (s *indexed) MaxAge(days int) service.Service {
+	return &indexed{Service: s.Service.MaxAge(days), index: s.index, maxAgeDays: days}
+}
+
+func (s *indexed) WithContext(ctx context.Context) service.Service {
+	return &indexed{Service: s.Service.WithContext(ctx), index: s.index, maxAgeDays: s.maxAgeDays}
+}
+
+// find looks the address up, ranges last seen longer ago than the max age are not proxies
+func (s *indexed) find(ip net.IP) (*service.IPDataRange, error) {
+	found, ok := s.index.Find(service.IP2int(ip))
+	if !ok {
+		return nil, service.NoResultError(service.CHECKDATA)
+	}
+	if service.Stale(found.Data.LastSeen, s.maxAgeDays) {
+		return nil, &service.StaleError{LastSeen: found.Data.LastSeen}
+	}
+	return found, nil
+}
+
+// badField refuses the fields IPData doesn't have, like the database service
+func badField(fields []string) error {
+	for _, field := range fields {
+		if (&service.IPData{}).FieldPointer(field) == nil {
+			return &service.BadField{Field: field}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,4 @@
+// Package ip2proxypb holds the gRPC contract, the .pb.go files are generated from ip2proxy.proto
+package ip2proxypb
+
+//go:generate protoc -I .. --go_out=.. --go_opt=paths=source_relative --go-grpc_out=.. --go-grpc_opt=paths=source_relative ip2proxypb/ip2proxy.proto
@@ -0,0 +1,309 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.27.3
+// source: ip2proxypb/ip2proxy.proto
+
+package ip2proxypb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	IP2Proxy_Lookup_FullMethodName            = "/ip2proxy.v1.IP2Proxy/Lookup"
+	IP2Proxy_BatchLookup_FullMethodName       = "/ip2proxy.v1.IP2Proxy/BatchLookup"
+	IP2Proxy_ListCountryRanges_FullMethodName = "/ip2proxy.v1.IP2Proxy/ListCountryRanges"
+	IP2Proxy_CountryISPs_FullMethodName       = "/ip2proxy.v1.IP2Proxy/CountryISPs"
+	IP2Proxy_CountryTotal_FullMethodName      = "/ip2proxy.v1.IP2Proxy/CountryTotal"
+	IP2Proxy_ProxyTypeStats_FullMethodName    = "/ip2proxy.v1.IP2Proxy/ProxyTypeStats"
+)
+
+// IP2ProxyClient is the client API for IP2Proxy service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type IP2ProxyClient interface {
+	Lookup(ctx context.Context, in *LookupRequest, opts ...grpc.CallOption) (*LookupResponse, error)
+	BatchLookup(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[LookupRequest, LookupResponse], error)
+	ListCountryRanges(ctx context.Context, in *CountryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[IPRange], error)
+	CountryISPs(ctx context.Context, in *CountryRequest, opts ...grpc.CallOption) (*CountryISPsResponse, error)
+	CountryTotal(ctx context.Context, in *CountryRequest, opts ...grpc.CallOption) (*CountryTotalResponse, error)
+	ProxyTypeStats(ctx context.Context, in *ProxyTypeStatsRequest, opts ...grpc.CallOption) (*ProxyTypeStatsResponse, error)
+}
+
+type iP2ProxyClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIP2ProxyClient(cc grpc.ClientConnInterface) IP2ProxyClient {
+	return &iP2ProxyClient{cc}
+}
+
+func (c *iP2ProxyClient) Lookup(ctx context.Context, in *LookupRequest, opts ...grpc.CallOption) (*LookupResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LookupResponse)
+	err := c.cc.Invoke(ctx, IP2Proxy_Lookup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *iP2ProxyClient) BatchLookup(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[LookupRequest, LookupResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &IP2Proxy_ServiceDesc.Streams[0], IP2Proxy_BatchLookup_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[LookupRequest, LookupResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type IP2Proxy_BatchLookupClient = grpc.BidiStreamingClient[LookupRequest, LookupResponse]
+
+func (c *iP2ProxyClient) ListCountryRanges(ctx context.Context, in *CountryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[IPRange], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &IP2Proxy_ServiceDesc.Streams[1], IP2Proxy_ListCountryRanges_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[CountryRequest, IPRange]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type IP2Proxy_ListCountryRangesClient = grpc.ServerStreamingClient[IPRange]
+
+func (c *iP2ProxyClient) CountryISPs(ctx context.Context, in *CountryRequest, opts ...grpc.CallOption) (*CountryISPsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CountryISPsResponse)
+	err := c.cc.Invoke(ctx, IP2Proxy_CountryISPs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *iP2ProxyClient) CountryTotal(ctx context.Context, in *CountryRequest, opts ...grpc.CallOption) (*CountryTotalResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CountryTotalResponse)
+	err := c.cc.Invoke(ctx, IP2Proxy_CountryTotal_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *iP2ProxyClient) ProxyTypeStats(ctx context.Context, in *ProxyTypeStatsRequest, opts ...grpc.CallOption) (*ProxyTypeStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProxyTypeStatsResponse)
+	err := c.cc.Invoke(ctx, IP2Proxy_ProxyTypeStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// IP2ProxyServer is the server API for IP2Proxy service.
+// All implementations must embed UnimplementedIP2ProxyServer
+// for forward compatibility.
+type IP2ProxyServer interface {
+	Lookup(context.Context, *LookupRequest) (*LookupResponse, error)
+	BatchLookup(grpc.BidiStreamingServer[LookupRequest, LookupResponse]) error
+	ListCountryRanges(*CountryRequest, grpc.ServerStreamingServer[IPRange]) error
+	CountryISPs(context.Context, *CountryRequest) (*CountryISPsResponse, error)
+	CountryTotal(context.Context, *CountryRequest) (*CountryTotalResponse, error)
+	ProxyTypeStats(context.Context, *ProxyTypeStatsRequest) (*ProxyTypeStatsResponse, error)
+	mustEmbedUnimplementedIP2ProxyServer()
+}
+
+// UnimplementedIP2ProxyServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedIP2ProxyServer struct{}
+
+func (UnimplementedIP2ProxyServer) Lookup(context.Context, *LookupRequest) (*LookupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Lookup not implemented")
+}
+func (UnimplementedIP2ProxyServer) BatchLookup(grpc.BidiStreamingServer[LookupRequest, LookupResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method BatchLookup not implemented")
+}
+func (UnimplementedIP2ProxyServer) ListCountryRanges(*CountryRequest, grpc.ServerStreamingServer[IPRange]) error {
+	return status.Errorf(codes.Unimplemented, "method ListCountryRanges not implemented")
+}
+func (UnimplementedIP2ProxyServer) CountryISPs(context.Context, *CountryRequest) (*CountryISPsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CountryISPs not implemented")
+}
+func (UnimplementedIP2ProxyServer) CountryTotal(context.Context, *CountryRequest) (*CountryTotalResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CountryTotal not implemented")
+}
+func (UnimplementedIP2ProxyServer) ProxyTypeStats(context.Context, *ProxyTypeStatsRequest) (*ProxyTypeStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProxyTypeStats not implemented")
+}
+func (UnimplementedIP2ProxyServer) mustEmbedUnimplementedIP2ProxyServer() {}
+func (UnimplementedIP2ProxyServer) testEmbeddedByValue()                  {}
+
+// UnsafeIP2ProxyServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IP2ProxyServer will
+// result in compilation errors.
+type UnsafeIP2ProxyServer interface {
+	mustEmbedUnimplementedIP2ProxyServer()
+}
+
+func RegisterIP2ProxyServer(s grpc.ServiceRegistrar, srv IP2ProxyServer) {
+	// If the following call pancis, it indicates UnimplementedIP2ProxyServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&IP2Proxy_ServiceDesc, srv)
+}
+
+func _IP2Proxy_Lookup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IP2ProxyServer).Lookup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IP2Proxy_Lookup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IP2ProxyServer).Lookup(ctx, req.(*LookupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IP2Proxy_BatchLookup_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(IP2ProxyServer).BatchLookup(&grpc.GenericServerStream[LookupRequest, LookupResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type IP2Proxy_BatchLookupServer = grpc.BidiStreamingServer[LookupRequest, LookupResponse]
+
+func _IP2Proxy_ListCountryRanges_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CountryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(IP2ProxyServer).ListCountryRanges(m, &grpc.GenericServerStream[CountryRequest, IPRange]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type IP2Proxy_ListCountryRangesServer = grpc.ServerStreamingServer[IPRange]
+
+func _IP2Proxy_CountryISPs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IP2ProxyServer).CountryISPs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IP2Proxy_CountryISPs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IP2ProxyServer).CountryISPs(ctx, req.(*CountryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IP2Proxy_CountryTotal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IP2ProxyServer).CountryTotal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IP2Proxy_CountryTotal_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IP2ProxyServer).CountryTotal(ctx, req.(*CountryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IP2Proxy_ProxyTypeStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProxyTypeStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IP2ProxyServer).ProxyTypeStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IP2Proxy_ProxyTypeStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IP2ProxyServer).ProxyTypeStats(ctx, req.(*ProxyTypeStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// IP2Proxy_ServiceDesc is the grpc.ServiceDesc for IP2Proxy service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var IP2Proxy_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ip2proxy.v1.IP2Proxy",
+	HandlerType: (*IP2ProxyServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Lookup",
+			Handler:    _IP2Proxy_Lookup_Handler,
+		},
+		{
+			MethodName: "CountryISPs",
+			Handler:    _IP2Proxy_CountryISPs_Handler,
+		},
+		{
+			MethodName: "CountryTotal",
+			Handler:    _IP2Proxy_CountryTotal_Handler,
+		},
+		{
+			MethodName: "ProxyTypeStats",
+			Handler:    _IP2Proxy_ProxyTypeStats_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "BatchLookup",
+			Handler:       _IP2Proxy_BatchLookup_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ListCountryRanges",
+			Handler:       _IP2Proxy_ListCountryRanges_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "ip2proxypb/ip2proxy.proto",
+}
@@ -0,0 +1,217 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nullc0rp/go-ip2proxy-api/privacy"
+)
+
+const (
+	STDOUT          = "stdout"
+	STDERR          = "stderr"
+	PACKAGE         = "package"
+	REQUESTID       = "request_id"
+	ROUTE           = "route"
+	DURATION        = "duration_ms"
+	REQUESTIDHEADER = "X-Request-ID"
+	//MAXREQUESTID is the longest request ID taken from a caller, longer ones are replaced
+	MAXREQUESTID = 128
+	BADLEVEL     = "Unknown log level %q, it must be debug, info, warn or error"
+)
+
+// Config picks what is logged and where
+type Config struct {
+	//Level is debug, info, warn or error, info by default
+	Level string
+	//Output is stdout, stderr or the path of a file to append to, stderr by default
+	Output string
+	//Packages overrides the level of some packages, like {"database": "debug"}
+	Packages map[string]string
+	//Anonymizer rewrites the addresses of every line, nil keeps them
+	Anonymizer *privacy.Anonymizer
+}
+
+// Loggers writes JSON lines to the configured output, each package at its own level
+type Loggers struct {
+	writer     io.Writer
+	level      slog.Level
+	packages   map[string]slog.Level
+	anonymizer *privacy.Anonymizer
+}
+
+// New opens the output and parses the levels of the configuration
+func New(config Config) (*Loggers, error) {
+	level, err := parseLevel(config.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	packages := make(map[string]slog.Level)
+	for name, value := range config.Packages {
+		if packages[name], err = parseLevel(value); err != nil {
+			return nil, err
+		}
+	}
+
+	var writer io.Writer
+	switch config.Output {
+	case "", STDERR:
+		writer = os.Stderr
+	case STDOUT:
+		writer = os.Stdout
+	default:
+		writer, err = os.OpenFile(config.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Loggers{writer: writer, level: level, packages: packages, anonymizer: config.Anonymizer}, nil
+}
+
+// NewWriter logs every package to the writer at the level, for tests
+func NewWriter(writer io.Writer, level slog.Level) *Loggers {
+	return &Loggers{writer: writer, level: level, packages: map[string]slog.Level{}}
+}
+
+// Anonymize rewrites the addresses of the lines of the loggers created from now on
+func (l *Loggers) Anonymize(anonymizer *privacy.Anonymizer) *Loggers {
+	l.anonymizer = anonymizer
+	return l
+}
+
+// For returns the logger of a package, its lines are tagged with the package name
+func (l *Loggers) For(name string) *slog.Logger {
+	level, ok := l.packages[name]
+	if !ok {
+		level = l.level
+	}
+	handler := slog.NewJSONHandler(l.writer, &slog.HandlerOptions{Level: level})
+	return slog.New(&contextHandler{Handler: handler, anonymizer: l.anonymizer}).With(PACKAGE, name)
+}
+
+func parseLevel(value string) (slog.Level, error) {
+	switch strings.ToLower(value) {
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf(BADLEVEL, value)
+}
+
+// Request is what the lines logged during a request say about it
+type Request struct {
+	ID    string
+	Route string
+	Start time.Time
+}
+
+type contextKey struct{}
+
+// FromContext returns the request being logged, if any
+func FromContext(ctx context.Context) (*Request, bool) {
+	request, ok := ctx.Value(contextKey{}).(*Request)
+	return request, ok
+}
+
+// Middleware gives every request an ID, the one of the X-Request-ID header when the caller sent one.
+// It is also sent back, and lines logged with the request context carry it.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(REQUESTIDHEADER)
+		if id == "" || len(id) > MAXREQUESTID {
+			id = newID()
+		}
+
+		request := &Request{ID: id, Start: time.Now()}
+		if current := mux.CurrentRoute(r); current != nil {
+			request.Route, _ = current.GetPathTemplate()
+		}
+
+		w.Header().Set(REQUESTIDHEADER, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, request)))
+	})
+}
+
+// newID is 16 random hex characters
+func newID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// contextHandler adds the request ID, route and time spent so far to the lines logged with a request context.
+// In privacy mode it also rewrites the addresses of the message and the attributes.
+type contextHandler struct {
+	slog.Handler
+	anonymizer *privacy.Anonymizer
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if h.anonymizer.Enabled() {
+		anonymized := slog.NewRecord(record.Time, record.Level, h.anonymizer.String(record.Message), record.PC)
+		record.Attrs(func(attr slog.Attr) bool {
+			anonymized.AddAttrs(h.anonymize(attr))
+			return true
+		})
+		record = anonymized
+	}
+
+	if request, ok := FromContext(ctx); ok {
+		record.AddAttrs(
+			slog.String(REQUESTID, request.ID),
+			slog.String(ROUTE, request.Route),
+			slog.Float64(DURATION, float64(time.Since(request.Start).Microseconds())/1000),
+		)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if h.anonymizer.Enabled() {
+		anonymized := []slog.Attr{}
+		for _, attr := range attrs {
+			anonymized = append(anonymized, h.anonymize(attr))
+		}
+		attrs = anonymized
+	}
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs), anonymizer: h.anonymizer}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name), anonymizer: h.anonymizer}
+}
+
+// anonymize rewrites the addresses of a value written as text, like strings, errors and net.IP
+func (h *contextHandler) anonymize(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindGroup:
+		group := []any{}
+		for _, member := range value.Group() {
+			group = append(group, h.anonymize(member))
+		}
+		return slog.Group(attr.Key, group...)
+	case slog.KindString, slog.KindAny:
+		text := value.String()
+		if anonymized := h.anonymizer.String(text); anonymized != text {
+			return slog.String(attr.Key, anonymized)
+		}
+	}
+	return slog.Attr{Key: attr.Key, Value: value}
+}
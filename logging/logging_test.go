@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+// lines decodes the JSON lines written so far
+func lines(t *testing.T, buffer *bytes.Buffer) []map[string]interface{} {
+	result := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		decoded := map[string]interface{}{}
+		assert.Nil(t, json.Unmarshal([]byte(line), &decoded), line)
+		result = append(result, decoded)
+	}
+	return result
+}
+
+func TestPackageLevels(t *testing.T) {
+
+	var buffer bytes.Buffer
+	loggers := NewWriter(&buffer, slog.LevelInfo)
+	loggers.packages["database"] = slog.LevelDebug
+
+	loggers.For("service").Debug("Query", "sql", "SELECT 1")
+	loggers.For("database").Debug("Query", "sql", "SELECT 2")
+	loggers.For("service").Info("Started")
+
+	//Only the database logs at debug
+	logged := lines(t, &buffer)
+	assert.Equal(t, 2, len(logged))
+	assert.Equal(t, "database", logged[0][PACKAGE])
+	assert.Equal(t, "SELECT 2", logged[0]["sql"])
+	assert.Equal(t, "DEBUG", logged[0]["level"])
+	assert.Equal(t, "service", logged[1][PACKAGE])
+}
+
+func TestNewBadLevel(t *testing.T) {
+
+	_, err := New(Config{Level: "verbose"})
+	assert.Equal(t, "Unknown log level \"verbose\", it must be debug, info, warn or error", err.Error())
+
+	_, err = New(Config{Packages: map[string]string{"service": "loud"}})
+	assert.NotNil(t, err)
+}
+
+func TestRequestLines(t *testing.T) {
+
+	var buffer bytes.Buffer
+	logger := NewWriter(&buffer, slog.LevelInfo).For("controller")
+
+	router := mux.NewRouter()
+	router.HandleFunc("/v2/ip/{address}", func(w http.ResponseWriter, r *http.Request) {
+		logger.InfoContext(r.Context(), "Received v2 request for ip info", "address", mux.Vars(r)["address"])
+	})
+	router.Use(Middleware)
+
+	//The ID of the caller is kept
+	r := httptest.NewRequest("GET", "/v2/ip/1.2.3.4", nil)
+	r.Header.Set(REQUESTIDHEADER, "abc-123")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	assert.Equal(t, "abc-123", w.Header().Get(REQUESTIDHEADER))
+
+	//Or one is made up
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/v2/ip/5.6.7.8", nil))
+	generated := w.Header().Get(REQUESTIDHEADER)
+	assert.Equal(t, 16, len(generated))
+
+	logged := lines(t, &buffer)
+	assert.Equal(t, 2, len(logged))
+	assert.Equal(t, "abc-123", logged[0][REQUESTID])
+	assert.Equal(t, "/v2/ip/{address}", logged[0][ROUTE])
+	assert.Equal(t, "1.2.3.4", logged[0]["address"])
+	_, ok := logged[0][DURATION].(float64)
+	assert.True(t, ok)
+	assert.Equal(t, generated, logged[1][REQUESTID])
+
+	//Lines without a request don't have those
+	buffer.Reset()
+	logger.Info("Started")
+	_, ok = lines(t, &buffer)[0][REQUESTID]
+	assert.False(t, ok)
+}
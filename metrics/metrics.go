@@ -0,0 +1,193 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nullc0rp/go-ip2proxy-api/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	NAMESPACE = "ip2proxy"
+	//NOROUTE labels the requests that matched no route, so unknown paths don't grow the series
+	NOROUTE = "none"
+)
+
+// Metrics holds the collectors of the server, each instance has its own registry
+type Metrics struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	calls           *prometheus.HistogramVec
+	errors          *prometheus.CounterVec
+	datasetInfo     *prometheus.GaugeVec
+	datasetLoaded   prometheus.Gauge
+	datasetRows     prometheus.Gauge
+}
+
+// New creates the collectors, the Go runtime and process ones included
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: NAMESPACE,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route, method and status.",
+		}, []string{"route", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: NAMESPACE,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		calls: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: NAMESPACE,
+			Name:      "service_call_duration_seconds",
+			Help:      "Service call latency by method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: NAMESPACE,
+			Name:      "service_errors_total",
+			Help:      "Failed service calls by method, addresses not in the dataset are not errors.",
+		}, []string{"method"}),
+		datasetInfo: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: NAMESPACE,
+			Name:      "dataset_info",
+			Help:      "The loaded dataset version, always 1.",
+		}, []string{"version"}),
+		datasetLoaded: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: NAMESPACE,
+			Name:      "dataset_loaded_timestamp_seconds",
+			Help:      "When the dataset was loaded, in seconds since the epoch.",
+		}),
+		datasetRows: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: NAMESPACE,
+			Name:      "dataset_rows",
+			Help:      "Ranges in the loaded dataset.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.calls,
+		m.errors,
+		m.datasetInfo,
+		m.datasetLoaded,
+		m.datasetRows,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format, for /metrics
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware counts and times the requests by their route template, it can be used directly as a mux middleware
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(recorder, r)
+
+		route := NOROUTE
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		status := strconv.Itoa(recorder.status)
+		m.requests.WithLabelValues(route, r.Method, status).Inc()
+		m.requestDuration.WithLabelValues(route, r.Method, status).Observe(time.Since(start).Seconds())
+	})
+}
+
+// WatchDB exposes the connection pool stats of a database, as go_sql_* labeled with the name
+func (m *Metrics) WatchDB(name string, db *sql.DB) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// CacheSource is a cache reporting its lookups, like cache.LRU
+type CacheSource interface {
+	Stats() cache.Stats
+}
+
+// WatchCache exposes the hits, misses and hit ratio of a cache under its name
+func (m *Metrics) WatchCache(name string, source CacheSource) {
+	m.registry.MustRegister(newCacheCollector(name, source))
+}
+
+// SetDataset reports the loaded dataset, the previous version is dropped
+func (m *Metrics) SetDataset(version string, loaded time.Time, rows int) {
+	m.datasetInfo.Reset()
+	m.datasetInfo.WithLabelValues(version).Set(1)
+	m.datasetLoaded.Set(float64(loaded.Unix()))
+	m.datasetRows.Set(float64(rows))
+}
+
+// statusRecorder keeps the status written by the handler, streamed lists still get flushed
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// cacheCollector reads the cache stats on every scrape
+type cacheCollector struct {
+	source  CacheSource
+	hits    *prometheus.Desc
+	misses  *prometheus.Desc
+	entries *prometheus.Desc
+	ratio   *prometheus.Desc
+}
+
+func newCacheCollector(name string, source CacheSource) *cacheCollector {
+	labels := prometheus.Labels{"cache": name}
+	return &cacheCollector{
+		source:  source,
+		hits:    prometheus.NewDesc(NAMESPACE+"_cache_hits_total", "Lookups answered by the cache.", nil, labels),
+		misses:  prometheus.NewDesc(NAMESPACE+"_cache_misses_total", "Lookups the cache could not answer.", nil, labels),
+		entries: prometheus.NewDesc(NAMESPACE+"_cache_entries", "Entries in the cache.", nil, labels),
+		ratio:   prometheus.NewDesc(NAMESPACE+"_cache_hit_ratio", "Share of the lookups answered by the cache.", nil, labels),
+	}
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.entries
+	ch <- c.ratio
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.source.Stats()
+	ratio := 0.0
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		ratio = float64(stats.Hits) / float64(lookups)
+	}
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(stats.Entries))
+	ch <- prometheus.MustNewConstMetric(c.ratio, prometheus.GaugeValue, ratio)
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/nullc0rp/go-ip2proxy-api/cache"
+	"github.com/nullc0rp/go-ip2proxy-api/mocks"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddlewareByRouteTemplate(t *testing.T) {
+
+	m := New()
+	r := mux.NewRouter()
+	r.HandleFunc("/ip/{ip}", func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["ip"] == "bad" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	r.Use(m.Middleware)
+
+	for _, path := range []string{"/ip/1.2.3.4", "/ip/5.6.7.8", "/ip/bad"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	//Addresses don't make their own series
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.requests.WithLabelValues("/ip/{ip}", "GET", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues("/ip/{ip}", "GET", "400")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.requestDuration))
+}
+
+func TestServiceCalls(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	m := New()
+	s := m.Service(mockService)
+
+	mockService.EXPECT().GetIPInfo(net.ParseIP("1.2.3.4")).Return(&service.IPData{ProxyType: "PUB"}, nil)
+	mockService.EXPECT().GetIPInfo(net.ParseIP("5.6.7.8")).Return(nil, service.NoResultError("query"))
+	mockService.EXPECT().GetCountryTotal("AR").Return(nil, errors.New("connection refused"))
+
+	data, err := s.GetIPInfo(net.ParseIP("1.2.3.4"))
+	assert.Nil(t, err)
+	assert.Equal(t, "PUB", data.ProxyType)
+	s.GetIPInfo(net.ParseIP("5.6.7.8"))
+	s.GetCountryTotal("AR")
+
+	//Unknown addresses are answers, not errors
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.errors.WithLabelValues("GetIPInfo")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.errors.WithLabelValues("GetCountryTotal")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.calls))
+
+	//Scoped services are timed too
+	mockService.EXPECT().MaxAge(30).Return(mockService)
+	mockService.EXPECT().MostProxyTypes().Return(&service.MostProxyTypeResult{}, nil)
+	s.MaxAge(30).MostProxyTypes()
+	assert.Equal(t, 3, testutil.CollectAndCount(m.calls))
+}
+
+func TestCacheAndDataset(t *testing.T) {
+
+	m := New()
+	lru := cache.NewLRU(10, time.Minute)
+	m.WatchCache("lookups", lru)
+
+	lru.Add("1.2.3.4", nil)
+	lru.Get("1.2.3.4")
+	lru.Get("1.2.3.4")
+	lru.Get("5.6.7.8")
+	lru.Get("5.6.7.9")
+
+	m.SetDataset("old", time.Unix(1000, 0), 10)
+	m.SetDataset("IP2PROXY-PX7.CSV@2024-01-01T00:00:00Z", time.Unix(2000, 0), 25)
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+
+	assert.Contains(t, body, "ip2proxy_cache_hits_total{cache=\"lookups\"} 2\n")
+	assert.Contains(t, body, "ip2proxy_cache_misses_total{cache=\"lookups\"} 2\n")
+	assert.Contains(t, body, "ip2proxy_cache_hit_ratio{cache=\"lookups\"} 0.5\n")
+	assert.Contains(t, body, "ip2proxy_cache_entries{cache=\"lookups\"} 1\n")
+
+	//Only the current version is reported
+	assert.Contains(t, body, "ip2proxy_dataset_info{version=\"IP2PROXY-PX7.CSV@2024-01-01T00:00:00Z\"} 1\n")
+	assert.False(t, strings.Contains(body, "version=\"old\""))
+	assert.Contains(t, body, "ip2proxy_dataset_loaded_timestamp_seconds 2000\n")
+	assert.Contains(t, body, "ip2proxy_dataset_rows 25\n")
+}
@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+)
+
+// Service times every call of the wrapped service and counts its failures
+func (m *Metrics) Service(s service.Service) service.Service {
+	return &instrumented{next: s, metrics: m}
+}
+
+type instrumented struct {
+	next    service.Service
+	metrics *Metrics
+}
+
+// observe records a call, addresses that are not in the dataset are answers and not errors
+func (s *instrumented) observe(method string, start time.Time, err error) {
+	s.metrics.calls.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil && !service.IsNoResult(err) {
+		s.metrics.errors.WithLabelValues(method).Inc()
+	}
+}
+
+func (s *instrumented) GetIPInfo(ip net.IP) (*service.IPData, error) {
+	start := time.Now()
+	result, err := s.next.GetIPInfo(ip)
+	s.observe("GetIPInfo", start, err)
+	return result, err
+}
+
+func (s *instrumented) GetIPInfoFields(ip net.IP, fields []string) (*service.IPData, error) {
+	start := time.Now()
+	result, err := s.next.GetIPInfoFields(ip, fields)
+	s.observe("GetIPInfoFields", start, err)
+	return result, err
+}
+
+func (s *instrumented) GetIPInfoRange(ip net.IP, fields []string) (*service.IPDataRange, error) {
+	start := time.Now()
+	result, err := s.next.GetIPInfoRange(ip, fields)
+	s.observe("GetIPInfoRange", start, err)
+	return result, err
+}
+
+func (s *instrumented) GetIPCountry(country string, limit int) (*service.IPCountryData, error) {
+	start := time.Now()
+	result, err := s.next.GetIPCountry(country, limit)
+	s.observe("GetIPCountry", start, err)
+	return result, err
+}
+
+func (s *instrumented) GetCountryRanges(country string, limit int) (*service.IPRangeCountryData, error) {
+	start := time.Now()
+	result, err := s.next.GetCountryRanges(country, limit)
+	s.observe("GetCountryRanges", start, err)
+	return result, err
+}
+
+func (s *instrumented) GetISPCountry(country string) (*service.ISPCountryData, error) {
+	start := time.Now()
+	result, err := s.next.GetISPCountry(country)
+	s.observe("GetISPCountry", start, err)
+	return result, err
+}
+
+func (s *instrumented) GetCountryTotal(country string) (*service.IPCountryTotal, error) {
+	start := time.Now()
+	result, err := s.next.GetCountryTotal(country)
+	s.observe("GetCountryTotal", start, err)
+	return result, err
+}
+
+func (s *instrumented) MostProxyTypes() (*service.MostProxyTypeResult, error) {
+	start := time.Now()
+	result, err := s.next.MostProxyTypes()
+	s.observe("MostProxyTypes", start, err)
+	return result, err
+}
+
+func (s *instrumented) GetProviders(country string) (*service.ProviderData, error) {
+	start := time.Now()
+	result, err := s.next.GetProviders(country)
+	s.observe("GetProviders", start, err)
+	return result, err
+}
+
+func (s *instrumented) GetProviderRanges(provider string, country string, limit int) (*service.IPRangeData, error) {
+	start := time.Now()
+	result, err := s.next.GetProviderRanges(provider, country, limit)
+	s.observe("GetProviderRanges", start, err)
+	return result, err
+}
+
+func (s *instrumented) GetThreats(country string, asn string) (*service.ThreatData, error) {
+	start := time.Now()
+	result, err := s.next.GetThreats(country, asn)
+	s.observe("GetThreats", start, err)
+	return result, err
+}
+
+func (s *instrumented) GetThreatRanges(threat string, country string, asn string, limit int) (*service.IPRangeData, error) {
+	start := time.Now()
+	result, err := s.next.GetThreatRanges(threat, country, asn, limit)
+	s.observe("GetThreatRanges", start, err)
+	return result, err
+}
+
+func (s *instrumented) DatasetVersion() (string, error) {
+	start := time.Now()
+	version, err := s.next.DatasetVersion()
+	s.observe("DatasetVersion", start, err)
+	return version, err
+}
+
+// MaxAge keeps the scoped service instrumented
+func (s *instrumented) MaxAge(days int) service.Service {
+	return &instrumented{next: s.next.MaxAge(days), metrics: s.metrics}
+}
+
+// WithContext keeps the service scoped to the request instrumented
+func (s *instrumented) WithContext(ctx context.Context) service.Service {
+	return &instrumented{next: s.next.WithContext(ctx), metrics: s.metrics}
+}
@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nullc0rp/go-ip2proxy-api/cache"
+	"github.com/nullc0rp/go-ip2proxy-api/clientip"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+)
+
+// Action is what the detector does with a request matching a rule
+type Action int
+
+const (
+	TAG Action = iota
+	BLOCK
+)
+
+const (
+	TAGSHEADER      = "X-Proxy-Tags"
+	FORBIDDEN       = "Forbidden"
+	UNAVAILABLE     = "Service unavailable"
+	LOOKUPERROR     = "Proxy lookup failed for %s: %s"
+	DEFAULTCACHE    = 10000
+	DEFAULTCACHETTL = 10 * time.Minute
+)
+
+// Rule matches looked up addresses, empty lists match anything.
+// Rules only apply to addresses found in the dataset, unknown addresses are not proxies.
+type Rule struct {
+	Name       string
+	ProxyTypes []string
+	Countries  []string
+	UsageTypes []string
+	Action     Action
+}
+
+// Options configures the detector
+type Options struct {
+	Service  service.Service
+	Resolver *clientip.Resolver
+	Rules    []Rule
+	//FailClosed rejects requests when the lookup fails, by default they go through untouched
+	FailClosed bool
+	//CacheSize is the amount of addresses kept in memory, a negative value disables the cache
+	CacheSize int
+	CacheTTL  time.Duration
+}
+
+// Result is stored in the request context for the next handlers
+type Result struct {
+	IP     net.IP
+	Source string
+	//Data is nil when the address is not in the dataset
+	Data *service.IPData
+	Tags []string
+}
+
+// Detector looks up every incoming request and applies the configured rules
+type Detector struct {
+	options Options
+	cache   *cache.LRU
+}
+
+type contextKey struct{}
+
+// NewDetector creates a detector filling in the defaults for missing options
+func NewDetector(options Options) *Detector {
+	if options.Resolver == nil {
+		options.Resolver = &clientip.Resolver{}
+	}
+	if options.CacheSize == 0 {
+		options.CacheSize = DEFAULTCACHE
+	}
+	if options.CacheTTL == 0 {
+		options.CacheTTL = DEFAULTCACHETTL
+	}
+
+	detector := &Detector{options: options}
+	if options.CacheSize > 0 {
+		detector.cache = cache.NewLRU(options.CacheSize, options.CacheTTL)
+	}
+	return detector
+}
+
+// Handler wraps the next handler, it can be used directly as a mux middleware
+func (d *Detector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		// Only the detector sets the tags, a client could claim any
+		r.Header.Del(TAGSHEADER)
+
+		// Resolve the client address
+		ip, source := d.options.Resolver.Resolve(r)
+		if ip == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Get service data, the dataset is IPv4 only so IPv6 clients are not in it
+		var data *service.IPData
+		var err error
+		if ip.To4() != nil {
+			data, err = d.lookup(ip)
+		}
+		if err != nil {
+			log.Printf(LOOKUPERROR, ip, err)
+			if d.options.FailClosed {
+				http.Error(w, UNAVAILABLE, http.StatusServiceUnavailable)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		result := &Result{
+			IP:     ip,
+			Source: source,
+			Data:   data,
+		}
+
+		// Apply rules, the first blocking one wins
+		if data != nil {
+			for _, rule := range d.options.Rules {
+				if !rule.Matches(data) {
+					continue
+				}
+				if rule.Action == BLOCK {
+					http.Error(w, FORBIDDEN, http.StatusForbidden)
+					return
+				}
+				result.Tags = append(result.Tags, rule.Name)
+			}
+		}
+
+		// Tags are also forwarded as a header for handlers that don't read the context
+		if len(result.Tags) > 0 {
+			r.Header.Set(TAGSHEADER, strings.Join(result.Tags, ","))
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, result)))
+	})
+}
+
+// CacheStats reports the lookups answered by the cache, all of them are misses without one
+func (d *Detector) CacheStats() cache.Stats {
+	if d.cache == nil {
+		return cache.Stats{}
+	}
+	return d.cache.Stats()
+}
+
+// FromContext returns the detection result for the request, if any
+func FromContext(ctx context.Context) (*Result, bool) {
+	result, ok := ctx.Value(contextKey{}).(*Result)
+	return result, ok
+}
+
+// Matches checks the rule against the address data
+func (rule Rule) Matches(data *service.IPData) bool {
+	return contains(rule.ProxyTypes, data.ProxyType) &&
+		contains(rule.Countries, data.CountryCode) &&
+		contains(rule.UsageTypes, data.UsageType)
+}
+
+// lookup asks the service for the address, going through the cache first
+func (d *Detector) lookup(ip net.IP) (*service.IPData, error) {
+	key := ip.String()
+	if d.cache != nil {
+		if data, ok := d.cache.Get(key); ok {
+			return data, nil
+		}
+	}
+
+	data, err := d.options.Service.GetIPInfo(ip)
+	if err != nil {
+		if !service.IsNoResult(err) {
+			return nil, err
+		}
+		//Not in the dataset, cached as well since those are most of the requests
+		data = nil
+	}
+
+	if d.cache != nil {
+		d.cache.Add(key, data)
+	}
+	return data, nil
+}
+
+// contains matches the value against the list, usage types may be combined like "DCH/SES"
+func contains(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, item := range list {
+		for _, part := range strings.Split(value, "/") {
+			if strings.EqualFold(item, part) {
+				return true
+			}
+		}
+	}
+	return false
+}
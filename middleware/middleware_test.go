@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/nullc0rp/go-ip2proxy-api/mocks"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+	"github.com/stretchr/testify/assert"
+)
+
+// echo writes the tags seen by the next handler
+var echo = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	result, ok := FromContext(r.Context())
+	if !ok {
+		w.Write([]byte("no result"))
+		return
+	}
+	if result.Data == nil {
+		w.Write([]byte("not a proxy"))
+		return
+	}
+	w.Write([]byte(r.Header.Get(TAGSHEADER)))
+})
+
+func TestDetectorTagAndCache(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	detector := NewDetector(Options{
+		Service: mockService,
+		Rules: []Rule{
+			{Name: "datacenter", UsageTypes: []string{"DCH"}, Action: TAG},
+			{Name: "public", ProxyTypes: []string{"PUB"}, Action: TAG},
+		},
+	})
+
+	//Expects setup, a single call thanks to the cache
+	mockService.EXPECT().GetIPInfo(net.ParseIP("203.0.113.7")).Return(&service.IPData{ProxyType: "PUB", UsageType: "DCH/SES"}, nil).Times(1)
+
+	for i := 0; i < 2; i++ {
+		r, _ := http.NewRequest("GET", "/", nil)
+		r.RemoteAddr = "203.0.113.7:1234"
+		w := httptest.NewRecorder()
+
+		detector.Handler(echo).ServeHTTP(w, r)
+
+		assert.Equal(t, "datacenter,public", w.Body.String())
+	}
+
+	stats := detector.CacheStats()
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, 1, stats.Entries)
+}
+
+func TestDetectorBlock(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	detector := NewDetector(Options{
+		Service:   mockService,
+		CacheSize: -1,
+		Rules: []Rule{
+			{Name: "tor", ProxyTypes: []string{"TOR"}, Countries: []string{"DE"}, Action: BLOCK},
+		},
+	})
+
+	mockService.EXPECT().GetIPInfo(net.ParseIP("203.0.113.7")).Return(&service.IPData{ProxyType: "TOR", CountryCode: "DE"}, nil)
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "203.0.113.7:1234"
+	w := httptest.NewRecorder()
+
+	detector.Handler(echo).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestDetectorNoResult(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	detector := NewDetector(Options{Service: mockService, FailClosed: true})
+
+	mockService.EXPECT().GetIPInfo(net.ParseIP("203.0.113.7")).Return(nil, service.NoResultError("nothing"))
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "203.0.113.7:1234"
+	w := httptest.NewRecorder()
+
+	detector.Handler(echo).ServeHTTP(w, r)
+
+	assert.Equal(t, "not a proxy", w.Body.String())
+}
+
+func TestDetectorSpoofedTags(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	detector := NewDetector(Options{
+		Service: mockService,
+		Rules:   []Rule{{Name: "public", ProxyTypes: []string{"PUB"}, Action: TAG}},
+	})
+
+	mockService.EXPECT().GetIPInfo(net.ParseIP("203.0.113.7")).Return(&service.IPData{ProxyType: "VPN"}, nil)
+
+	//The address matches no rule, the tags sent by the client are dropped
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "203.0.113.7:1234"
+	r.Header.Set(TAGSHEADER, "trusted")
+	w := httptest.NewRecorder()
+
+	detector.Handler(echo).ServeHTTP(w, r)
+
+	assert.Equal(t, "", w.Body.String())
+}
+
+func TestDetectorIPv6(t *testing.T) {
+
+	//Mocked service setup, the dataset is IPv4 only and is not asked
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	detector := NewDetector(Options{Service: mockService, FailClosed: true})
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "[2001:db8::cb00:7107]:1234"
+	w := httptest.NewRecorder()
+
+	detector.Handler(echo).ServeHTTP(w, r)
+
+	assert.Equal(t, "not a proxy", w.Body.String())
+}
+
+func TestDetectorFailModes(t *testing.T) {
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	mockService.EXPECT().GetIPInfo(gomock.Any()).Return(nil, errors.New("some dirty info")).Times(2)
+
+	//Fail open
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "203.0.113.7:1234"
+	w := httptest.NewRecorder()
+	NewDetector(Options{Service: mockService}).Handler(echo).ServeHTTP(w, r)
+	assert.Equal(t, "no result", w.Body.String())
+
+	//Fail closed
+	w = httptest.NewRecorder()
+	NewDetector(Options{Service: mockService, FailClosed: true}).Handler(echo).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
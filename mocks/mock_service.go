@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	context "context"
 	gomock "github.com/golang/mock/gomock"
 	service "github.com/nullc0rp/go-ip2proxy-api/service"
 	net "net"
@@ -49,6 +50,36 @@ func (mr *MockServiceMockRecorder) GetIPInfo(ip interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIPInfo", reflect.TypeOf((*MockService)(nil).GetIPInfo), ip)
 }
 
+// GetIPInfoFields mocks base method
+func (m *MockService) GetIPInfoFields(ip net.IP, fields []string) (*service.IPData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIPInfoFields", ip, fields)
+	ret0, _ := ret[0].(*service.IPData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIPInfoFields indicates an expected call of GetIPInfoFields
+func (mr *MockServiceMockRecorder) GetIPInfoFields(ip, fields interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIPInfoFields", reflect.TypeOf((*MockService)(nil).GetIPInfoFields), ip, fields)
+}
+
+// GetIPInfoRange mocks base method
+func (m *MockService) GetIPInfoRange(ip net.IP, fields []string) (*service.IPDataRange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIPInfoRange", ip, fields)
+	ret0, _ := ret[0].(*service.IPDataRange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIPInfoRange indicates an expected call of GetIPInfoRange
+func (mr *MockServiceMockRecorder) GetIPInfoRange(ip, fields interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIPInfoRange", reflect.TypeOf((*MockService)(nil).GetIPInfoRange), ip, fields)
+}
+
 // GetIPCountry mocks base method
 func (m *MockService) GetIPCountry(country string, limit int) (*service.IPCountryData, error) {
 	m.ctrl.T.Helper()
@@ -64,6 +95,21 @@ func (mr *MockServiceMockRecorder) GetIPCountry(country, limit interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIPCountry", reflect.TypeOf((*MockService)(nil).GetIPCountry), country, limit)
 }
 
+// GetCountryRanges mocks base method
+func (m *MockService) GetCountryRanges(country string, limit int) (*service.IPRangeCountryData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCountryRanges", country, limit)
+	ret0, _ := ret[0].(*service.IPRangeCountryData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCountryRanges indicates an expected call of GetCountryRanges
+func (mr *MockServiceMockRecorder) GetCountryRanges(country, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCountryRanges", reflect.TypeOf((*MockService)(nil).GetCountryRanges), country, limit)
+}
+
 // GetISPCountry mocks base method
 func (m *MockService) GetISPCountry(country string) (*service.ISPCountryData, error) {
 	m.ctrl.T.Helper()
@@ -108,3 +154,106 @@ func (mr *MockServiceMockRecorder) MostProxyTypes() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MostProxyTypes", reflect.TypeOf((*MockService)(nil).MostProxyTypes))
 }
+
+// GetProviders mocks base method
+func (m *MockService) GetProviders(country string) (*service.ProviderData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProviders", country)
+	ret0, _ := ret[0].(*service.ProviderData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProviders indicates an expected call of GetProviders
+func (mr *MockServiceMockRecorder) GetProviders(country interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProviders", reflect.TypeOf((*MockService)(nil).GetProviders), country)
+}
+
+// GetProviderRanges mocks base method
+func (m *MockService) GetProviderRanges(provider string, country string, limit int) (*service.IPRangeData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProviderRanges", provider, country, limit)
+	ret0, _ := ret[0].(*service.IPRangeData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProviderRanges indicates an expected call of GetProviderRanges
+func (mr *MockServiceMockRecorder) GetProviderRanges(provider, country, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProviderRanges", reflect.TypeOf((*MockService)(nil).GetProviderRanges), provider, country, limit)
+}
+
+// GetThreats mocks base method
+func (m *MockService) GetThreats(country string, asn string) (*service.ThreatData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetThreats", country, asn)
+	ret0, _ := ret[0].(*service.ThreatData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetThreats indicates an expected call of GetThreats
+func (mr *MockServiceMockRecorder) GetThreats(country, asn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetThreats", reflect.TypeOf((*MockService)(nil).GetThreats), country, asn)
+}
+
+// GetThreatRanges mocks base method
+func (m *MockService) GetThreatRanges(threat string, country string, asn string, limit int) (*service.IPRangeData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetThreatRanges", threat, country, asn, limit)
+	ret0, _ := ret[0].(*service.IPRangeData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetThreatRanges indicates an expected call of GetThreatRanges
+func (mr *MockServiceMockRecorder) GetThreatRanges(threat, country, asn, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetThreatRanges", reflect.TypeOf((*MockService)(nil).GetThreatRanges), threat, country, asn, limit)
+}
+
+// DatasetVersion mocks base method
+func (m *MockService) DatasetVersion() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DatasetVersion")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DatasetVersion indicates an expected call of DatasetVersion
+func (mr *MockServiceMockRecorder) DatasetVersion() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DatasetVersion", reflect.TypeOf((*MockService)(nil).DatasetVersion))
+}
+
+// MaxAge mocks base method
+func (m *MockService) MaxAge(days int) service.Service {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaxAge", days)
+	ret0, _ := ret[0].(service.Service)
+	return ret0
+}
+
+// MaxAge indicates an expected call of MaxAge
+func (mr *MockServiceMockRecorder) MaxAge(days interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxAge", reflect.TypeOf((*MockService)(nil).MaxAge), days)
+}
+
+// WithContext mocks base method
+func (m *MockService) WithContext(ctx context.Context) service.Service {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithContext", ctx)
+	ret0, _ := ret[0].(service.Service)
+	return ret0
+}
+
+// WithContext indicates an expected call of WithContext
+func (mr *MockServiceMockRecorder) WithContext(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithContext", reflect.TypeOf((*MockService)(nil).WithContext), ctx)
+}
@@ -0,0 +1,134 @@
+package openapi
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+)
+
+const (
+	CONTENTYPE = "Content-Type"
+	APPJSON    = "application/json"
+	TEXTHTML   = "text/html; charset=utf-8"
+	VIOLATION  = "OpenAPI violation on %s %s: %s"
+)
+
+// Spec is the OpenAPI document of every route, keep it in sync with api_server.go
+//
+//go:embed openapi.json
+var Spec []byte
+
+//go:embed swagger.html
+var swaggerUI []byte
+
+// ServeSpec serves the OpenAPI document
+func ServeSpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(CONTENTYPE, APPJSON)
+	w.Write(Spec)
+}
+
+// ServeDocs serves the Swagger UI page for the document
+func ServeDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(CONTENTYPE, TEXTHTML)
+	w.Write(swaggerUI)
+}
+
+// rawTypes are checked as plain strings, the Swagger UI page and the non JSON response formats
+var rawTypes = []string{"text/html", "text/csv", "application/xml", "application/msgpack", "application/x-protobuf"}
+
+func init() {
+	for _, contentType := range rawTypes {
+		openapi3filter.RegisterBodyDecoder(contentType, func(body io.Reader, header http.Header, schema *openapi3.SchemaRef, encodingFn openapi3filter.EncodingFn) (interface{}, error) {
+			data, err := ioutil.ReadAll(body)
+			return string(data), err
+		})
+	}
+}
+
+// Validator checks requests and responses against the document
+type Validator struct {
+	router routers.Router
+}
+
+// NewValidator loads and validates the document itself
+func NewValidator() (*Validator, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(Spec)
+	if err != nil {
+		return nil, err
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, err
+	}
+
+	//Routes are matched by path only, whatever host the server runs on
+	doc.Servers = nil
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &Validator{router: router}, nil
+}
+
+// Validate checks a request and the response given to it
+func (v *Validator) Validate(r *http.Request, status int, header http.Header, body []byte) error {
+	route, pathParams, err := v.router.FindRoute(r)
+	if err != nil {
+		return err
+	}
+
+	requestInput := &openapi3filter.RequestValidationInput{
+		Request:    r,
+		PathParams: pathParams,
+		Route:      route,
+		Options:    &openapi3filter.Options{ExcludeRequestBody: true},
+	}
+	if err := openapi3filter.ValidateRequest(r.Context(), requestInput); err != nil {
+		return err
+	}
+
+	responseInput := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: requestInput,
+		Status:                 status,
+		Header:                 header,
+		Options:                &openapi3filter.Options{IncludeResponseStatus: true},
+	}
+	responseInput.SetBodyBytes(body)
+	return openapi3filter.ValidateResponse(r.Context(), responseInput)
+}
+
+// Middleware validates every response and logs the violations, it is meant for tests and staging
+func (v *Validator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &recorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		if err := v.Validate(r, recorder.status, w.Header(), recorder.body.Bytes()); err != nil {
+			log.Printf(VIOLATION, r.Method, r.URL.Path, err)
+		}
+	})
+}
+
+// recorder keeps a copy of the response while writing it
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
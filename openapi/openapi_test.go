@@ -0,0 +1,200 @@
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/nullc0rp/go-ip2proxy-api/controller"
+	"github.com/nullc0rp/go-ip2proxy-api/graphqlapi"
+	"github.com/nullc0rp/go-ip2proxy-api/metrics"
+	"github.com/nullc0rp/go-ip2proxy-api/mocks"
+	"github.com/nullc0rp/go-ip2proxy-api/probes"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+	"github.com/stretchr/testify/assert"
+)
+
+// pinger is a database that answers the pings with err
+type pinger struct {
+	err error
+}
+
+func (p pinger) PingContext(ctx context.Context) error {
+	return p.err
+}
+
+// router builds the same routes as the server on a mocked service
+func router(t *testing.T, mockService *mocks.MockService) *mux.Router {
+	r := controller.NewRouter(&controller.ControllerImpl{Service: mockService}, &controller.ControllerV2Impl{Service: mockService})
+	graphqlHandler, err := graphqlapi.NewHandler(mockService)
+	assert.Nil(t, err)
+	r.Handle("/graphql", graphqlHandler).Methods("GET", "POST")
+	r.HandleFunc("/openapi.json", ServeSpec).Methods("GET")
+	r.HandleFunc("/docs", ServeDocs).Methods("GET")
+	checker := &probes.Checker{DB: pinger{}}
+	checker.SetDataset("2021-06-01 10:00:00", time.Now(), 10)
+	r.HandleFunc("/healthz", checker.Healthz).Methods("GET")
+	r.HandleFunc("/readyz", checker.Readyz).Methods("GET")
+	r.HandleFunc("/status", checker.Status).Methods("GET")
+	r.Handle("/metrics", metrics.New().Handler()).Methods("GET")
+	return r
+}
+
+// TestResponsesMatchSpec runs every route through the real handlers and validates the responses
+func TestResponsesMatchSpec(t *testing.T) {
+
+	validator, err := NewValidator()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when loading the document", err)
+	}
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mockService := mocks.NewMockService(controller)
+
+	mockService.EXPECT().GetIPInfo(net.ParseIP("10.10.10.1")).Return(&service.IPData{ProxyType: "PUB"}, nil)
+	mockService.EXPECT().GetIPInfo(net.ParseIP("192.0.2.1")).Return(&service.IPData{ProxyType: "VPN"}, nil)
+	mockService.EXPECT().GetIPInfo(net.ParseIP("10.10.10.2")).Return(nil, errors.New("some dirty info"))
+	mockService.EXPECT().GetIPCountry("AR", 10).Return(&service.IPCountryData{Total: 1, IPList: []*service.IPDataResult{{IP: "1.0.4.1"}}}, nil)
+	mockService.EXPECT().GetISPCountry("AR").Return(&service.ISPCountryData{Total: 1, ISPList: []*service.ISPDataResult{{Name: "Telstra"}}}, nil)
+	mockService.EXPECT().GetCountryTotal("AR").Return(&service.IPCountryTotal{Total: 10}, nil)
+	mockService.EXPECT().MostProxyTypes().Return(&service.MostProxyTypeResult{ProxyTypeList: []*service.MostProxyType{{ProxyType: "PUB", Total: 3}}}, nil)
+	mockService.EXPECT().GetCountryTotal("PL").Return(&service.IPCountryTotal{Total: 10}, nil)
+	mockService.EXPECT().MostProxyTypes().Return(&service.MostProxyTypeResult{ProxyTypeList: []*service.MostProxyType{{ProxyType: "PUB", Total: 3}}}, nil)
+	mockService.EXPECT().GetIPInfo(net.ParseIP("10.10.10.3")).Return(nil, service.NoResultError("test"))
+	mockService.EXPECT().GetIPInfo(net.ParseIP("192.0.2.1")).Return(&service.IPData{ProxyType: "VPN"}, nil)
+	mockService.EXPECT().GetIPCountry("AR", 15).Return(&service.IPCountryData{Total: 1, IPList: []*service.IPDataResult{{IP: "1.0.4.1"}}}, nil)
+	mockService.EXPECT().GetISPCountry("AR").Return(&service.ISPCountryData{Total: 1, ISPList: []*service.ISPDataResult{{Name: "Telstra"}}}, nil)
+	mockService.EXPECT().GetCountryTotal("AR").Return(nil, service.NoResultError("test"))
+	mockService.EXPECT().MostProxyTypes().Return(&service.MostProxyTypeResult{}, nil)
+	mockService.EXPECT().GetIPInfo(net.ParseIP("10.10.10.4")).Return(&service.IPData{ProxyType: "PUB"}, nil).Times(7)
+	mockService.EXPECT().GetIPInfoFields(net.ParseIP("10.10.10.5"), []string{"proxy_type", "country_code"}).Return(&service.IPData{ProxyType: "PUB", CountryCode: "PL"}, nil)
+	mockService.EXPECT().GetIPInfoFields(net.ParseIP("10.10.10.5"), []string{"asn"}).Return(&service.IPData{ASN: "1299"}, nil)
+	mockService.EXPECT().GetProviders("PL").Return(&service.ProviderData{ProviderList: []*service.ProviderTotal{{Name: "NordVPN", Ranges: 2, Total: 512}}}, nil)
+	mockService.EXPECT().GetProviderRanges("NordVPN", "", 10).Return(&service.IPRangeData{Total: 1, RangeList: []*service.IPRange{{IPFrom: 16777216, IPTo: 16777471, CountryCode: "AU"}}}, nil)
+	mockService.EXPECT().GetProviderRanges("Unknown", "", 10).Return(nil, service.NoResultError("test"))
+	mockService.EXPECT().GetThreats("PL", "1299").Return(&service.ThreatData{ThreatList: []*service.ThreatTotal{{Name: "SPAM", Ranges: 2, Total: 512}}}, nil)
+	mockService.EXPECT().GetThreatRanges("SPAM", "", "", 10).Return(&service.IPRangeData{Total: 1, RangeList: []*service.IPRange{{IPFrom: 16777216, IPTo: 16777471, CountryCode: "AU"}}}, nil)
+	mockService.EXPECT().GetThreatRanges("BOTNET", "", "", 10).Return(nil, service.NoResultError("test"))
+	mockService.EXPECT().DatasetVersion().Return("2021-06-01 10:00:00", nil).AnyTimes()
+
+	handler := validator.Middleware(router(t, mockService))
+
+	requests := []*http.Request{
+		httptest.NewRequest("GET", "/ip/10.10.10.1", nil),
+		httptest.NewRequest("GET", "/ip/10.10.10.2", nil),
+		httptest.NewRequest("GET", "/me", nil),
+		httptest.NewRequest("GET", "/country/AR?limit=10", nil),
+		httptest.NewRequest("GET", "/country/AR/isp", nil),
+		httptest.NewRequest("GET", "/country/AR/total", nil),
+		httptest.NewRequest("GET", "/proxytypes", nil),
+		httptest.NewRequest("GET", "/v1/proxytypes", nil),
+		httptest.NewRequest("GET", "/ip/10.10.10.5?fields=proxy_type,country_code", nil),
+		httptest.NewRequest("GET", "/v2/ip/10.10.10.5?fields=asn", nil),
+		httptest.NewRequest("GET", "/ip/10.10.10.5?fields=color", nil),
+		httptest.NewRequest("GET", "/ip/10.10.10.4?format=csv", nil),
+		httptest.NewRequest("GET", "/ip/10.10.10.4?format=xml", nil),
+		httptest.NewRequest("GET", "/ip/10.10.10.4?format=msgpack", nil),
+		httptest.NewRequest("GET", "/ip/10.10.10.4?format=protobuf", nil),
+		httptest.NewRequest("GET", "/v2/ip/10.10.10.4?format=csv", nil),
+		httptest.NewRequest("GET", "/v2/ip/10.10.10.4?format=xml", nil),
+		httptest.NewRequest("GET", "/v2/ip/10.10.10.4?format=protobuf", nil),
+		httptest.NewRequest("GET", "/v2/ip/10.10.10.asd?format=csv", nil),
+		httptest.NewRequest("GET", "/v2/codes?format=protobuf", nil),
+		httptest.NewRequest("GET", "/v2/ip/10.10.10.3", nil),
+		httptest.NewRequest("GET", "/v2/ip/10.10.10.asd", nil),
+		httptest.NewRequest("GET", "/v2/me", nil),
+		httptest.NewRequest("GET", "/v2/country/AR?limit=10&offset=5", nil),
+		httptest.NewRequest("GET", "/v2/country/AR/isp", nil),
+		httptest.NewRequest("GET", "/v2/country/AR/total", nil),
+		httptest.NewRequest("GET", "/v2/proxytypes", nil),
+		httptest.NewRequest("GET", "/v2/provider?country=PL", nil),
+		httptest.NewRequest("GET", "/v2/provider/NordVPN?limit=10", nil),
+		httptest.NewRequest("GET", "/v2/provider/Unknown?limit=10", nil),
+		httptest.NewRequest("GET", "/v2/provider/Nord%3BVPN", nil),
+		httptest.NewRequest("GET", "/v2/threat?country=PL&asn=1299", nil),
+		httptest.NewRequest("GET", "/v2/threat/SPAM?limit=10", nil),
+		httptest.NewRequest("GET", "/v2/threat/BOTNET?limit=10", nil),
+		httptest.NewRequest("GET", "/v2/codes", nil),
+		httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "{ country(code: \"PL\") { total } }"}`)),
+		httptest.NewRequest("GET", "/openapi.json", nil),
+		httptest.NewRequest("GET", "/docs", nil),
+		httptest.NewRequest("GET", "/healthz", nil),
+		httptest.NewRequest("GET", "/readyz", nil),
+		httptest.NewRequest("GET", "/status", nil),
+		httptest.NewRequest("GET", "/metrics", nil),
+	}
+
+	//None of the formats
+	notAcceptable := httptest.NewRequest("GET", "/ip/10.10.10.4", nil)
+	notAcceptable.Header.Set("Accept", "text/html")
+	requests = append(requests, notAcceptable)
+
+	for _, r := range requests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		body, _ := ioutil.ReadAll(w.Result().Body)
+
+		err := validator.Validate(r, w.Code, w.Header(), body)
+		assert.Nil(t, err, r.URL.Path)
+	}
+
+	//Probes of an instance that is not ready
+	notReady := &probes.Checker{DB: pinger{err: errors.New("connection refused")}}
+	for path, probe := range map[string]http.HandlerFunc{"/readyz": notReady.Readyz, "/status": notReady.Status} {
+		r := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		probe(w, r)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Nil(t, validator.Validate(r, w.Code, w.Header(), w.Body.Bytes()), path)
+	}
+}
+
+func TestValidateRejectsWrongShape(t *testing.T) {
+
+	validator, _ := NewValidator()
+
+	r := httptest.NewRequest("GET", "/country/AR/total", nil)
+	header := http.Header{}
+	header.Set(CONTENTYPE, APPJSON)
+
+	err := validator.Validate(r, http.StatusOK, header, []byte(`{"total": 10}`))
+
+	assert.NotNil(t, err)
+}
+
+// TestEveryRouteDocumented keeps the document in sync with the router
+func TestEveryRouteDocumented(t *testing.T) {
+
+	doc := struct {
+		Paths map[string]interface{} `json:"paths"`
+	}{}
+	assert.Nil(t, json.Unmarshal(Spec, &doc))
+
+	//Mocked service setup
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	router(t, mocks.NewMockService(controller)).Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		//Subrouter prefixes are not routes themselves
+		if route.GetHandler() == nil {
+			return nil
+		}
+		template, _ := route.GetPathTemplate()
+		path := regexp.MustCompile(`\{(\w+):[^}]*\}`).ReplaceAllString(template, "{$1}")
+		_, ok := doc.Paths[path]
+		assert.True(t, ok, "%s is not documented", path)
+		return nil
+	})
+}
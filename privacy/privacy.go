@@ -0,0 +1,124 @@
+package privacy
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+)
+
+const (
+	OFF      = "off"
+	TRUNCATE = "truncate"
+	HASH     = "hash"
+	//IPV4BITS and IPV6BITS are the prefixes kept when truncating, the rest of the address is zeroed
+	IPV4BITS = 24
+	IPV6BITS = 48
+	//HASHPREFIX marks the hashed addresses, HASHBYTES of the HMAC are kept
+	HASHPREFIX = "anon-"
+	HASHBYTES  = 8
+	//MASKED replaces the decimal addresses of the SQL statements when hashing
+	MASKED = "?"
+	//DEFAULTRETENTIONDAYS is how long access logs are kept in privacy mode when nothing else is configured
+	DEFAULTRETENTIONDAYS = 30
+	BADMODE              = "Unknown privacy mode %q, it must be off, truncate or hash"
+)
+
+var (
+	ipv4Text = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+	ipv6Text = regexp.MustCompile(`[0-9A-Fa-f]{0,4}(?::[0-9A-Fa-f]{0,4}){2,7}`)
+	//Decimal addresses compared with the range bounds, like the lookup query "ip_from <= %d AND %d <= ip_to"
+	sqlAddress = regexp.MustCompile(`(\b(?:ip_from|ip_to)\s*(?:<=|>=|<|>|=)\s*)(\d+)\b|\b(\d+)(\s*(?:<=|>=|<|>|=)\s*(?:ip_from|ip_to)\b)`)
+)
+
+// Anonymizer rewrites the addresses written to logs, access records and traces.
+// A nil Anonymizer is privacy mode off, it leaves everything as it is.
+type Anonymizer struct {
+	mode string
+	salt []byte
+}
+
+// New creates the anonymizer of a mode, nil for off. Hashing without a salt uses a random one,
+// so the hashes of an address can't be matched across restarts.
+func New(mode string, salt string) (*Anonymizer, error) {
+	switch mode {
+	case "", OFF:
+		return nil, nil
+	case TRUNCATE:
+		return &Anonymizer{mode: mode}, nil
+	case HASH:
+		key := []byte(salt)
+		if len(key) == 0 {
+			key = make([]byte, sha256.Size)
+			rand.Read(key)
+		}
+		return &Anonymizer{mode: mode, salt: key}, nil
+	}
+	return nil, fmt.Errorf(BADMODE, mode)
+}
+
+// Enabled tells if addresses are being rewritten
+func (a *Anonymizer) Enabled() bool {
+	return a != nil
+}
+
+// IP is the anonymized form of an address
+func (a *Anonymizer) IP(ip net.IP) string {
+	if a == nil || ip == nil {
+		return ip.String()
+	}
+	if a.mode == HASH {
+		return a.hash(ip)
+	}
+	if ipv4 := ip.To4(); ipv4 != nil {
+		return ipv4.Mask(net.CIDRMask(IPV4BITS, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(IPV6BITS, 128)).String()
+}
+
+// String rewrites every address written in the text, and the decimal addresses of SQL range conditions
+func (a *Anonymizer) String(text string) string {
+	if a == nil {
+		return text
+	}
+	text = sqlAddress.ReplaceAllStringFunc(text, a.sqlAddress)
+	text = ipv6Text.ReplaceAllStringFunc(text, a.textAddress)
+	return ipv4Text.ReplaceAllStringFunc(text, a.textAddress)
+}
+
+// textAddress rewrites a match when it really is an address
+func (a *Anonymizer) textAddress(match string) string {
+	ip := net.ParseIP(match)
+	if ip == nil {
+		return match
+	}
+	return a.IP(ip)
+}
+
+// sqlAddress rewrites the decimal of a range condition, truncated as well or masked when hashing
+func (a *Anonymizer) sqlAddress(match string) string {
+	parts := sqlAddress.FindStringSubmatch(match)
+	before, number, after := parts[1], parts[2], parts[4]
+	if number == "" {
+		number = parts[3]
+	}
+
+	replacement := MASKED
+	if a.mode == TRUNCATE {
+		if decimal, err := strconv.ParseUint(number, 10, 32); err == nil {
+			truncated := decimal &^ (1<<(32-IPV4BITS) - 1)
+			replacement = strconv.FormatUint(truncated, 10)
+		}
+	}
+	return before + replacement + after
+}
+
+func (a *Anonymizer) hash(ip net.IP) string {
+	mac := hmac.New(sha256.New, a.salt)
+	mac.Write(ip.To16())
+	return HASHPREFIX + hex.EncodeToString(mac.Sum(nil)[:HASHBYTES])
+}
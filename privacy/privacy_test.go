@@ -0,0 +1,56 @@
+package privacy
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncate(t *testing.T) {
+
+	a, err := New(TRUNCATE, "")
+	assert.Nil(t, err)
+
+	assert.Equal(t, "1.2.3.0", a.IP(net.ParseIP("1.2.3.4")))
+	assert.Equal(t, "2001:db8:1::", a.IP(net.ParseIP("2001:db8:1:2:3:4:5:6")))
+
+	//Addresses in text, the decimal ones of the lookup query included
+	assert.Equal(t, "Service error address=1.2.3.0 from 2001:db8:1::", a.String("Service error address=1.2.3.4 from 2001:db8:1:2::7"))
+	assert.Equal(t, "SELECT proxy_type FROM ip2proxy_database where ip_from <= 16909056 AND 16909056 <= ip_to;",
+		a.String("SELECT proxy_type FROM ip2proxy_database where ip_from <= 16909060 AND 16909060 <= ip_to;"))
+
+	//Other numbers and times are left alone
+	assert.Equal(t, "[19/Oct/2026:12:00:00 +0000] LIMIT 50 version 1.2.3", a.String("[19/Oct/2026:12:00:00 +0000] LIMIT 50 version 1.2.3"))
+}
+
+func TestHash(t *testing.T) {
+
+	a, _ := New(HASH, "salt")
+	b, _ := New(HASH, "salt")
+	other, _ := New(HASH, "pepper")
+
+	hashed := a.IP(net.ParseIP("1.2.3.4"))
+	assert.True(t, strings.HasPrefix(hashed, HASHPREFIX))
+	assert.Equal(t, len(HASHPREFIX)+2*HASHBYTES, len(hashed))
+
+	//The same salt matches the same address, so a user can still be followed without knowing the address
+	assert.Equal(t, hashed, b.IP(net.ParseIP("1.2.3.4")))
+	assert.NotEqual(t, hashed, a.IP(net.ParseIP("1.2.3.5")))
+	assert.NotEqual(t, hashed, other.IP(net.ParseIP("1.2.3.4")))
+
+	assert.Equal(t, "ip_from <= ? AND ? <= ip_to for "+hashed, a.String("ip_from <= 16909060 AND 16909060 <= ip_to for 1.2.3.4"))
+}
+
+func TestOff(t *testing.T) {
+
+	a, err := New(OFF, "")
+	assert.Nil(t, err)
+	assert.False(t, a.Enabled())
+	assert.Equal(t, "1.2.3.4", a.IP(net.ParseIP("1.2.3.4")))
+	assert.Equal(t, "ip_from <= 16909060", a.String("ip_from <= 16909060"))
+
+	_, err = New("mask", "")
+	assert.Equal(t, "Unknown privacy mode \"mask\", it must be off, truncate or hash", err.Error())
+}
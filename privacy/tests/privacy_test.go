@@ -0,0 +1,122 @@
+package privacy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nullc0rp/go-ip2proxy-api/accesslog"
+	"github.com/nullc0rp/go-ip2proxy-api/controller"
+	"github.com/nullc0rp/go-ip2proxy-api/database"
+	"github.com/nullc0rp/go-ip2proxy-api/logging"
+	"github.com/nullc0rp/go-ip2proxy-api/metrics"
+	"github.com/nullc0rp/go-ip2proxy-api/privacy"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+	"github.com/nullc0rp/go-ip2proxy-api/tracing"
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const (
+	ADDRESS = "1.2.3.4"
+	DECIMAL = "16909060"
+	LOOKUP  = "ip_from <= 16909060 AND 16909060 <= ip_to"
+)
+
+// exporter keeps the spans in memory, the tracers of the layers only take the first provider registered
+var exporter = tracetest.NewInMemoryExporter()
+
+func init() {
+	tracing.Register(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+}
+
+// serve sends the lookups of a client at ADDRESS through every layer of the server and returns what they wrote
+func serve(t *testing.T, anonymizer *privacy.Anonymizer) map[string]string {
+
+	exporter.Reset()
+
+	// Create mock database, the lookup finds a proxy, nothing and then fails
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	mock.MatchExpectationsInOrder(false)
+
+	mock.ExpectQuery(LOOKUP).WillReturnRows(sqlmock.NewRows([]string{"proxy_type"}).AddRow("PUB"))
+	mock.ExpectQuery(LOOKUP).WillReturnRows(sqlmock.NewRows([]string{"proxy_type"}))
+	mock.ExpectQuery(LOOKUP).WillReturnError(errors.New("Error 1205: lock wait timeout on " + LOOKUP))
+	mock.ExpectQuery(LOOKUP).WillReturnRows(sqlmock.NewRows([]string{"proxy_type", "country_code", "country_name", "region_name", "city_name", "isp", "domain", "usage_type", "asn", "as"}).
+		AddRow("PUB", "PL", "Poland", "Mazowieckie", "Warsaw", "Opera Software ASA", "opera.com", "DCH", "1299", "as"))
+	for i := 0; i < 4; i++ {
+		mock.ExpectQuery("SELECT version FROM ip2proxy_metadata").WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow("2024-01-01 00:00:00"))
+	}
+
+	//Every writer of the server, at its most verbose
+	var logs, accessLogs bytes.Buffer
+	loggers := logging.NewWriter(&logs, slog.LevelDebug).Anonymize(anonymizer)
+	defaultLogger := slog.Default()
+	slog.SetDefault(loggers.For("main"))
+	defer slog.SetDefault(defaultLogger)
+
+	svc := &service.ServiceImp{
+		DB:     &database.DatabaseImpl{Connection: db, Logger: loggers.For("database"), Anonymizer: anonymizer},
+		Logger: loggers.For("service"),
+	}
+	metricsInstance := metrics.New()
+	router := controller.NewRouter(
+		&controller.ControllerImpl{Service: svc, Logger: loggers.For("controller")},
+		&controller.ControllerV2Impl{Service: svc, Logger: loggers.For("controller")},
+	)
+	router.Handle("/metrics", metricsInstance.Handler()).Methods("GET")
+	router.Use(metricsInstance.Middleware)
+	router.Use(tracing.Middleware(anonymizer))
+	router.Use(logging.Middleware)
+	router.Use(accesslog.NewWriter(accesslog.JSON, &accessLogs, nil).Anonymize(anonymizer).Middleware)
+
+	for _, path := range []string{"/v2/ip/" + ADDRESS + "?fields=proxy_type", "/v2/ip/" + ADDRESS + "?fields=proxy_type", "/v2/ip/" + ADDRESS + "?fields=proxy_type", "/ip/" + ADDRESS, "/v2/me?fields=proxy_type"} {
+		r := httptest.NewRequest("GET", path, nil)
+		r.RemoteAddr = ADDRESS + ":51234"
+		r.Header.Set("Referer", "https://example.com/lookup?ip="+ADDRESS)
+		router.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	var spans strings.Builder
+	for _, span := range exporter.GetSpans() {
+		fmt.Fprintln(&spans, span.Name, span.Attributes, span.Status, span.Events)
+	}
+
+	return map[string]string{"logs": logs.String(), "access log": accessLogs.String(), "metrics": w.Body.String(), "spans": spans.String()}
+}
+
+func TestNoAddressWritten(t *testing.T) {
+
+	for _, mode := range []string{privacy.TRUNCATE, privacy.HASH} {
+		anonymizer, err := privacy.New(mode, "salt")
+		assert.Nil(t, err)
+
+		for name, text := range serve(t, anonymizer) {
+			assert.NotEmpty(t, text, name)
+			assert.NotContains(t, text, ADDRESS, "%s in %s mode", name, mode)
+			assert.NotContains(t, text, DECIMAL, "%s in %s mode", name, mode)
+		}
+	}
+}
+
+func TestAddressWrittenWhenOff(t *testing.T) {
+
+	//Without privacy mode the same requests do write the address, the test above would catch a leak
+	written := serve(t, nil)
+	assert.Contains(t, written["logs"], DECIMAL)
+	assert.Contains(t, written["access log"], ADDRESS)
+	assert.Contains(t, written["spans"], DECIMAL)
+}
@@ -0,0 +1,194 @@
+package probes
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+const (
+	CONTENTYPE      = "Content-Type"
+	APPLICATIONJSON = "application/json"
+	//Results of the checks
+	OK          = "ok"
+	READY       = "ready"
+	NOTREADY    = "not ready"
+	UNREACHABLE = "unreachable"
+	NOTLOADED   = "not loaded"
+	INPROGRESS  = "in progress"
+	//PINGTIMEOUT bounds the database check, probes give up on slow answers anyway
+	PINGTIMEOUT = 2 * time.Second
+	PINGFAILED  = "Database ping failed"
+)
+
+// Pinger is the database connection, *sql.DB is one
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// Dataset is the loaded dataset
+type Dataset struct {
+	Version string    `json:"version"`
+	Rows    int       `json:"rows"`
+	Loaded  time.Time `json:"loaded"`
+}
+
+// Build identifies the running binary, from the build information of the Go toolchain
+type Build struct {
+	GoVersion string `json:"go_version"`
+	Version   string `json:"version,omitempty"`
+	Revision  string `json:"revision,omitempty"`
+	Time      string `json:"time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+}
+
+// Readiness is the answer of /readyz, each check is ok or says what is wrong
+type Readiness struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// Status is the answer of /status
+type Status struct {
+	Readiness
+	Started   time.Time `json:"started"`
+	Uptime    float64   `json:"uptime_seconds"`
+	Dataset   *Dataset  `json:"dataset"`
+	Reloading bool      `json:"reloading"`
+	Build     Build     `json:"build"`
+}
+
+// started is when the process started, the uptime counts from it
+var started = time.Now()
+
+// Checker answers the probes of the orchestrator. The dataset is reported by the server once loaded,
+// until then and while it is being reloaded the instance is not ready.
+type Checker struct {
+	DB Pinger
+	//Logger writes the failed checks, slog.Default() when nil
+	Logger *slog.Logger
+
+	mutex     sync.RWMutex
+	dataset   *Dataset
+	reloading bool
+}
+
+// SetDataset reports the loaded dataset, an empty one keeps the instance not ready
+func (c *Checker) SetDataset(version string, loaded time.Time, rows int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.dataset = &Dataset{Version: version, Rows: rows, Loaded: loaded}
+}
+
+// Reloading tells the checker a dataset reload started or ended
+func (c *Checker) Reloading(reloading bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.reloading = reloading
+}
+
+// Healthz is the liveness probe, the process answers
+func (c *Checker) Healthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": OK})
+}
+
+// Readyz is the readiness probe: the database answers, a dataset is loaded and it is not being reloaded
+func (c *Checker) Readyz(w http.ResponseWriter, r *http.Request) {
+	readiness := c.ready(r.Context())
+	writeJSON(w, code(readiness), readiness)
+}
+
+// Status details the readiness, the dataset, the uptime and the build
+func (c *Checker) Status(w http.ResponseWriter, r *http.Request) {
+	readiness := c.ready(r.Context())
+
+	c.mutex.RLock()
+	status := Status{
+		Readiness: readiness,
+		Started:   started,
+		Uptime:    time.Since(started).Seconds(),
+		Dataset:   c.dataset,
+		Reloading: c.reloading,
+		Build:     build(),
+	}
+	c.mutex.RUnlock()
+
+	writeJSON(w, code(readiness), status)
+}
+
+// ready runs the checks, the failed ones are logged with their error
+func (c *Checker) ready(ctx context.Context) Readiness {
+	readiness := Readiness{Status: READY, Checks: map[string]string{"database": OK, "dataset": OK, "reload": OK}}
+
+	ctx, cancel := context.WithTimeout(ctx, PINGTIMEOUT)
+	defer cancel()
+	if err := c.DB.PingContext(ctx); err != nil {
+		c.logger().ErrorContext(ctx, PINGFAILED, "error", err)
+		readiness.Checks["database"] = UNREACHABLE
+	}
+
+	c.mutex.RLock()
+	if c.dataset == nil || c.dataset.Rows == 0 {
+		readiness.Checks["dataset"] = NOTLOADED
+	}
+	if c.reloading {
+		readiness.Checks["reload"] = INPROGRESS
+	}
+	c.mutex.RUnlock()
+
+	for _, result := range readiness.Checks {
+		if result != OK {
+			readiness.Status = NOTREADY
+		}
+	}
+	return readiness
+}
+
+func (c *Checker) logger() *slog.Logger {
+	if c.Logger == nil {
+		return slog.Default()
+	}
+	return c.Logger
+}
+
+func code(readiness Readiness) int {
+	if readiness.Status != READY {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+// build reads the module version and the VCS stamp of the binary
+func build() Build {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return Build{}
+	}
+	result := Build{GoVersion: info.GoVersion, Version: info.Main.Version}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			result.Revision = setting.Value
+		case "vcs.time":
+			result.Time = setting.Value
+		case "vcs.modified":
+			result.Modified = setting.Value == "true"
+		}
+	}
+	return result
+}
+
+func writeJSON(w http.ResponseWriter, code int, value any) {
+	jData, err := json.Marshal(value)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(CONTENTYPE, APPLICATIONJSON)
+	w.WriteHeader(code)
+	w.Write(jData)
+}
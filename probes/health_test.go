@@ -0,0 +1,85 @@
+package probes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// probe sends a request to the handler and decodes its answer
+func probe(t *testing.T, handler http.HandlerFunc, path string, answer interface{}) int {
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", path, nil))
+	assert.Equal(t, APPLICATIONJSON, w.Header().Get(CONTENTYPE))
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), answer))
+	return w.Code
+}
+
+func TestHealthz(t *testing.T) {
+
+	//Alive even without a database nor a dataset
+	checker := &Checker{}
+	answer := map[string]string{}
+	assert.Equal(t, http.StatusOK, probe(t, checker.Healthz, "/healthz", &answer))
+	assert.Equal(t, OK, answer["status"])
+}
+
+func TestReadyz(t *testing.T) {
+
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	checker := &Checker{DB: db}
+
+	//No dataset yet
+	mock.ExpectPing()
+	readiness := Readiness{}
+	assert.Equal(t, http.StatusServiceUnavailable, probe(t, checker.Readyz, "/readyz", &readiness))
+	assert.Equal(t, Readiness{Status: NOTREADY, Checks: map[string]string{"database": OK, "dataset": NOTLOADED, "reload": OK}}, readiness)
+
+	//Loaded
+	checker.SetDataset("2024-01-01 00:00:00", time.Now(), 1000)
+	mock.ExpectPing()
+	readiness = Readiness{}
+	assert.Equal(t, http.StatusOK, probe(t, checker.Readyz, "/readyz", &readiness))
+	assert.Equal(t, Readiness{Status: READY, Checks: map[string]string{"database": OK, "dataset": OK, "reload": OK}}, readiness)
+
+	//Reloading and the database gone, the error is not sent
+	checker.Reloading(true)
+	mock.ExpectPing().WillReturnError(errors.New("dial tcp 10.0.0.5:3306: connection refused"))
+	w := httptest.NewRecorder()
+	checker.Readyz(w, httptest.NewRequest("GET", "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, `{"status": "not ready", "checks": {"database": "unreachable", "dataset": "ok", "reload": "in progress"}}`, w.Body.String())
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestStatus(t *testing.T) {
+
+	db, mock, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	defer db.Close()
+	mock.ExpectPing()
+
+	checker := &Checker{DB: db}
+	loaded := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	checker.SetDataset("2024-01-01 00:00:00", loaded, 1000)
+
+	status := Status{}
+	assert.Equal(t, http.StatusOK, probe(t, checker.Status, "/status", &status))
+	assert.Equal(t, READY, status.Status)
+	assert.Equal(t, &Dataset{Version: "2024-01-01 00:00:00", Rows: 1000, Loaded: loaded}, status.Dataset)
+	assert.False(t, status.Reloading)
+	assert.True(t, status.Uptime >= 0)
+	assert.NotEmpty(t, status.Build.GoVersion)
+}
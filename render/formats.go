@@ -0,0 +1,454 @@
+package render
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/nullc0rp/go-ip2proxy-api/ip2proxypb"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	APPJSON     = "application/json"
+	TEXTCSV     = "text/csv; charset=utf-8"
+	APPXML      = "application/xml; charset=utf-8"
+	APPMSGPACK  = "application/msgpack"
+	APPPROTOBUF = "application/x-protobuf"
+	NOMESSAGE   = "%w for %T"
+)
+
+// ErrNoMessage is returned for the protobuf format when a value has no message
+var ErrNoMessage = errors.New("No protobuf message")
+
+// Message is implemented by the results without a service model, to give their protobuf message
+type Message interface {
+	Proto() proto.Message
+}
+
+// Payload is implemented by envelopes. CSV and protobuf can't hold them, they write the payload alone,
+// and a payload that is a slice is written as the rows or messages of its items.
+type Payload interface {
+	Payload() interface{}
+}
+
+// payload is the value written by the formats without envelopes
+func payload(value interface{}) interface{} {
+	if envelope, ok := value.(Payload); ok {
+		return envelope.Payload()
+	}
+	return value
+}
+
+// jsonEncoder keeps the output of json.Marshal, lists included
+type jsonEncoder struct{}
+
+func (jsonEncoder) ContentType() string {
+	return APPJSON
+}
+
+func (jsonEncoder) Encode(w *bytes.Buffer, value interface{}) error {
+	jData, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(jData)
+	return err
+}
+
+func (jsonEncoder) EncodeList(w http.ResponseWriter, list *List) error {
+	io.WriteString(w, "{")
+	for _, field := range list.Fields {
+		jData, err := json.Marshal(field.Value)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%q:%s,", field.Name, jData)
+	}
+	fmt.Fprintf(w, "%q:", list.Name)
+
+	if list.Items.IsNil() {
+		_, err := io.WriteString(w, "null}")
+		return err
+	}
+
+	io.WriteString(w, "[")
+	for i := 0; i < list.Items.Len(); i++ {
+		jData, err := json.Marshal(list.Items.Index(i).Interface())
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			io.WriteString(w, ",")
+		}
+		w.Write(jData)
+		flush(w, i+1)
+	}
+	_, err := io.WriteString(w, "]}")
+	return err
+}
+
+// csvEncoder writes a header row with the field names and a row per value.
+// Lists are the rows of their items, the other fields are left out.
+type csvEncoder struct{}
+
+func (csvEncoder) ContentType() string {
+	return TEXTCSV
+}
+
+func (csvEncoder) Encode(w *bytes.Buffer, value interface{}) error {
+	writer := csv.NewWriter(w)
+	value = payload(value)
+
+	if items := reflect.ValueOf(value); items.Kind() == reflect.Slice {
+		cols := csvColumns(items.Type().Elem())
+		if len(cols) == 0 {
+			cols = []column{{name: itemName(items.Type().Elem())}}
+		}
+		writer.Write(header(cols))
+		for i := 0; i < items.Len(); i++ {
+			writer.Write(row(items.Index(i), cols))
+		}
+	} else if record, ok := value.(*Record); ok {
+		names := []string{}
+		values := []string{}
+		for _, field := range record.Fields {
+			names = append(names, field.Name)
+			values = append(values, fmt.Sprint(field.Value))
+		}
+		writer.Write(names)
+		writer.Write(values)
+	} else {
+		v := reflect.ValueOf(value)
+		cols := csvColumns(v.Type())
+		writer.Write(header(cols))
+		writer.Write(row(v, cols))
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func (csvEncoder) EncodeList(w http.ResponseWriter, list *List) error {
+	cols := csvColumns(list.Items.Type().Elem())
+
+	writer := csv.NewWriter(w)
+	if len(cols) == 0 {
+		//Lists of plain values are a single column named after the list
+		cols = []column{{name: list.Name}}
+	}
+	writer.Write(header(cols))
+	for i := 0; i < list.Items.Len(); i++ {
+		writer.Write(row(list.Items.Index(i), cols))
+		if (i+1)%FLUSHEVERY == 0 {
+			writer.Flush()
+			flush(w, i+1)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvColumns are the columns of a type without the ones left out of CSV
+func csvColumns(t reflect.Type) []column {
+	cols := []column{}
+	for _, col := range columns(t) {
+		if !col.omitCSV {
+			cols = append(cols, col)
+		}
+	}
+	return cols
+}
+
+func header(cols []column) []string {
+	names := []string{}
+	for _, col := range cols {
+		names = append(names, col.name)
+	}
+	return names
+}
+
+func row(v reflect.Value, cols []column) []string {
+	values := []string{}
+	for _, col := range cols {
+		if col.index == nil {
+			values = append(values, fmt.Sprint(reflect.Indirect(v).Interface()))
+			continue
+		}
+		field, ok := fieldValue(v, col.index)
+		if !ok {
+			values = append(values, "")
+			continue
+		}
+		values = append(values, fmt.Sprint(field.Interface()))
+	}
+	return values
+}
+
+// xmlEncoder names the elements like the JSON keys, the root after the result type
+type xmlEncoder struct{}
+
+func (xmlEncoder) ContentType() string {
+	return APPXML
+}
+
+func (xmlEncoder) Encode(w *bytes.Buffer, value interface{}) error {
+	encoder := xml.NewEncoder(w)
+
+	if record, ok := value.(*Record); ok {
+		root := xml.StartElement{Name: xml.Name{Local: record.Name}}
+		if err := encoder.EncodeToken(root); err != nil {
+			return err
+		}
+		for _, field := range record.Fields {
+			if err := writeXML(encoder, field.Name, reflect.ValueOf(field.Value)); err != nil {
+				return err
+			}
+		}
+		if err := encoder.EncodeToken(root.End()); err != nil {
+			return err
+		}
+		return encoder.Flush()
+	}
+
+	v := reflect.ValueOf(value)
+	if err := writeXML(encoder, reflect.Indirect(v).Type().Name(), v); err != nil {
+		return err
+	}
+	return encoder.Flush()
+}
+
+func (xmlEncoder) EncodeList(w http.ResponseWriter, list *List) error {
+	encoder := xml.NewEncoder(w)
+	root := xml.StartElement{Name: xml.Name{Local: list.Root}}
+	items := xml.StartElement{Name: xml.Name{Local: list.Name}}
+
+	if err := encoder.EncodeToken(root); err != nil {
+		return err
+	}
+	for _, field := range list.Fields {
+		if err := writeXML(encoder, field.Name, reflect.ValueOf(field.Value)); err != nil {
+			return err
+		}
+	}
+	if err := encoder.EncodeToken(items); err != nil {
+		return err
+	}
+	for i := 0; i < list.Items.Len(); i++ {
+		item := list.Items.Index(i)
+		if err := writeXML(encoder, itemName(item.Type()), item); err != nil {
+			return err
+		}
+		if (i+1)%FLUSHEVERY == 0 {
+			if err := encoder.Flush(); err != nil {
+				return err
+			}
+			flush(w, i+1)
+		}
+	}
+	if err := encoder.EncodeToken(items.End()); err != nil {
+		return err
+	}
+	if err := encoder.EncodeToken(root.End()); err != nil {
+		return err
+	}
+	return encoder.Flush()
+}
+
+// writeXML writes structs as an element per field and slices as an element per item
+func writeXML(encoder *xml.Encoder, name string, v reflect.Value) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return encoder.EncodeElement("", start)
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if err := encoder.EncodeToken(start); err != nil {
+			return err
+		}
+		for _, col := range columns(v.Type()) {
+			field, ok := fieldValue(v, col.index)
+			if !ok {
+				continue
+			}
+			if err := writeXML(encoder, col.name, field); err != nil {
+				return err
+			}
+		}
+		return encoder.EncodeToken(start.End())
+	case reflect.Slice:
+		if err := encoder.EncodeToken(start); err != nil {
+			return err
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := writeXML(encoder, itemName(v.Type().Elem()), v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return encoder.EncodeToken(start.End())
+	default:
+		return encoder.EncodeElement(fmt.Sprint(v.Interface()), start)
+	}
+}
+
+// itemName is the element of a list item, its type name or item for plain values
+func itemName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t.Name() == "" {
+		return "item"
+	}
+	return t.Name()
+}
+
+// msgpackEncoder uses the JSON keys, so both formats decode into the same shapes
+type msgpackEncoder struct{}
+
+func (msgpackEncoder) ContentType() string {
+	return APPMSGPACK
+}
+
+func (msgpackEncoder) Encode(w *bytes.Buffer, value interface{}) error {
+	return newMsgpack(w).Encode(value)
+}
+
+func (msgpackEncoder) EncodeList(w http.ResponseWriter, list *List) error {
+	encoder := newMsgpack(w)
+
+	encoder.EncodeMapLen(len(list.Fields) + 1)
+	for _, field := range list.Fields {
+		encoder.EncodeString(field.Name)
+		if err := encoder.Encode(field.Value); err != nil {
+			return err
+		}
+	}
+	encoder.EncodeString(list.Name)
+
+	if list.Items.IsNil() {
+		return encoder.EncodeNil()
+	}
+	if err := encoder.EncodeArrayLen(list.Items.Len()); err != nil {
+		return err
+	}
+	for i := 0; i < list.Items.Len(); i++ {
+		if err := encoder.Encode(list.Items.Index(i).Interface()); err != nil {
+			return err
+		}
+		flush(w, i+1)
+	}
+	return nil
+}
+
+func newMsgpack(w io.Writer) *msgpack.Encoder {
+	encoder := msgpack.NewEncoder(w)
+	encoder.SetCustomStructTag(JSON)
+	return encoder
+}
+
+// protobufEncoder writes the ip2proxy.v1 messages, lists are a stream of length-delimited item messages
+type protobufEncoder struct{}
+
+func (protobufEncoder) ContentType() string {
+	return APPPROTOBUF
+}
+
+func (protobufEncoder) Encode(w *bytes.Buffer, value interface{}) error {
+	value = payload(value)
+
+	if items := reflect.ValueOf(value); items.Kind() == reflect.Slice {
+		for i := 0; i < items.Len(); i++ {
+			message, err := toProto(items.Index(i).Interface())
+			if err != nil {
+				return err
+			}
+			if _, err := protodelim.MarshalTo(w, message); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	message, err := toProto(value)
+	if err != nil {
+		return err
+	}
+	data, err := proto.Marshal(message)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func (protobufEncoder) EncodeList(w http.ResponseWriter, list *List) error {
+	for i := 0; i < list.Items.Len(); i++ {
+		message, err := toProto(list.Items.Index(i).Interface())
+		if err != nil {
+			return err
+		}
+		if _, err := protodelim.MarshalTo(w, message); err != nil {
+			return err
+		}
+		flush(w, i+1)
+	}
+	return nil
+}
+
+// toProto maps the service models to the messages of the gRPC API
+func toProto(value interface{}) (proto.Message, error) {
+	switch v := value.(type) {
+	case proto.Message:
+		return v, nil
+	case Message:
+		return v.Proto(), nil
+	case *Record:
+		if v.Message != nil {
+			return v.Message, nil
+		}
+	case *service.IPData:
+		return ProtoIPData(v), nil
+	case *service.IPDataResult:
+		return &ip2proxypb.CountryIP{Ip: v.IP, CountryName: v.CountryName, CityName: v.CityName}, nil
+	case *service.ISPDataResult:
+		return &ip2proxypb.ISP{Name: v.Name}, nil
+	case *service.MostProxyType:
+		return &ip2proxypb.ProxyTypeStat{ProxyType: v.ProxyType, Total: int64(v.Total)}, nil
+	case *service.IPCountryTotal:
+		return &ip2proxypb.CountryTotalResponse{Total: int64(v.Total)}, nil
+	}
+	return nil, fmt.Errorf(NOMESSAGE, ErrNoMessage, value)
+}
+
+// ProtoIPData maps the service model to the protobuf message, the gRPC API answers with it too
+func ProtoIPData(data *service.IPData) *ip2proxypb.IPData {
+	return &ip2proxypb.IPData{
+		ProxyType:   data.ProxyType,
+		CountryCode: data.CountryCode,
+		CountryName: data.CountryName,
+		RegionName:  data.RegionName,
+		CityName:    data.CityName,
+		Isp:         data.ISP,
+		Domain:      data.Domain,
+		UsageType:   data.UsageType,
+		Asn:         data.ASN,
+		As:          data.AS,
+		LastSeen:    data.LastSeen,
+		Threat:      data.Threat,
+		Provider:    data.Provider,
+		FraudScore:  data.FraudScore,
+	}
+}
@@ -0,0 +1,290 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	FORMAT     = "format"
+	ACCEPT     = "Accept"
+	CONTENTYPE = "Content-Type"
+	JSON       = "json"
+	CSV        = "csv"
+	XML        = "xml"
+	MSGPACK    = "msgpack"
+	PROTOBUF   = "protobuf"
+	ANYTYPE    = "*/*"
+	ANYAPP     = "application/*"
+	//FLUSHEVERY is how many list items are written between flushes
+	FLUSHEVERY = 100
+)
+
+// Encoder writes responses in one format
+type Encoder interface {
+	ContentType() string
+	// Encode writes a single value
+	Encode(w *bytes.Buffer, value interface{}) error
+	// EncodeList writes the fields of a list response and then its items one at a time
+	EncodeList(w http.ResponseWriter, list *List) error
+}
+
+// List is a list response, a result with a slice of items and a few fields around it
+type List struct {
+	// Root is the name of the result type
+	Root string
+	// Fields are the other fields of the result, like the total
+	Fields []Field
+	// Name is the key of the items
+	Name string
+	// Items is the slice of items, it may be nil
+	Items reflect.Value
+}
+
+// Field is a named value of a result
+type Field struct {
+	Name  string
+	Value interface{}
+}
+
+// Record is a result with a chosen set of fields, every format keeps their order
+type Record struct {
+	// Name is the name of the result type
+	Name   string
+	Fields []Field
+	// Message is the protobuf form of the record
+	Message proto.Message
+}
+
+// MarshalJSON writes the fields as an object in their order
+func (r *Record) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString("{")
+	for i, field := range r.Fields {
+		jData, err := json.Marshal(field.Value)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+		fmt.Fprintf(&buffer, "%q:%s", field.Name, jData)
+	}
+	buffer.WriteString("}")
+	return buffer.Bytes(), nil
+}
+
+// EncodeMsgpack writes the fields as a map in their order
+func (r *Record) EncodeMsgpack(encoder *msgpack.Encoder) error {
+	if err := encoder.EncodeMapLen(len(r.Fields)); err != nil {
+		return err
+	}
+	for _, field := range r.Fields {
+		encoder.EncodeString(field.Name)
+		if err := encoder.Encode(field.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encoders by format name, every format is also reachable by its media types
+var encoders = map[string]Encoder{
+	JSON:     jsonEncoder{},
+	CSV:      csvEncoder{},
+	XML:      xmlEncoder{},
+	MSGPACK:  msgpackEncoder{},
+	PROTOBUF: protobufEncoder{},
+}
+
+var mediaTypes = map[string]string{
+	"application/json":       JSON,
+	"text/csv":               CSV,
+	"application/xml":        XML,
+	"text/xml":               XML,
+	"application/msgpack":    MSGPACK,
+	"application/x-msgpack":  MSGPACK,
+	"application/x-protobuf": PROTOBUF,
+	"application/protobuf":   PROTOBUF,
+	ANYTYPE:                  JSON,
+	ANYAPP:                   JSON,
+}
+
+// Negotiate picks the encoder from the format parameter, or else the Accept header.
+// JSON is the default, it is false when nothing asked for is supported.
+func Negotiate(r *http.Request) (Encoder, bool) {
+	if format := r.URL.Query().Get(FORMAT); format != "" {
+		encoder, ok := encoders[strings.ToLower(format)]
+		return encoder, ok
+	}
+
+	accept := r.Header.Get(ACCEPT)
+	if accept == "" {
+		return encoders[JSON], true
+	}
+
+	for _, mediaType := range acceptable(accept) {
+		if format, ok := mediaTypes[mediaType]; ok {
+			return encoders[format], true
+		}
+	}
+	return nil, false
+}
+
+// acceptable lists the media types of an Accept header by preference, dropping the refused ones
+func acceptable(accept string) []string {
+	type weighted struct {
+		mediaType string
+		q         float64
+	}
+
+	ranges := []weighted{}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, weighted{mediaType: mediaType, q: q})
+		}
+	}
+
+	//Equal weights keep the order of the header
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	mediaTypes := []string{}
+	for _, r := range ranges {
+		mediaTypes = append(mediaTypes, r.mediaType)
+	}
+	return mediaTypes
+}
+
+// Write encodes a value before sending it, so a failure can still be answered with an error
+func Write(w http.ResponseWriter, encoder Encoder, value interface{}) error {
+	return WriteStatus(w, encoder, http.StatusOK, value)
+}
+
+// WriteStatus is Write with a status other than 200
+func WriteStatus(w http.ResponseWriter, encoder Encoder, status int, value interface{}) error {
+	var buffer bytes.Buffer
+	if err := encoder.Encode(&buffer, value); err != nil {
+		return err
+	}
+
+	w.Header().Set(CONTENTYPE, encoder.ContentType())
+	w.WriteHeader(status)
+	_, err := w.Write(buffer.Bytes())
+	return err
+}
+
+// Default is the encoder of the responses when nothing else can be told, JSON
+func Default() Encoder {
+	return encoders[JSON]
+}
+
+// WriteList streams a result holding a list, items is the name of its slice field.
+// The response is already started when the items fail, so errors can only be logged.
+func WriteList(w http.ResponseWriter, encoder Encoder, result interface{}, items string) error {
+	value := reflect.Indirect(reflect.ValueOf(result))
+	list := &List{Root: value.Type().Name()}
+
+	for _, column := range columns(value.Type()) {
+		field, ok := fieldValue(value, column.index)
+		if !ok {
+			continue
+		}
+		if column.field == items {
+			list.Name = column.name
+			list.Items = field
+		} else {
+			list.Fields = append(list.Fields, Field{Name: column.name, Value: field.Interface()})
+		}
+	}
+
+	w.Header().Set(CONTENTYPE, encoder.ContentType())
+	return encoder.EncodeList(w, list)
+}
+
+// flush sends the items written so far, when every few items
+func flush(w http.ResponseWriter, written int) {
+	if written%FLUSHEVERY != 0 {
+		return
+	}
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// column is a field of a struct under its JSON name
+type column struct {
+	name  string
+	field string
+	index []int
+	//omitCSV leaves the field out of the CSV rows, it is tagged csv:"-"
+	omitCSV bool
+}
+
+// columns lists the fields of a struct like encoding/json, embedded structs are flattened
+func columns(t reflect.Type) []column {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	result := []column{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			for _, embedded := range columns(field.Type) {
+				embedded.index = append([]int{i}, embedded.index...)
+				result = append(result, embedded)
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get(JSON), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		result = append(result, column{name: name, field: field.Name, index: []int{i}, omitCSV: field.Tag.Get(CSV) == "-"})
+	}
+	return result
+}
+
+// fieldValue reads a column, through nil embedded pointers as well
+func fieldValue(v reflect.Value, index []int) (reflect.Value, bool) {
+	for _, i := range index {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v, true
+}
@@ -0,0 +1,141 @@
+package render
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nullc0rp/go-ip2proxy-api/ip2proxypb"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/proto"
+)
+
+// ownAddress embeds the model like the controller results do
+type ownAddress struct {
+	IP string `json:"ip_address"`
+	*service.IPData
+}
+
+func countryData() *service.IPCountryData {
+	return &service.IPCountryData{
+		Total: 2,
+		IPList: []*service.IPDataResult{
+			{IP: "1.0.4.1", CountryName: "Argentina", CityName: "Rosario"},
+			{IP: "1.0.4.2", CountryName: "Argentina", CityName: "Rosario, Santa Fe"},
+		},
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+
+	cases := map[string]string{
+		"":                                     APPJSON,
+		"*/*":                                  APPJSON,
+		"text/csv":                             TEXTCSV,
+		"application/xml;q=0.5, text/csv":      TEXTCSV,
+		"application/msgpack, application/xml": APPMSGPACK,
+		"text/html, application/x-protobuf":    APPPROTOBUF,
+	}
+
+	for accept, contentType := range cases {
+		r := httptest.NewRequest("GET", "/ip/1.2.3.4", nil)
+		r.Header.Set(ACCEPT, accept)
+		encoder, ok := Negotiate(r)
+		assert.True(t, ok, accept)
+		assert.Equal(t, contentType, encoder.ContentType(), accept)
+	}
+
+	//The parameter wins over the header
+	r := httptest.NewRequest("GET", "/ip/1.2.3.4?format=xml", nil)
+	r.Header.Set(ACCEPT, "text/csv")
+	encoder, _ := Negotiate(r)
+	assert.Equal(t, APPXML, encoder.ContentType())
+
+	r = httptest.NewRequest("GET", "/ip/1.2.3.4", nil)
+	r.Header.Set(ACCEPT, "text/html, application/json;q=0")
+	_, ok := Negotiate(r)
+	assert.False(t, ok)
+
+	_, ok = Negotiate(httptest.NewRequest("GET", "/ip/1.2.3.4?format=yaml", nil))
+	assert.False(t, ok)
+}
+
+func TestJSONListMatchesMarshal(t *testing.T) {
+
+	w := httptest.NewRecorder()
+	assert.Nil(t, WriteList(w, jsonEncoder{}, countryData(), "IPList"))
+	assert.Equal(t, "{\"total\":2,\"IPList\":[{\"ip_address\":\"1.0.4.1\",\"country_name\":\"Argentina\",\"city_name\":\"Rosario\"},{\"ip_address\":\"1.0.4.2\",\"country_name\":\"Argentina\",\"city_name\":\"Rosario, Santa Fe\"}]}", w.Body.String())
+
+	//Nil lists keep their null
+	w = httptest.NewRecorder()
+	assert.Nil(t, WriteList(w, jsonEncoder{}, &service.IPCountryData{}, "IPList"))
+	assert.Equal(t, "{\"total\":0,\"IPList\":null}", w.Body.String())
+}
+
+func TestCSV(t *testing.T) {
+
+	w := httptest.NewRecorder()
+	assert.Nil(t, WriteList(w, csvEncoder{}, countryData(), "IPList"))
+	assert.Equal(t, TEXTCSV, w.Header().Get(CONTENTYPE))
+	assert.Equal(t, "ip_address,country_name,city_name\n1.0.4.1,Argentina,Rosario\n1.0.4.2,Argentina,\"Rosario, Santa Fe\"\n", w.Body.String())
+
+	//Embedded models are flattened
+	var buffer bytes.Buffer
+	assert.Nil(t, csvEncoder{}.Encode(&buffer, &ownAddress{IP: "1.2.3.4", IPData: &service.IPData{ProxyType: "PUB"}}))
+	assert.Equal(t, "ip_address,proxy_type,country_code,country_name,region_name,city_name,isp,domain,usage_type,asn,as,last_seen,threat,provider,fraud_score\n1.2.3.4,PUB,,,,,,,,,,,,,\n", buffer.String())
+}
+
+func TestXML(t *testing.T) {
+
+	w := httptest.NewRecorder()
+	assert.Nil(t, WriteList(w, xmlEncoder{}, &service.ISPCountryData{Total: 1, ISPList: []*service.ISPDataResult{{Name: "AT&T"}}}, "ISPList"))
+	assert.Equal(t, "<ISPCountryData><total>1</total><ISPList><ISPDataResult><isp>AT&amp;T</isp></ISPDataResult></ISPList></ISPCountryData>", w.Body.String())
+
+	var buffer bytes.Buffer
+	assert.Nil(t, xmlEncoder{}.Encode(&buffer, &service.IPCountryTotal{Total: 10}))
+	assert.Equal(t, "<IPCountryTotal><total_ip>10</total_ip></IPCountryTotal>", buffer.String())
+}
+
+func TestMsgpack(t *testing.T) {
+
+	w := httptest.NewRecorder()
+	assert.Nil(t, WriteList(w, msgpackEncoder{}, countryData(), "IPList"))
+
+	decoded := map[string]interface{}{}
+	assert.Nil(t, msgpack.Unmarshal(w.Body.Bytes(), &decoded))
+	assert.Equal(t, int8(2), decoded["total"])
+	assert.Equal(t, "1.0.4.2", decoded["IPList"].([]interface{})[1].(map[string]interface{})["ip_address"])
+
+	var buffer bytes.Buffer
+	assert.Nil(t, msgpackEncoder{}.Encode(&buffer, &ownAddress{IP: "1.2.3.4", IPData: &service.IPData{ProxyType: "PUB"}}))
+	decoded = map[string]interface{}{}
+	assert.Nil(t, msgpack.Unmarshal(buffer.Bytes(), &decoded))
+	assert.Equal(t, "PUB", decoded["proxy_type"])
+}
+
+func TestProtobufList(t *testing.T) {
+
+	w := httptest.NewRecorder()
+	assert.Nil(t, WriteList(w, protobufEncoder{}, countryData(), "IPList"))
+
+	//The items come as length-delimited messages
+	ips := []string{}
+	for w.Body.Len() > 0 {
+		message := &ip2proxypb.CountryIP{}
+		assert.Nil(t, protodelim.UnmarshalFrom(w.Body, message))
+		ips = append(ips, message.Ip)
+	}
+	assert.Equal(t, []string{"1.0.4.1", "1.0.4.2"}, ips)
+
+	var buffer bytes.Buffer
+	assert.Nil(t, protobufEncoder{}.Encode(&buffer, &service.IPData{ProxyType: "VPN"}))
+	message := &ip2proxypb.IPData{}
+	assert.Nil(t, proto.Unmarshal(buffer.Bytes(), message))
+	assert.Equal(t, "VPN", message.ProxyType)
+
+	//Results without a message are refused before anything is written
+	assert.NotNil(t, protobufEncoder{}.Encode(&buffer, &ownAddress{}))
+}
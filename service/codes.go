@@ -0,0 +1,77 @@
+package service
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	RISKHIGH   = "high"
+	RISKMEDIUM = "medium"
+	RISKLOW    = "low"
+)
+
+// Code describes a proxy_type or usage_type code of the IP2Proxy data
+type Code struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Risk        string `json:"risk"`
+}
+
+// PROXYTYPES are the proxy types of every package tier, RES comes with PX10 and CPN and EPN with PX11
+var PROXYTYPES = map[string]*Code{
+	"VPN": {Code: "VPN", Name: "Anonymizing VPN", Description: "Anonymizing VPN services, also used by regular users for privacy", Risk: RISKMEDIUM},
+	"TOR": {Code: "TOR", Name: "Tor exit node", Description: "Exit nodes of the Tor network", Risk: RISKHIGH},
+	"DCH": {Code: "DCH", Name: "Data center", Description: "Hosting providers, data centers and content delivery networks", Risk: RISKMEDIUM},
+	"PUB": {Code: "PUB", Name: "Public proxy", Description: "Open proxies anyone can connect through", Risk: RISKHIGH},
+	"WEB": {Code: "WEB", Name: "Web proxy", Description: "Web sites that fetch other sites for their users", Risk: RISKHIGH},
+	"SES": {Code: "SES", Name: "Search engine robot", Description: "Crawlers of the search engines", Risk: RISKLOW},
+	"RES": {Code: "RES", Name: "Residential proxy", Description: "Proxies running on residential connections, often through compromised or rented devices", Risk: RISKHIGH},
+	"CPN": {Code: "CPN", Name: "Consumer privacy network", Description: "Privacy relays built into consumer products", Risk: RISKLOW},
+	"EPN": {Code: "EPN", Name: "Enterprise private network", Description: "Secure access gateways of companies", Risk: RISKLOW},
+}
+
+// USAGETYPES are the usage types, a range may have several of them joined by a slash like ISP/MOB
+var USAGETYPES = map[string]*Code{
+	"COM": {Code: "COM", Name: "Commercial", Description: "Companies", Risk: RISKLOW},
+	"ORG": {Code: "ORG", Name: "Organization", Description: "Non-profit and other organizations", Risk: RISKLOW},
+	"GOV": {Code: "GOV", Name: "Government", Description: "Government agencies", Risk: RISKLOW},
+	"MIL": {Code: "MIL", Name: "Military", Description: "Military networks", Risk: RISKLOW},
+	"EDU": {Code: "EDU", Name: "Education", Description: "Universities, colleges and schools", Risk: RISKLOW},
+	"LIB": {Code: "LIB", Name: "Library", Description: "Public libraries", Risk: RISKLOW},
+	"CDN": {Code: "CDN", Name: "Content delivery network", Description: "Networks serving content for other sites", Risk: RISKMEDIUM},
+	"ISP": {Code: "ISP", Name: "Fixed line ISP", Description: "Home and business fixed line connections", Risk: RISKLOW},
+	"MOB": {Code: "MOB", Name: "Mobile ISP", Description: "Mobile carriers, many users share each address", Risk: RISKLOW},
+	"DCH": {Code: "DCH", Name: "Data center", Description: "Data centers, web hosting and transit", Risk: RISKMEDIUM},
+	"SES": {Code: "SES", Name: "Search engine spider", Description: "Crawlers of the search engines", Risk: RISKLOW},
+	"RSV": {Code: "RSV", Name: "Reserved", Description: "Reserved and private address space", Risk: RISKLOW},
+}
+
+// ProxyTypeCode describes a proxy type, nil for unknown ones and the - of addresses that are not proxies
+func ProxyTypeCode(code string) *Code {
+	return PROXYTYPES[code]
+}
+
+// UsageTypeCodes describes each known part of a usage type like ISP/MOB
+func UsageTypeCodes(usageType string) []*Code {
+	codes := []*Code{}
+	for _, code := range strings.Split(usageType, "/") {
+		if description, ok := USAGETYPES[code]; ok {
+			codes = append(codes, description)
+		}
+	}
+	return codes
+}
+
+// SortedCodes lists a set of codes by code
+func SortedCodes(set map[string]*Code) []*Code {
+	codes := []*Code{}
+	for _, code := range set {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		return codes[i].Code < codes[j].Code
+	})
+	return codes
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	BADFIELD = "Unknown field %s"
+)
+
+// IPDATAFIELDS are the fields of IPData by their JSON names, in table order
+var IPDATAFIELDS = []string{"proxy_type", "country_code", "country_name", "region_name", "city_name", "isp", "domain", "usage_type", "asn", "as", "last_seen", "threat", "provider", "fraud_score"}
+
+// PX7FIELDS are the fields of the PX7 package, assumed when the columns weren't detected
+var PX7FIELDS = IPDATAFIELDS[:10]
+
+// ipDataColumns maps the fields to the columns selected for them
+var ipDataColumns = map[string]string{
+	"proxy_type":   PROXYTYPE,
+	"country_code": COUNTRYCODE,
+	"country_name": COUNTRYNAME,
+	"region_name":  REGIONNAME,
+	"city_name":    CITYNAME,
+	"isp":          ISP,
+	"domain":       DOMAIN,
+	"usage_type":   USAGETYPE,
+	"asn":          ASN,
+	"as":           AS,
+	"last_seen":    LASTSEEN,
+	"threat":       THREAT,
+	"provider":     PROVIDER,
+	"fraud_score":  FRAUDSCORE,
+}
+
+// BadField is returned for a field IPData doesn't have
+type BadField struct {
+	Field string
+}
+
+func (e *BadField) Error() string {
+	return fmt.Sprintf(BADFIELD, e.Field)
+}
+
+// ParseFields reads a comma separated field list, in the given order and without repeats.
+// An empty list selects every field.
+func ParseFields(input string) ([]string, error) {
+	if strings.TrimSpace(input) == "" {
+		return IPDATAFIELDS, nil
+	}
+
+	fields := []string{}
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(input, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if _, ok := ipDataColumns[field]; !ok {
+			return nil, &BadField{Field: field}
+		}
+		if !seen[field] {
+			seen[field] = true
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// FieldPointer gives the IPData field of a name, nil when there is none
+func (d *IPData) FieldPointer(field string) *string {
+	switch field {
+	case "proxy_type":
+		return &d.ProxyType
+	case "country_code":
+		return &d.CountryCode
+	case "country_name":
+		return &d.CountryName
+	case "region_name":
+		return &d.RegionName
+	case "city_name":
+		return &d.CityName
+	case "isp":
+		return &d.ISP
+	case "domain":
+		return &d.Domain
+	case "usage_type":
+		return &d.UsageType
+	case "asn":
+		return &d.ASN
+	case "as":
+		return &d.AS
+	case "last_seen":
+		return &d.LastSeen
+	case "threat":
+		return &d.Threat
+	case "provider":
+		return &d.Provider
+	case "fraud_score":
+		return &d.FraudScore
+	}
+	return nil
+}
+
+// Select copies only the given fields into a new IPData
+func (d *IPData) Select(fields []string) *IPData {
+	selected := &IPData{}
+	for _, field := range fields {
+		if source := d.FieldPointer(field); source != nil {
+			*selected.FieldPointer(field) = *source
+		}
+	}
+	return selected
+}
+
+// fields are the IPData fields of the table, in order
+func (s ServiceImp) fields() []string {
+	if s.Columns == nil {
+		return PX7FIELDS
+	}
+	return s.Columns
+}
+
+func (s ServiceImp) hasField(field string) bool {
+	return contains(s.fields(), field)
+}
+
+func contains(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// column is the column of a field, or an empty value when the table doesn't have it
+func (s ServiceImp) column(field string) string {
+	if !s.hasField(field) {
+		return EMPTYCOLUMN
+	}
+	return ipDataColumns[field]
+}
@@ -12,6 +12,18 @@ type IPData struct {
 	UsageType   string `json:"usage_type"`
 	ASN         string `json:"asn"`
 	AS          string `json:"as"`
+	//Only in the PX8 and higher package tiers
+	LastSeen   string `json:"last_seen,omitempty"`
+	Threat     string `json:"threat,omitempty"`
+	Provider   string `json:"provider,omitempty"`
+	FraudScore string `json:"fraud_score,omitempty"`
+}
+
+//IPDataRange is the data of an address with the bounds of the range it was found in
+type IPDataRange struct {
+	IPFrom uint32
+	IPTo   uint32
+	Data   *IPData
 }
 
 //IPDataSimple raw data from DB
@@ -22,6 +34,12 @@ type IPDataSimple struct {
 	CityName    string `json:"city_name"`
 }
 
+// IPRangeCountryData data formated for response
+type IPRangeCountryData struct {
+	Total     int             `json:"total"`
+	RangeList []*IPDataSimple `json:"ranges"`
+}
+
 //IPDataResult data formated for response
 type IPDataResult struct {
 	IP          string `json:"ip_address"`
@@ -61,3 +79,41 @@ type MostProxyType struct {
 type MostProxyTypeResult struct {
 	ProxyTypeList []*MostProxyType
 }
+
+//ProviderTotal data formated for response
+type ProviderTotal struct {
+	Name   string `json:"provider"`
+	Ranges int    `json:"ranges"`
+	Total  int    `json:"total_ip"`
+}
+
+//ProviderData data formated for response, the biggest providers first
+type ProviderData struct {
+	ProviderList []*ProviderTotal
+}
+
+//IPRange raw data from DB
+type IPRange struct {
+	IPFrom      uint32 `json:"ip_from"`
+	IPTo        uint32 `json:"ip_to"`
+	CountryCode string `json:"country_code"`
+	LastSeen    string `json:"last_seen,omitempty"`
+}
+
+//IPRangeData data formated for response
+type IPRangeData struct {
+	Total     int        `json:"total"`
+	RangeList []*IPRange `json:"ranges"`
+}
+
+//ThreatTotal data formated for response
+type ThreatTotal struct {
+	Name   string `json:"threat"`
+	Ranges int    `json:"ranges"`
+	Total  int    `json:"total_ip"`
+}
+
+//ThreatData data formated for response, the biggest categories first
+type ThreatData struct {
+	ThreatList []*ThreatTotal
+}
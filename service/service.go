@@ -1,11 +1,18 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
+	"strings"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/nullc0rp/go-ip2proxy-api/database"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -22,62 +29,183 @@ const (
 	AS                  = "'as'"
 	IPFROM              = "ip_from"
 	IPTO                = "ip_to"
-	IPDATAQUERY         = "SELECT " + PROXYTYPE + "," + COUNTRYCODE + "," + COUNTRYNAME + "," + REGIONNAME + "," + CITYNAME + "," + ISP + "," + DOMAIN + "," + USAGETYPE + "," + ASN + "," + AS + " FROM ip2proxy_database where ip_from <= %d AND %d <= ip_to;"
-	ISPCOUNTRYQUERY     = "SELECT " + ISP + " FROM ip2proxy_database where " + COUNTRYCODE + " = '%s'"
-	IPCOUNTRYQUERY      = "SELECT " + IPFROM + "," + IPTO + "," + COUNTRYNAME + "," + CITYNAME + " FROM ip2proxy_database where " + COUNTRYCODE + " = '%s' LIMIT %d;"
-	IPCOUNTRYTOTALQUERY = "SELECT SUM(cast(ip_to+1 as signed)-cast(ip_from as signed)) as total_ip FROM ip2proxy_database where " + COUNTRYCODE + " = '%s' LIMIT 1;"
-	MOSTPROXYTYPES      = "SELECT " + PROXYTYPE + ",count(" + PROXYTYPE + ") as total FROM ip2proxy_database GROUP BY " + PROXYTYPE + " ORDER BY total DESC LIMIT 3;"
+	LASTSEEN            = "last_seen"
+	THREAT              = "threat"
+	PROVIDER            = "provider"
+	FRAUDSCORE          = "fraud_score"
+	EMPTYCOLUMN         = "''"
+	IPDATAQUERY         = "SELECT %s FROM ip2proxy_database where ip_from <= %d AND %d <= ip_to;"
+	ISPCOUNTRYQUERY     = "SELECT " + ISP + " FROM ip2proxy_database where " + COUNTRYCODE + " = '%s'%s"
+	IPCOUNTRYQUERY      = "SELECT " + IPFROM + "," + IPTO + "," + COUNTRYNAME + ",%s FROM ip2proxy_database where " + COUNTRYCODE + " = '%s'%s LIMIT %d;"
+	IPCOUNTRYTOTALQUERY = "SELECT SUM(cast(ip_to+1 as signed)-cast(ip_from as signed)) as total_ip FROM ip2proxy_database where " + COUNTRYCODE + " = '%s'%s LIMIT 1;"
+	MOSTPROXYTYPES      = "SELECT " + PROXYTYPE + ",count(" + PROXYTYPE + ") as total FROM ip2proxy_database%s GROUP BY " + PROXYTYPE + " ORDER BY total DESC LIMIT 3;"
+	PROVIDERSQUERY      = "SELECT " + PROVIDER + ",count(*) as ranges,SUM(cast(ip_to+1 as signed)-cast(ip_from as signed)) as total_ip FROM ip2proxy_database where " + PROVIDER + " NOT IN ('','-')%s GROUP BY " + PROVIDER + " ORDER BY total_ip DESC;"
+	PROVIDERRANGESQUERY = "SELECT " + IPFROM + "," + IPTO + "," + COUNTRYCODE + ",%s FROM ip2proxy_database where " + PROVIDER + " = '%s'%s ORDER BY " + IPFROM + " LIMIT %d;"
+	THREATSQUERY        = "SELECT " + THREAT + ",count(*) as ranges,SUM(cast(ip_to+1 as signed)-cast(ip_from as signed)) as total_ip FROM ip2proxy_database where " + THREAT + " NOT IN ('','-')%s GROUP BY " + THREAT + " ORDER BY total_ip DESC;"
+	THREATRANGESQUERY   = "SELECT " + IPFROM + "," + IPTO + "," + COUNTRYCODE + ",%s FROM ip2proxy_database where " + THREAT + " = '%s'%s ORDER BY " + IPFROM + " LIMIT %d;"
+	COUNTRYFILTER       = " AND " + COUNTRYCODE + " = '%s'"
+	ASNFILTER           = " AND " + ASN + " = '%s'"
+	FRESHFILTER         = " AND " + LASTSEEN + " <= %d"
+	FRESHWHERE          = " where " + LASTSEEN + " <= %d"
+	COLUMNSQUERY        = "SELECT COLUMN_NAME FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'ip2proxy_database';"
+	DATASETVERSIONQUERY = "SELECT version FROM ip2proxy_metadata LIMIT 1;"
+	ROWSQUERY           = "SELECT count(*) FROM ip2proxy_database;"
+	ALLRANGESQUERY      = "SELECT %s FROM ip2proxy_database ORDER BY " + IPFROM + ";"
+	UNKNOWNVERSION      = "unknown"
+	NOMETADATA          = "No ip2proxy_metadata table, the dataset version is unknown"
+	//NOSUCHTABLE is the MySQL error of a missing table
+	NOSUCHTABLE = 1146
 	CHECKDATA           = "Please check your data, no results for query"
+	QUERY               = "Query"
+	QUERYFAILED         = "Query failed"
+	SQL                 = "sql"
+	ERRORKEY            = "error"
 	UNKNOWN             = "Unknown error"
 )
 
+// tracer names the spans of the service calls
+var tracer = otel.Tracer("github.com/nullc0rp/go-ip2proxy-api/service")
+
 //ServiceImp handles requests and interacts with the DB
 type ServiceImp struct {
 	DB database.Database
+	//Columns are the IPData fields the table has, nil for the PX7 ones. See DetectColumns
+	Columns []string
+	//MaxAgeDays leaves out the ranges last seen longer ago than that, zero keeps all of them. See MaxAge
+	MaxAgeDays int
+	//Logger writes the queries at debug level and the failures, slog.Default() when nil
+	Logger *slog.Logger
+	//Version is the configured dataset version, when empty it is read from the row the import writes. See DatasetVersion
+	Version string
+	//ctx is the request the service is scoped to, it parents the spans. See WithContext
+	ctx context.Context
 }
 
 //Service interface
 type Service interface {
 	GetIPInfo(ip net.IP) (*IPData, error)
+	GetIPInfoFields(ip net.IP, fields []string) (*IPData, error)
+	// GetIPInfoRange is GetIPInfoFields with the bounds of the range, every address of the range has the same data
+	GetIPInfoRange(ip net.IP, fields []string) (*IPDataRange, error)
 	GetIPCountry(country string, limit int) (*IPCountryData, error)
+	GetCountryRanges(country string, limit int) (*IPRangeCountryData, error)
 	GetISPCountry(country string) (*ISPCountryData, error)
 	GetCountryTotal(country string) (*IPCountryTotal, error)
 	MostProxyTypes() (*MostProxyTypeResult, error)
+	GetProviders(country string) (*ProviderData, error)
+	GetProviderRanges(provider string, country string, limit int) (*IPRangeData, error)
+	GetThreats(country string, asn string) (*ThreatData, error)
+	GetThreatRanges(threat string, country string, asn string, limit int) (*IPRangeData, error)
+	DatasetVersion() (string, error)
+	// MaxAge scopes the service to the ranges seen in the last days, older ones are treated as not being proxies
+	MaxAge(days int) Service
+	// WithContext scopes the service to a request, its calls are traced as part of it
+	WithContext(ctx context.Context) Service
 }
 
 //GetIPInfo TODO:
 func (s ServiceImp) GetIPInfo(ip net.IP) (*IPData, error) {
+	return s.GetIPInfoFields(ip, s.fields())
+}
+
+// GetIPInfoFields gets only the given fields of IPData, the others are left empty and not selected.
+// Fields the package tier doesn't have are left out too.
+func (s ServiceImp) GetIPInfoFields(ip net.IP, fields []string) (*IPData, error) {
+	ctx, span := s.start("GetIPInfoFields")
+	defer span.End()
+
+	found, err := s.lookup(ctx, ip, fields, false)
+	if err != nil {
+		return nil, err
+	}
+	return found.Data, nil
+}
+
+// GetIPInfoRange gets the given fields along with the bounds of the range the address was found in.
+// Nil fields are every field of the package tier, like GetIPInfo.
+func (s ServiceImp) GetIPInfoRange(ip net.IP, fields []string) (*IPDataRange, error) {
+	ctx, span := s.start("GetIPInfoRange")
+	defer span.End()
+
+	if fields == nil {
+		fields = s.fields()
+	}
+	return s.lookup(ctx, ip, fields, true)
+}
+
+// lookup selects the fields of the range holding the address, its bounds too when asked
+func (s ServiceImp) lookup(ctx context.Context, ip net.IP, fields []string, bounds bool) (*IPDataRange, error) {
 
 	//Get decimal ip value
 	decimalIP := IP2int(ip)
 
+	//Result carrier, scanned straight into the selected fields
+	var found IPDataRange
+	var ipdata IPData
+	columns := []string{}
+	destinations := []interface{}{}
+	if bounds {
+		columns = append(columns, IPFROM, IPTO)
+		destinations = append(destinations, &found.IPFrom, &found.IPTo)
+	}
+	for _, field := range fields {
+		column, ok := ipDataColumns[field]
+		if !ok {
+			return nil, &BadField{Field: field}
+		}
+		if !s.hasField(field) {
+			continue
+		}
+		columns = append(columns, column)
+		destinations = append(destinations, ipdata.FieldPointer(field))
+	}
+
+	//The age of the range is needed to tell if it is stale, even when it wasn't selected
+	checkAge := s.freshness(FRESHFILTER) != ""
+	lastSeen := &ipdata.LastSeen
+	if checkAge && !contains(fields, LASTSEEN) {
+		lastSeen = new(string)
+		columns = append(columns, LASTSEEN)
+		destinations = append(destinations, lastSeen)
+	}
+
+	//Nothing to select, the range is still looked up to tell if it exists
+	if len(columns) == 0 {
+		var from uint32
+		columns = append(columns, IPFROM)
+		destinations = append(destinations, &from)
+	}
+
 	//Build query
-	query := fmt.Sprintf(IPDATAQUERY, decimalIP, decimalIP)
-	log.Print(query)
+	query := fmt.Sprintf(IPDATAQUERY, strings.Join(columns, ","), decimalIP, decimalIP)
+	s.logger().DebugContext(ctx, QUERY, SQL, query)
 
 	//Fetch results
-	results, err := s.DB.Query(query)
+	results, err := s.DB.QueryContext(ctx, query)
 	if err != nil || results == nil {
-		log.Printf(ERROR, err)
+		s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
 		return nil, err
 	}
 
-	//Result carrier
-	var ipdata IPData
-
 	// For first row, scan the result data
 	if results.Next() {
-		err = results.Scan(&ipdata.ProxyType, &ipdata.CountryCode, &ipdata.CountryName, &ipdata.RegionName, &ipdata.CityName, &ipdata.ISP, &ipdata.Domain, &ipdata.UsageType, &ipdata.ASN, &ipdata.AS)
+		err = results.Scan(destinations...)
 		if err != nil {
-			log.Printf(ERROR, err)
+			s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
 			return nil, err
 		}
 	} else {
-		log.Printf(CHECKDATA)
+		s.logger().DebugContext(ctx, CHECKDATA)
 		return nil, NoResultError(CHECKDATA)
 	}
 
-	return &ipdata, nil
+	//Ranges not seen lately are not proxies anymore
+	if checkAge && Stale(*lastSeen, s.MaxAgeDays) {
+		return nil, &StaleError{LastSeen: *lastSeen}
+	}
+
+	found.Data = &ipdata
+	return &found, nil
 }
 
 //GetIPCountry Gets an ammount of ip addresses for country
@@ -87,15 +215,17 @@ func (s ServiceImp) GetIPInfo(ip net.IP) (*IPData, error) {
 //Since a complex query is needed, the performance of the query may be affected, and using Mysql variables is not a good practice
 //I've decided to go a simpler approach with limit. The results will be rendered in runtime and controlled before return. It exchanges performance for memory, which is acceptable in my opinion
 func (s ServiceImp) GetIPCountry(country string, limit int) (*IPCountryData, error) {
+	ctx, span := s.start("GetIPCountry")
+	defer span.End()
 
 	//Build query
-	query := fmt.Sprintf(IPCOUNTRYQUERY, country, limit)
-	log.Print(query)
+	query := fmt.Sprintf(IPCOUNTRYQUERY, s.column(CITYNAME), country, s.freshness(FRESHFILTER), limit)
+	s.logger().DebugContext(ctx, QUERY, SQL, query)
 
 	//Fetch results
-	results, err := s.DB.Query(query)
+	results, err := s.DB.QueryContext(ctx, query)
 	if err != nil {
-		log.Printf(ERROR, err)
+		s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
 		return nil, err
 	}
 
@@ -110,7 +240,7 @@ func (s ServiceImp) GetIPCountry(country string, limit int) (*IPCountryData, err
 		err = results.Scan(&ipDataSimple.IPFrom, &ipDataSimple.IPTo, &ipDataSimple.CountryName, &ipDataSimple.CityName)
 		if err != nil {
 			//Keep cycling
-			log.Printf(ERROR, err)
+			s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
 		} else {
 			//Creates the result object and counts the addresses
 			//For each IP range, it builds the IPV4 address from IPFrom and then
@@ -137,17 +267,61 @@ func (s ServiceImp) GetIPCountry(country string, limit int) (*IPCountryData, err
 	return IPCountryData, nil
 }
 
+// GetCountryRanges Gets up to limit address ranges for a country, without expanding them
+func (s ServiceImp) GetCountryRanges(country string, limit int) (*IPRangeCountryData, error) {
+	ctx, span := s.start("GetCountryRanges")
+	defer span.End()
+
+	//Build query, the ranges are the raw rows of the address list query
+	query := fmt.Sprintf(IPCOUNTRYQUERY, s.column(CITYNAME), country, s.freshness(FRESHFILTER), limit)
+	s.logger().DebugContext(ctx, QUERY, SQL, query)
+
+	//Fetch results
+	results, err := s.DB.QueryContext(ctx, query)
+	if err != nil {
+		s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
+		return nil, err
+	}
+
+	//Result carrier
+	rangeList := []*IPDataSimple{}
+
+	// For each row, scan the result into a new range
+	for results.Next() {
+		ipDataSimple := &IPDataSimple{}
+		err = results.Scan(&ipDataSimple.IPFrom, &ipDataSimple.IPTo, &ipDataSimple.CountryName, &ipDataSimple.CityName)
+		if err != nil {
+			//Keep cycling
+			s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
+		} else {
+			rangeList = append(rangeList, ipDataSimple)
+		}
+	}
+
+	return &IPRangeCountryData{
+		RangeList: rangeList,
+		Total:     len(rangeList),
+	}, nil
+}
+
 //GetISPCountry Service to get all the ISP by country
 func (s ServiceImp) GetISPCountry(country string) (*ISPCountryData, error) {
+	ctx, span := s.start("GetISPCountry")
+	defer span.End()
+
+	//Tiers below PX4 have no ISP
+	if !s.hasField(ISP) {
+		return &ISPCountryData{ISPList: []*ISPDataResult{}}, nil
+	}
 
 	//Build query
-	query := fmt.Sprintf(ISPCOUNTRYQUERY, country)
-	log.Print(query)
+	query := fmt.Sprintf(ISPCOUNTRYQUERY, country, s.freshness(FRESHFILTER))
+	s.logger().DebugContext(ctx, QUERY, SQL, query)
 
 	//Fetch results
-	results, err := s.DB.Query(query)
+	results, err := s.DB.QueryContext(ctx, query)
 	if err != nil {
-		log.Printf(ERROR, err)
+		s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
 		return nil, err
 	}
 
@@ -164,7 +338,7 @@ func (s ServiceImp) GetISPCountry(country string) (*ISPCountryData, error) {
 		err = results.Scan(&ispDataSimple.Name)
 		if err != nil {
 			//Keep cycling
-			log.Printf(ERROR, err)
+			s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
 		} else {
 			//Save value in dictionary
 			set[ispDataSimple.Name] = true
@@ -189,15 +363,17 @@ func (s ServiceImp) GetISPCountry(country string) (*ISPCountryData, error) {
 
 //GetCountryTotal Get the total ammount of ips for a country
 func (s ServiceImp) GetCountryTotal(country string) (*IPCountryTotal, error) {
+	ctx, span := s.start("GetCountryTotal")
+	defer span.End()
 
 	//Build query
-	query := fmt.Sprintf(IPCOUNTRYTOTALQUERY, country)
-	log.Print(query)
+	query := fmt.Sprintf(IPCOUNTRYTOTALQUERY, country, s.freshness(FRESHFILTER))
+	s.logger().DebugContext(ctx, QUERY, SQL, query)
 
 	//Fetch results
-	results, err := s.DB.Query(query)
+	results, err := s.DB.QueryContext(ctx, query)
 	if err != nil {
-		log.Printf(ERROR, err)
+		s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
 		return nil, err
 	}
 
@@ -208,7 +384,7 @@ func (s ServiceImp) GetCountryTotal(country string) (*IPCountryTotal, error) {
 	if results.Next() {
 		err = results.Scan(&ipCountryTotal.Total)
 		if err != nil {
-			log.Printf(ERROR, err)
+			s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
 			return nil, err
 		}
 	}
@@ -219,14 +395,22 @@ func (s ServiceImp) GetCountryTotal(country string) (*IPCountryTotal, error) {
 //MostProxyTypes Is the ServiceImp that gets the most proxy types in the database
 //Note: The database has only one kind of proxy type in the whole table, so this always returns one result
 func (s ServiceImp) MostProxyTypes() (*MostProxyTypeResult, error) {
+	ctx, span := s.start("MostProxyTypes")
+	defer span.End()
+
+	//PX1 has no proxy type
+	if !s.hasField(PROXYTYPE) {
+		return &MostProxyTypeResult{ProxyTypeList: []*MostProxyType{}}, nil
+	}
 
 	//Prepare query
-	log.Print(MOSTPROXYTYPES)
+	query := fmt.Sprintf(MOSTPROXYTYPES, s.freshness(FRESHWHERE))
+	s.logger().DebugContext(ctx, QUERY, SQL, query)
 
 	//Fetch results
-	results, err := s.DB.Query(MOSTPROXYTYPES)
+	results, err := s.DB.QueryContext(ctx, query)
 	if err != nil {
-		log.Printf(ERROR, err)
+		s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
 		return nil, err
 	}
 
@@ -239,7 +423,7 @@ func (s ServiceImp) MostProxyTypes() (*MostProxyTypeResult, error) {
 		err = results.Scan(&mostProxyType.ProxyType, &mostProxyType.Total)
 		if err != nil {
 			//Keep cycling
-			log.Printf(ERROR, err)
+			s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
 		} else {
 			mostProxyTypeList = append(mostProxyTypeList, &MostProxyType{
 				Total:     mostProxyType.Total,
@@ -255,3 +439,388 @@ func (s ServiceImp) MostProxyTypes() (*MostProxyTypeResult, error) {
 
 	return mostProxyTypeResult, nil
 }
+
+// DatasetVersion identifies the loaded data by the version in ip2proxy_metadata, written by every import,
+// or by the configured one. The table timestamps are not kept across MySQL restarts, so they can't tell it
+func (s ServiceImp) DatasetVersion() (string, error) {
+	if s.Version != "" {
+		return s.Version, nil
+	}
+
+	ctx, span := s.start("DatasetVersion")
+	defer span.End()
+
+	//Fetch results
+	results, err := s.DB.QueryContext(ctx, DATASETVERSIONQUERY)
+	//Stock databases don't have the table, their version is unknown like a table without the row
+	var mysqlError *mysql.MySQLError
+	if errors.As(err, &mysqlError) && mysqlError.Number == NOSUCHTABLE {
+		s.logger().DebugContext(ctx, NOMETADATA)
+		return UNKNOWNVERSION, nil
+	}
+	if err != nil {
+		s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
+		return "", err
+	}
+	defer results.Close()
+
+	//Result carrier
+	var version sql.NullString
+
+	// For first row, scan the result data
+	if results.Next() {
+		err = results.Scan(&version)
+		if err != nil {
+			s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
+			return "", err
+		}
+	}
+
+	if !version.Valid {
+		return UNKNOWNVERSION, nil
+	}
+	return version.String, nil
+}
+
+// GetProviders counts the ranges and addresses of every VPN provider, of a country when it is not empty
+func (s ServiceImp) GetProviders(country string) (*ProviderData, error) {
+	ctx, span := s.start("GetProviders")
+	defer span.End()
+
+	//Only PX10 and higher name the providers
+	if !s.hasField(PROVIDER) {
+		return &ProviderData{ProviderList: []*ProviderTotal{}}, nil
+	}
+
+	//Build query
+	query := fmt.Sprintf(PROVIDERSQUERY, countryFilter(country)+s.freshness(FRESHFILTER))
+	s.logger().DebugContext(ctx, QUERY, SQL, query)
+
+	//Fetch results
+	results, err := s.DB.QueryContext(ctx, query)
+	if err != nil {
+		s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
+		return nil, err
+	}
+	defer results.Close()
+
+	//Result carrier
+	providerList := []*ProviderTotal{}
+
+	// For each row, scan the result into a new provider
+	for results.Next() {
+		provider := &ProviderTotal{}
+		err = results.Scan(&provider.Name, &provider.Ranges, &provider.Total)
+		if err != nil {
+			//Keep cycling
+			s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
+		} else {
+			providerList = append(providerList, provider)
+		}
+	}
+
+	return &ProviderData{ProviderList: providerList}, nil
+}
+
+// GetProviderRanges gets up to limit ranges of a VPN provider in address order, of a country when it is not empty
+func (s ServiceImp) GetProviderRanges(provider string, country string, limit int) (*IPRangeData, error) {
+	ctx, span := s.start("GetProviderRanges")
+	defer span.End()
+
+	//Only PX10 and higher name the providers
+	if !s.hasField(PROVIDER) {
+		return &IPRangeData{RangeList: []*IPRange{}}, nil
+	}
+
+	//Build query
+	query := fmt.Sprintf(PROVIDERRANGESQUERY, s.column(LASTSEEN), escape(provider), countryFilter(country)+s.freshness(FRESHFILTER), limit)
+	s.logger().DebugContext(ctx, QUERY, SQL, query)
+
+	//Fetch results
+	results, err := s.DB.QueryContext(ctx, query)
+	if err != nil {
+		s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
+		return nil, err
+	}
+	defer results.Close()
+
+	//Result carrier
+	rangeList := []*IPRange{}
+
+	// For each row, scan the result into a new range
+	for results.Next() {
+		ipRange := &IPRange{}
+		err = results.Scan(&ipRange.IPFrom, &ipRange.IPTo, &ipRange.CountryCode, &ipRange.LastSeen)
+		if err != nil {
+			//Keep cycling
+			s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
+		} else {
+			rangeList = append(rangeList, ipRange)
+		}
+	}
+
+	return &IPRangeData{
+		RangeList: rangeList,
+		Total:     len(rangeList),
+	}, nil
+}
+
+// GetThreats counts the ranges and addresses of every threat category, of a country and an ASN when they are not empty
+func (s ServiceImp) GetThreats(country string, asn string) (*ThreatData, error) {
+	ctx, span := s.start("GetThreats")
+	defer span.End()
+
+	//Only PX9 and higher classify threats
+	if !s.hasField(THREAT) {
+		return &ThreatData{ThreatList: []*ThreatTotal{}}, nil
+	}
+
+	//Build query
+	query := fmt.Sprintf(THREATSQUERY, countryFilter(country)+asnFilter(asn)+s.freshness(FRESHFILTER))
+	s.logger().DebugContext(ctx, QUERY, SQL, query)
+
+	//Fetch results
+	results, err := s.DB.QueryContext(ctx, query)
+	if err != nil {
+		s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
+		return nil, err
+	}
+	defer results.Close()
+
+	//Result carrier
+	threatList := []*ThreatTotal{}
+
+	// For each row, scan the result into a new category
+	for results.Next() {
+		threat := &ThreatTotal{}
+		err = results.Scan(&threat.Name, &threat.Ranges, &threat.Total)
+		if err != nil {
+			//Keep cycling
+			s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
+		} else {
+			threatList = append(threatList, threat)
+		}
+	}
+
+	return &ThreatData{ThreatList: threatList}, nil
+}
+
+// GetThreatRanges gets up to limit ranges of a threat category in address order, of a country and an ASN when they are not empty
+func (s ServiceImp) GetThreatRanges(threat string, country string, asn string, limit int) (*IPRangeData, error) {
+	ctx, span := s.start("GetThreatRanges")
+	defer span.End()
+
+	//Only PX9 and higher classify threats
+	if !s.hasField(THREAT) {
+		return &IPRangeData{RangeList: []*IPRange{}}, nil
+	}
+
+	//Build query
+	query := fmt.Sprintf(THREATRANGESQUERY, s.column(LASTSEEN), escape(threat), countryFilter(country)+asnFilter(asn)+s.freshness(FRESHFILTER), limit)
+	s.logger().DebugContext(ctx, QUERY, SQL, query)
+
+	//Fetch results
+	results, err := s.DB.QueryContext(ctx, query)
+	if err != nil {
+		s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
+		return nil, err
+	}
+	defer results.Close()
+
+	//Result carrier
+	rangeList := []*IPRange{}
+
+	// For each row, scan the result into a new range
+	for results.Next() {
+		ipRange := &IPRange{}
+		err = results.Scan(&ipRange.IPFrom, &ipRange.IPTo, &ipRange.CountryCode, &ipRange.LastSeen)
+		if err != nil {
+			//Keep cycling
+			s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
+		} else {
+			rangeList = append(rangeList, ipRange)
+		}
+	}
+
+	return &IPRangeData{
+		RangeList: rangeList,
+		Total:     len(rangeList),
+	}, nil
+}
+
+// MaxAge scopes the service to the ranges seen in the last days, it has no effect on tiers without last_seen
+func (s ServiceImp) MaxAge(days int) Service {
+	s.MaxAgeDays = days
+	return s
+}
+
+// WithContext returns a copy of the service whose spans and queries belong to the request
+func (s ServiceImp) WithContext(ctx context.Context) Service {
+	s.ctx = ctx
+	return s
+}
+
+// logger is the configured logger or the default one
+func (s ServiceImp) logger() *slog.Logger {
+	if s.Logger == nil {
+		return slog.Default()
+	}
+	return s.Logger
+}
+
+// start opens the span of a service call, under the request the service is scoped to
+func (s ServiceImp) start(method string) (context.Context, trace.Span) {
+	ctx := s.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return tracer.Start(ctx, "ServiceImp."+method)
+}
+
+// freshness is the condition leaving out the stale ranges, nothing when every range is kept
+func (s ServiceImp) freshness(condition string) string {
+	if s.MaxAgeDays <= 0 || !s.hasField(LASTSEEN) {
+		return ""
+	}
+	return fmt.Sprintf(condition, s.MaxAgeDays)
+}
+
+// countryFilter narrows a query to a country, nothing when it is empty
+func countryFilter(country string) string {
+	if country == "" {
+		return ""
+	}
+	return fmt.Sprintf(COUNTRYFILTER, escape(country))
+}
+
+// asnFilter narrows a query to an autonomous system, nothing when it is empty
+func asnFilter(asn string) string {
+	if asn == "" {
+		return ""
+	}
+	return fmt.Sprintf(ASNFILTER, escape(asn))
+}
+
+// DetectColumns reads which IPData fields the table has, every package tier from PX1 has a different set.
+// The result goes in ServiceImp.Columns.
+func DetectColumns(db database.Database) ([]string, error) {
+
+	//Fetch results
+	results, err := db.Query(COLUMNSQUERY)
+	if err != nil {
+		slog.Error(QUERYFAILED, ERRORKEY, err)
+		return nil, err
+	}
+	defer results.Close()
+
+	//Known columns by name, the rest of the table is ignored
+	present := make(map[string]bool)
+	for results.Next() {
+		var name string
+		if err := results.Scan(&name); err != nil {
+			slog.Error(QUERYFAILED, ERRORKEY, err)
+			return nil, err
+		}
+		present[strings.ToLower(name)] = true
+	}
+
+	fields := []string{}
+	for _, field := range IPDATAFIELDS {
+		if present[field] {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return nil, NoResultError(COLUMNSQUERY)
+	}
+	return fields, nil
+}
+
+// CountRows counts the ranges of the table, it is reported along with the dataset version
+func CountRows(db database.Database) (int, error) {
+
+	//Fetch results
+	results, err := db.Query(ROWSQUERY)
+	if err != nil {
+		slog.Error(QUERYFAILED, ERRORKEY, err)
+		return 0, err
+	}
+	defer results.Close()
+
+	var rows int
+	if results.Next() {
+		if err := results.Scan(&rows); err != nil {
+			slog.Error(QUERYFAILED, ERRORKEY, err)
+			return 0, err
+		}
+	}
+	return rows, nil
+}
+
+// EachRange reads the whole table in order, every range with the fields of the package tier.
+// It stops at the first error of visit, which is returned.
+func (s ServiceImp) EachRange(visit func(found *IPDataRange) error) error {
+	ctx, span := s.start("EachRange")
+	defer span.End()
+
+	columns := []string{IPFROM, IPTO}
+	for _, field := range s.fields() {
+		columns = append(columns, ipDataColumns[field])
+	}
+	query := fmt.Sprintf(ALLRANGESQUERY, strings.Join(columns, ","))
+	s.logger().DebugContext(ctx, QUERY, SQL, query)
+
+	//Fetch results
+	results, err := s.DB.QueryContext(ctx, query)
+	if err != nil {
+		s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
+		return err
+	}
+	defer results.Close()
+
+	for results.Next() {
+		found := &IPDataRange{Data: &IPData{}}
+		destinations := []interface{}{&found.IPFrom, &found.IPTo}
+		for _, field := range s.fields() {
+			destinations = append(destinations, found.Data.FieldPointer(field))
+		}
+		if err := results.Scan(destinations...); err != nil {
+			s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
+			return err
+		}
+		if err := visit(found); err != nil {
+			return err
+		}
+	}
+	return results.Err()
+}
+
+// EachBounds reads the bounds of every range in order, without their fields.
+// It stops at the first error of visit, which is returned.
+func (s ServiceImp) EachBounds(visit func(from uint32, to uint32) error) error {
+	ctx, span := s.start("EachBounds")
+	defer span.End()
+
+	query := fmt.Sprintf(ALLRANGESQUERY, IPFROM+","+IPTO)
+	s.logger().DebugContext(ctx, QUERY, SQL, query)
+
+	//Fetch results
+	results, err := s.DB.QueryContext(ctx, query)
+	if err != nil {
+		s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
+		return err
+	}
+	defer results.Close()
+
+	var from, to uint32
+	for results.Next() {
+		if err := results.Scan(&from, &to); err != nil {
+			s.logger().ErrorContext(ctx, QUERYFAILED, ERRORKEY, err)
+			return err
+		}
+		if err := visit(from, to); err != nil {
+			return err
+		}
+	}
+	return results.Err()
+}
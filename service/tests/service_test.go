@@ -1,14 +1,18 @@
 package service
 
 import (
+	"bytes"
+	"log/slog"
 	"net"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 
 	"github.com/nullc0rp/go-ip2proxy-api/service"
 
 	"github.com/nullc0rp/go-ip2proxy-api/database"
+	"github.com/nullc0rp/go-ip2proxy-api/logging"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -161,6 +165,42 @@ func TestGetIPCountryNoResult(t *testing.T) {
 	assert.Empty(t, result.IPList, "")
 }
 
+func TestGetCountryRangesHappy(t *testing.T) {
+
+	// Create mock database
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// Expected data result
+	rows := sqlmock.NewRows([]string{"ip_from", "ip_to", "country_name", "region_name"}).
+		AddRow(16778241, 16778241, "Australia", "Victoria").
+		AddRow(16778242, 16778249, "Australia", "Victoria")
+	mock.ExpectQuery("SELECT ip_from,ip_to,country_name,city_name FROM ip2proxy_database where country_code = 'AU' LIMIT 10;").WillReturnRows(rows)
+
+	//Instance services
+	database := &database.DatabaseImpl{
+		Connection: db,
+	}
+
+	service := &service.ServiceImp{
+		DB: database,
+	}
+
+	//Execution
+	result, err := service.GetCountryRanges("AU", 10)
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+	assert.Equal(t, 2, result.Total, "")
+	assert.Equal(t, uint32(16778249), result.RangeList[1].IPTo, "")
+}
+
 func TestGetISPCountryHappy(t *testing.T) {
 
 	// Create mock database
@@ -329,3 +369,660 @@ func TestMostProxyTypesHappy(t *testing.T) {
 
 	assert.Equal(t, 3, len(result.ProxyTypeList), "")
 }
+
+func TestDatasetVersionHappy(t *testing.T) {
+
+	// Create mock database
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// Expected data result
+	rows := sqlmock.NewRows([]string{"version"}).AddRow("2021-06-01 10:00:00")
+	mock.ExpectQuery("SELECT version FROM ip2proxy_metadata").WillReturnRows(rows)
+
+	//Instance services
+	database := &database.DatabaseImpl{
+		Connection: db,
+	}
+
+	service := &service.ServiceImp{
+		DB: database,
+	}
+
+	//Execution
+	result, err := service.DatasetVersion()
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+	assert.Nil(t, err)
+	assert.Equal(t, "2021-06-01 10:00:00", result, "")
+}
+
+func TestDatasetVersionUnknown(t *testing.T) {
+
+	// Create mock database
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// Datasets imported without writing the version have no row
+	rows := sqlmock.NewRows([]string{"version"})
+	mock.ExpectQuery("SELECT version FROM ip2proxy_metadata").WillReturnRows(rows)
+
+	//Instance services
+	database := &database.DatabaseImpl{
+		Connection: db,
+	}
+
+	service := &service.ServiceImp{
+		DB: database,
+	}
+
+	//Execution
+	result, err := service.DatasetVersion()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "unknown", result, "")
+}
+
+func TestDatasetVersionNoTable(t *testing.T) {
+
+	// Create mock database
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// Stock databases don't have the metadata table
+	mock.ExpectQuery("SELECT version FROM ip2proxy_metadata").WillReturnError(&mysql.MySQLError{Number: 1146, Message: "Table 'ip2proxy_database.ip2proxy_metadata' doesn't exist"})
+
+	//Instance services
+	database := &database.DatabaseImpl{
+		Connection: db,
+	}
+
+	service := &service.ServiceImp{
+		DB: database,
+	}
+
+	//Execution
+	result, err := service.DatasetVersion()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "unknown", result, "")
+}
+
+func TestDatasetVersionConfigured(t *testing.T) {
+
+	// Create mock database
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	//Instance services
+	database := &database.DatabaseImpl{
+		Connection: db,
+	}
+
+	service := &service.ServiceImp{
+		DB:      database,
+		Version: "2024-01-01",
+	}
+
+	//Execution, the database is not asked
+	result, err := service.DatasetVersion()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+	assert.Nil(t, err)
+	assert.Equal(t, "2024-01-01", result, "")
+}
+
+func TestGetIPInfoFieldsHappy(t *testing.T) {
+
+	// Create mock database
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// Expected data result, only the selected columns
+	rows := sqlmock.NewRows([]string{"country_code", "proxy_type"}).AddRow("PL", "PUB")
+	mock.ExpectQuery("SELECT country_code,proxy_type FROM ip2proxy_database where ip_from <= 168430081 AND 168430081 <= ip_to;").WillReturnRows(rows)
+
+	//Instance services
+	database := &database.DatabaseImpl{
+		Connection: db,
+	}
+
+	service := &service.ServiceImp{
+		DB: database,
+	}
+
+	//Execution
+	result, err := service.GetIPInfoFields(net.ParseIP("10.10.10.1"), []string{"country_code", "proxy_type"})
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+	assert.Nil(t, err)
+	assert.Equal(t, "PL", result.CountryCode, "")
+	assert.Equal(t, "PUB", result.ProxyType, "")
+	assert.Equal(t, "", result.CityName, "")
+}
+
+func TestGetIPInfoRangeHappy(t *testing.T) {
+
+	// Create mock database
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// Expected data result, the bounds of the range first
+	rows := sqlmock.NewRows([]string{"ip_from", "ip_to", "proxy_type"}).AddRow(168430080, 168430335, "PUB")
+	mock.ExpectQuery("SELECT ip_from,ip_to,proxy_type FROM ip2proxy_database where ip_from <= 168430081 AND 168430081 <= ip_to;").WillReturnRows(rows)
+
+	//Instance services
+	database := &database.DatabaseImpl{
+		Connection: db,
+	}
+
+	service := &service.ServiceImp{
+		DB: database,
+	}
+
+	//Execution
+	result, err := service.GetIPInfoRange(net.ParseIP("10.10.10.1"), []string{"proxy_type"})
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(168430080), result.IPFrom, "")
+	assert.Equal(t, uint32(168430335), result.IPTo, "")
+	assert.Equal(t, "PUB", result.Data.ProxyType, "")
+}
+
+func TestEachRange(t *testing.T) {
+
+	// Create mock database
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// Expected data result, the whole table in order
+	rows := sqlmock.NewRows([]string{"ip_from", "ip_to", "proxy_type", "country_code"}).
+		AddRow(168430080, 168430335, "PUB", "PL").
+		AddRow(168430592, 168430847, "VPN", "DE")
+	mock.ExpectQuery("SELECT ip_from,ip_to,proxy_type,country_code FROM ip2proxy_database ORDER BY ip_from;").WillReturnRows(rows)
+
+	//Instance services
+	database := &database.DatabaseImpl{
+		Connection: db,
+	}
+
+	var from []uint32
+	var countries []string
+	visit := func(found *service.IPDataRange) error {
+		from = append(from, found.IPFrom)
+		countries = append(countries, found.Data.CountryCode)
+		return nil
+	}
+
+	service := &service.ServiceImp{
+		DB:      database,
+		Columns: []string{"proxy_type", "country_code"},
+	}
+
+	//Execution
+	err = service.EachRange(visit)
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{168430080, 168430592}, from, "")
+	assert.Equal(t, []string{"PL", "DE"}, countries, "")
+}
+
+func TestEachBounds(t *testing.T) {
+
+	// Create mock database
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// Expected data result, only the bounds of the whole table in order
+	rows := sqlmock.NewRows([]string{"ip_from", "ip_to"}).
+		AddRow(168430080, 168430335).
+		AddRow(168430592, 168430847)
+	mock.ExpectQuery("SELECT ip_from,ip_to FROM ip2proxy_database ORDER BY ip_from;").WillReturnRows(rows)
+
+	//Instance services
+	database := &database.DatabaseImpl{
+		Connection: db,
+	}
+
+	var bounds [][2]uint32
+	visit := func(from uint32, to uint32) error {
+		bounds = append(bounds, [2]uint32{from, to})
+		return nil
+	}
+
+	service := &service.ServiceImp{
+		DB:      database,
+		Columns: []string{"proxy_type", "country_code"},
+	}
+
+	//Execution
+	err = service.EachBounds(visit)
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+	assert.Nil(t, err)
+	assert.Equal(t, [][2]uint32{{168430080, 168430335}, {168430592, 168430847}}, bounds, "")
+}
+
+func TestParseFields(t *testing.T) {
+
+	fields, err := service.ParseFields(" Proxy_Type,asn,proxy_type")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"proxy_type", "asn"}, fields)
+
+	fields, _ = service.ParseFields("")
+	assert.Equal(t, service.IPDATAFIELDS, fields)
+
+	_, err = service.ParseFields("proxy_type,ip_from")
+	assert.Equal(t, "Unknown field ip_from", err.Error())
+}
+
+func TestDetectColumnsHappy(t *testing.T) {
+
+	// Create mock database
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// Expected data result, a PX1 table
+	rows := sqlmock.NewRows([]string{"COLUMN_NAME"}).AddRow("ip_from").AddRow("ip_to").AddRow("COUNTRY_CODE").AddRow("country_name")
+	mock.ExpectQuery("SELECT COLUMN_NAME FROM information_schema.columns WHERE table_schema = DATABASE\\(\\) AND table_name = 'ip2proxy_database';").WillReturnRows(rows)
+
+	//Instance services
+	database := &database.DatabaseImpl{
+		Connection: db,
+	}
+
+	//Execution
+	result, err := service.DetectColumns(database)
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"country_code", "country_name"}, result, "")
+}
+
+func TestGetIPInfoPX1(t *testing.T) {
+
+	// Create mock database
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// Expected data result, only the columns of the tier
+	rows := sqlmock.NewRows([]string{"country_code", "country_name"}).AddRow("PL", "Poland")
+	mock.ExpectQuery("SELECT country_code,country_name FROM ip2proxy_database where ip_from <= 168430081 AND 168430081 <= ip_to;").WillReturnRows(rows)
+
+	//Instance services
+	database := &database.DatabaseImpl{
+		Connection: db,
+	}
+
+	service := &service.ServiceImp{
+		DB:      database,
+		Columns: []string{"country_code", "country_name"},
+	}
+
+	//Execution
+	result, err := service.GetIPInfo(net.ParseIP("10.10.10.1"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Poland", result.CountryName, "")
+	assert.Equal(t, "", result.ProxyType, "")
+
+	//The tier has no proxy types to count, nothing is queried
+	proxyTypes, err := service.MostProxyTypes()
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+	assert.Nil(t, err)
+	assert.Empty(t, proxyTypes.ProxyTypeList)
+}
+
+func TestGetProvidersHappy(t *testing.T) {
+
+	// Create mock database
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// Expected data result
+	rows := sqlmock.NewRows([]string{"provider", "ranges", "total_ip"}).AddRow("NordVPN", 3, 600).AddRow("ExpressVPN", 1, 10)
+	mock.ExpectQuery("SELECT provider,count\\(\\*\\) as ranges,SUM\\(cast\\(ip_to\\+1 as signed\\)-cast\\(ip_from as signed\\)\\) as total_ip FROM ip2proxy_database where provider NOT IN \\('','-'\\) AND country_code = 'AU' GROUP BY provider ORDER BY total_ip DESC;").WillReturnRows(rows)
+
+	//Instance services
+	database := &database.DatabaseImpl{
+		Connection: db,
+	}
+
+	service := &service.ServiceImp{
+		DB:      database,
+		Columns: service.IPDATAFIELDS,
+	}
+
+	//Execution
+	result, err := service.GetProviders("AU")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(result.ProviderList), "")
+	assert.Equal(t, "NordVPN", result.ProviderList[0].Name, "")
+	assert.Equal(t, 600, result.ProviderList[0].Total, "")
+}
+
+func TestGetProviderRangesEscaped(t *testing.T) {
+
+	// Create mock database
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// Expected data result, the quote in the name stays inside the literal
+	rows := sqlmock.NewRows([]string{"ip_from", "ip_to", "country_code", "last_seen"}).AddRow(16778240, 16778495, "AU", "3")
+	mock.ExpectQuery("SELECT ip_from,ip_to,country_code,last_seen FROM ip2proxy_database where provider = 'Joe''s VPN' ORDER BY ip_from LIMIT 10;").WillReturnRows(rows)
+
+	//Instance services
+	database := &database.DatabaseImpl{
+		Connection: db,
+	}
+
+	service := &service.ServiceImp{
+		DB:      database,
+		Columns: service.IPDATAFIELDS,
+	}
+
+	//Execution
+	result, err := service.GetProviderRanges("Joe's VPN", "", 10)
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(16778495), result.RangeList[0].IPTo, "")
+}
+
+func TestRangeCIDRs(t *testing.T) {
+
+	assert.Equal(t, []string{"0.0.0.0/0"}, service.RangeCIDRs(0, 4294967295))
+	assert.Equal(t, []string{"10.10.10.1/32"}, service.RangeCIDRs(168430081, 168430081))
+	assert.Equal(t, []string{"10.10.10.1/32", "10.10.10.2/31", "10.10.10.4/30", "10.10.10.8/31", "10.10.10.10/32"}, service.RangeCIDRs(168430081, 168430090))
+}
+
+func TestGetThreatRangesFiltered(t *testing.T) {
+
+	// Create mock database
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// Expected data result
+	rows := sqlmock.NewRows([]string{"ip_from", "ip_to", "country_code", "last_seen"}).AddRow(16778240, 16778495, "AU", "3")
+	mock.ExpectQuery("SELECT ip_from,ip_to,country_code,last_seen FROM ip2proxy_database where threat = 'SCANNER' AND country_code = 'AU' AND asn = '1221' ORDER BY ip_from LIMIT 10;").WillReturnRows(rows)
+
+	//Instance services
+	database := &database.DatabaseImpl{
+		Connection: db,
+	}
+
+	service := &service.ServiceImp{
+		DB:      database,
+		Columns: service.IPDATAFIELDS,
+	}
+
+	//Execution
+	result, err := service.GetThreatRanges("SCANNER", "AU", "1221", 10)
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, result.Total, "")
+}
+
+func TestGetThreatsPX7(t *testing.T) {
+
+	// Create mock database
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	//Instance services
+	database := &database.DatabaseImpl{
+		Connection: db,
+	}
+
+	service := &service.ServiceImp{
+		DB: database,
+	}
+
+	//Execution, PX7 has no threats and nothing is queried
+	result, err := service.GetThreats("AU", "")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+	assert.Nil(t, err)
+	assert.Empty(t, result.ThreatList)
+}
+
+func TestGetIPInfoStale(t *testing.T) {
+
+	// Create mock database
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// Expected data result, the age is looked up even when it wasn't asked for
+	rows := sqlmock.NewRows([]string{"proxy_type", "last_seen"}).AddRow("VPN", "45")
+	mock.ExpectQuery("SELECT proxy_type,last_seen FROM ip2proxy_database where ip_from <= 168430081 AND 168430081 <= ip_to;").WillReturnRows(rows)
+	rows = sqlmock.NewRows([]string{"proxy_type", "last_seen"}).AddRow("VPN", "3")
+	mock.ExpectQuery("SELECT proxy_type,last_seen FROM ip2proxy_database where ip_from <= 168430081 AND 168430081 <= ip_to;").WillReturnRows(rows)
+
+	//Instance services
+	database := &database.DatabaseImpl{
+		Connection: db,
+	}
+
+	scoped := (&service.ServiceImp{
+		DB:      database,
+		Columns: service.IPDATAFIELDS,
+	}).MaxAge(30)
+
+	//Execution
+	result, err := scoped.GetIPInfoFields(net.ParseIP("10.10.10.1"), []string{"proxy_type"})
+
+	assert.Nil(t, result)
+	assert.True(t, service.IsNoResult(err))
+	assert.Equal(t, "45", err.(*service.StaleError).LastSeen)
+
+	//A fresh range is found without the age it wasn't asked for
+	result, err = scoped.GetIPInfoFields(net.ParseIP("10.10.10.1"), []string{"proxy_type"})
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+	assert.Nil(t, err)
+	assert.Equal(t, &service.IPData{ProxyType: "VPN"}, result)
+}
+
+func TestMostProxyTypesMaxAge(t *testing.T) {
+
+	// Create mock database
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	// Expected data result
+	rows := sqlmock.NewRows([]string{"proxy_type", "total"}).AddRow("VPN", 10)
+	mock.ExpectQuery("SELECT proxy_type,count\\(proxy_type\\) as total FROM ip2proxy_database where last_seen <= 30 GROUP BY proxy_type ORDER BY total DESC LIMIT 3;").WillReturnRows(rows)
+
+	//Instance services
+	database := &database.DatabaseImpl{
+		Connection: db,
+	}
+
+	scoped := (&service.ServiceImp{
+		DB:      database,
+		Columns: service.IPDATAFIELDS,
+	}).MaxAge(30)
+
+	//Execution
+	result, err := scoped.MostProxyTypes()
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+	assert.Nil(t, err)
+	assert.Equal(t, 10, result.ProxyTypeList[0].Total, "")
+}
+
+func TestCountRows(t *testing.T) {
+
+	// Create mock database
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"count(*)"}).AddRow(3)
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM ip2proxy_database;").WillReturnRows(rows)
+
+	//Instance services
+	database := &database.DatabaseImpl{
+		Connection: db,
+	}
+
+	//Execution
+	result, err := service.CountRows(database)
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, result, "")
+}
+
+func TestQueriesLoggedAtDebug(t *testing.T) {
+
+	// Create mock database
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	for i := 0; i < 2; i++ {
+		rows := sqlmock.NewRows([]string{"total"}).AddRow(256)
+		mock.ExpectQuery("SELECT SUM").WillReturnRows(rows)
+	}
+
+	//Instance services, logging at info and then at debug
+	var buffer bytes.Buffer
+	database := &database.DatabaseImpl{
+		Connection: db,
+	}
+	service := &service.ServiceImp{
+		DB:     database,
+		Logger: logging.NewWriter(&buffer, slog.LevelInfo).For("service"),
+	}
+
+	service.GetCountryTotal("AR")
+	assert.Equal(t, "", buffer.String())
+
+	service.Logger = logging.NewWriter(&buffer, slog.LevelDebug).For("service")
+	service.GetCountryTotal("AR")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+	assert.Contains(t, buffer.String(), "\"level\":\"DEBUG\",\"msg\":\"Query\",\"package\":\"service\",\"sql\":\"SELECT SUM")
+}
@@ -5,11 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math/bits"
 	"net"
+	"strconv"
+	"strings"
 )
 
 const (
 	NORESULTS = "No results for query, %s"
+	STALE     = "last seen %s days ago"
 )
 
 // IP2int converts from IP to integer
@@ -27,9 +31,69 @@ func Int2IP(nn uint32) net.IP {
 	return ip
 }
 
+// RangeCIDRs splits an address range into the fewest CIDR blocks covering it
+func RangeCIDRs(from uint32, to uint32) []string {
+	cidrs := []string{}
+	for start := uint64(from); start <= uint64(to); {
+		//The largest block aligned on start that doesn't go past the end
+		size := bits.TrailingZeros32(uint32(start))
+		if start == 0 {
+			size = 32
+		}
+		for size > 0 && start+(uint64(1)<<size)-1 > uint64(to) {
+			size = size - 1
+		}
+		cidrs = append(cidrs, fmt.Sprintf("%s/%d", Int2IP(uint32(start)), 32-size))
+		start = start + uint64(1)<<size
+	}
+	return cidrs
+}
+
+// escape quotes a value for the string literals of the queries
+func escape(value string) string {
+	return strings.NewReplacer("\\", "\\\\", "'", "''").Replace(value)
+}
+
+// NoResult is returned when the query ran fine but matched nothing
+type NoResult struct {
+	Message string
+}
+
+func (e *NoResult) Error() string {
+	return fmt.Sprintf(NORESULTS, e.Message)
+}
+
+// StaleError is returned for an address whose range wasn't seen lately, it is a no result as well
+type StaleError struct {
+	LastSeen string
+}
+
+func (e *StaleError) Error() string {
+	return fmt.Sprintf(NORESULTS, fmt.Sprintf(STALE, e.LastSeen))
+}
+
+func (e *StaleError) Unwrap() error {
+	return NoResultError(fmt.Sprintf(STALE, e.LastSeen))
+}
+
+// Stale tells if a range last seen that many days ago is older than maxAgeDays, unknown ages are kept
+func Stale(lastSeen string, maxAgeDays int) bool {
+	days, err := strconv.Atoi(lastSeen)
+	if err != nil || maxAgeDays <= 0 {
+		return false
+	}
+	return days > maxAgeDays
+}
+
 //NoResultError custom error for no results
 func NoResultError(message string) error {
-	return errors.New(fmt.Sprintf(NORESULTS, message))
+	return &NoResult{Message: message}
+}
+
+// IsNoResult tells apart an empty result from a failure
+func IsNoResult(err error) bool {
+	var noResult *NoResult
+	return errors.As(err, &noResult)
 }
 
 //LogError self explanatory
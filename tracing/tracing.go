@@ -0,0 +1,115 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/nullc0rp/go-ip2proxy-api/privacy"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	SERVICENAME    = "go-ip2proxy-api"
+	HTTPMETHOD     = "http.request.method"
+	HTTPROUTE      = "http.route"
+	HTTPSTATUSCODE = "http.response.status_code"
+	URLPATH        = "url.path"
+	//NOROUTE names the spans of requests that matched no route
+	NOROUTE = "unmatched"
+)
+
+// tracer names the spans of the requests
+var tracer = otel.Tracer("github.com/nullc0rp/go-ip2proxy-api/tracing")
+
+// Setup exports the spans over OTLP/gRPC to the collector at endpoint, host:port.
+// The returned function flushes the pending spans, it is called on shutdown.
+func Setup(ctx context.Context, endpoint string, insecure bool) (func(context.Context) error, error) {
+	options := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(endpoint)}
+	if insecure {
+		options = append(options, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", SERVICENAME))),
+	)
+	Register(provider)
+	return provider.Shutdown, nil
+}
+
+// Register makes the provider the one of every layer, tests give it an in-memory exporter
+func Register(provider trace.TracerProvider) {
+	otel.SetTracerProvider(provider)
+	Propagate()
+}
+
+// Propagate reads and writes the W3C trace context and baggage headers
+func Propagate() {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+}
+
+// Middleware starts the server span of every request, continuing the trace of the caller from its headers.
+// Spans are named after the route template, the anonymizer rewrites the addresses of the path when not nil.
+func Middleware(anonymizer *privacy.Anonymizer) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return traced(next, anonymizer)
+	}
+}
+
+func traced(next http.Handler, anonymizer *privacy.Anonymizer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		route := NOROUTE
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		ctx, span := tracer.Start(ctx, fmt.Sprintf("%s %s", r.Method, route), trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+			attribute.String(HTTPMETHOD, r.Method),
+			attribute.String(HTTPROUTE, route),
+			attribute.String(URLPATH, anonymizer.String(r.URL.Path)),
+		))
+		defer span.End()
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int(HTTPSTATUSCODE, recorder.status))
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.status))
+		}
+	})
+}
+
+// statusRecorder keeps the status written by the handler, streamed lists still get flushed
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
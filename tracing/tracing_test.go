@@ -0,0 +1,87 @@
+package tracing
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nullc0rp/go-ip2proxy-api/controller"
+	"github.com/nullc0rp/go-ip2proxy-api/database"
+	"github.com/nullc0rp/go-ip2proxy-api/service"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const (
+	TRACEID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	TRACEPARENT = "00-" + TRACEID + "-00f067aa0ba902b7-01"
+)
+
+// spanNamed finds a recorded span by name
+func spanNamed(spans tracetest.SpanStubs, name string) (tracetest.SpanStub, bool) {
+	for _, span := range spans {
+		if span.Name == name {
+			return span, true
+		}
+	}
+	return tracetest.SpanStub{}, false
+}
+
+func TestLookupTrace(t *testing.T) {
+
+	//Spans are kept in memory
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer provider.Shutdown(context.Background())
+	Register(provider)
+
+	// Create mock database
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"proxy_type"}).AddRow("PUB")
+	mock.ExpectQuery("SELECT proxy_type FROM ip2proxy_database where ip_from <= 16909060 AND 16909060 <= ip_to;").WillReturnRows(rows)
+	mock.ExpectQuery("SELECT version FROM ip2proxy_metadata").WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow("2024-01-01 00:00:00"))
+
+	//The layers of the server
+	svc := &service.ServiceImp{DB: &database.DatabaseImpl{Connection: db}}
+	router := controller.NewRouter(&controller.ControllerImpl{Service: svc}, &controller.ControllerV2Impl{Service: svc})
+	router.Use(Middleware(nil))
+
+	r := httptest.NewRequest("GET", "/v2/ip/1.2.3.4?fields=proxy_type", nil)
+	r.Header.Set("traceparent", TRACEPARENT)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+	assert.Equal(t, 200, w.Code)
+
+	spans := exporter.GetSpans()
+	server, ok := spanNamed(spans, "GET /v2/ip/{address:.*}")
+	assert.True(t, ok)
+	handler, ok := spanNamed(spans, "ControllerV2Impl.GetIpInfo")
+	assert.True(t, ok)
+	call, ok := spanNamed(spans, "ServiceImp.GetIPInfoFields")
+	assert.True(t, ok)
+	query, ok := spanNamed(spans, "DatabaseImpl.Query")
+	assert.True(t, ok)
+
+	//The trace of the caller goes on, every layer is a child of the one above
+	assert.Equal(t, TRACEID, server.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent.SpanID().String())
+	assert.Equal(t, server.SpanContext.SpanID(), handler.Parent.SpanID())
+	assert.Equal(t, handler.SpanContext.SpanID(), call.Parent.SpanID())
+	assert.Equal(t, call.SpanContext.SpanID(), query.Parent.SpanID())
+
+	assert.Contains(t, server.Attributes, attribute.Int(HTTPSTATUSCODE, 200))
+	assert.Contains(t, query.Attributes, attribute.String(database.DBSTATEMENT, "SELECT proxy_type FROM ip2proxy_database where ip_from <= 16909060 AND 16909060 <= ip_to;"))
+}